@@ -0,0 +1,112 @@
+// Package audit records OTP validation events as JSON lines, in the same shape
+// as the log/slog JSON handler, so that they can be shipped along with the
+// rest of the application logs.
+//
+// Events never hold the secret key nor the validated token, see otpgo.Event.
+package audit
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"sync"
+	"time"
+
+	"github.com/jltorresm/otpgo"
+)
+
+// DefaultMessage is the message of every line, unless Writer.Message is set.
+const DefaultMessage = "otp validation"
+
+// Levels of the written lines.
+const (
+	LevelInfo = "INFO" // Successful validations
+	LevelWarn = "WARN" // Failed validations
+)
+
+// The Writer type is an otpgo.Observer that writes each event as a JSON line,
+// e.g.:
+//
+//	{"time":"2020-10-20T08:00:00Z","level":"WARN","msg":"otp validation","credentialId":"john","type":"totp","success":false,"reason":"invalid_token"}
+//
+// It is safe for concurrent use.
+type Writer struct {
+	Output  io.Writer // Defaults to ioutil.Discard
+	Message string    // Defaults to DefaultMessage
+
+	mu  sync.Mutex
+	err error
+}
+
+// The line type is the JSON representation of an event.
+type line struct {
+	Time         time.Time `json:"time"`
+	Level        string    `json:"level"`
+	Msg          string    `json:"msg"`
+	CredentialID string    `json:"credentialId,omitempty"`
+	Type         string    `json:"type,omitempty"`
+	Success      bool      `json:"success"`
+	Offset       *int64    `json:"offset,omitempty"` // Only meaningful for successful validations
+	Reason       string    `json:"reason,omitempty"`
+	Generation   string    `json:"generation,omitempty"`
+}
+
+// Observe writes the event. Write errors can't be returned to the validation,
+// the first one is kept and reported by Err.
+func (w *Writer) Observe(e otpgo.Event) {
+	l := line{
+		Time:         e.Time,
+		Level:        LevelWarn,
+		Msg:          w.message(),
+		CredentialID: e.CredentialID,
+		Type:         e.Type,
+		Success:      e.Success,
+		Reason:       e.Reason,
+		Generation:   e.Generation,
+	}
+
+	if e.Success {
+		l.Level = LevelInfo
+		l.Offset = &e.Offset
+	}
+
+	raw, err := json.Marshal(l)
+	if err == nil {
+		raw = append(raw, '\n')
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err == nil {
+		_, err = w.output().Write(raw)
+	}
+
+	if err != nil && w.err == nil {
+		w.err = err
+	}
+}
+
+// Err returns the first error found while writing events.
+func (w *Writer) Err() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.err
+}
+
+func (w *Writer) output() io.Writer {
+	if w.Output == nil {
+		return ioutil.Discard
+	}
+
+	return w.Output
+}
+
+func (w *Writer) message() string {
+	if w.Message == "" {
+		return DefaultMessage
+	}
+
+	return w.Message
+}
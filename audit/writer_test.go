@@ -0,0 +1,103 @@
+package audit
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jltorresm/otpgo"
+)
+
+func TestWriter_Observe(t *testing.T) {
+	at := time.Date(2020, 10, 20, 8, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		label    string
+		message  string
+		event    otpgo.Event
+		expected string
+	}{
+		{
+			"Success",
+			"",
+			otpgo.Event{Time: at, CredentialID: "john", Type: otpgo.TypeHOTP, Success: true, Offset: 0},
+			`{"time":"2020-10-20T08:00:00Z","level":"INFO","msg":"otp validation","credentialId":"john","type":"hotp","success":true,"offset":0}`,
+		},
+		{
+			"Failure",
+			"second factor",
+			otpgo.Event{Time: at, Type: otpgo.TypeTOTP, Reason: otpgo.ReasonReplay},
+			`{"time":"2020-10-20T08:00:00Z","level":"WARN","msg":"second factor","type":"totp","success":false,"reason":"replay"}`,
+		},
+		{
+			"Rotating",
+			"",
+			otpgo.Event{Time: at, CredentialID: "john", Type: otpgo.TypeTOTP, Success: true, Offset: -1, Generation: otpgo.GenerationPrevious},
+			`{"time":"2020-10-20T08:00:00Z","level":"INFO","msg":"otp validation","credentialId":"john","type":"totp","success":true,"offset":-1,"generation":"previous"}`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.label, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			w := &Writer{Output: buf, Message: c.message}
+			w.Observe(c.event)
+
+			if actual := buf.String(); c.expected+"\n" != actual {
+				t.Errorf("unexpected line\nexpected: %s\n  actual: %s", c.expected, actual)
+			}
+		})
+	}
+}
+
+func TestWriter_NoSecrets(t *testing.T) {
+	const key = "73QK7D3A3PIZ6NUQQBF4BNFYQBRVUHUQ"
+
+	buf := &bytes.Buffer{}
+	totp := &otpgo.TOTP{Key: key, ID: "john", Observer: &Writer{Output: buf}}
+	token, _ := totp.Generate()
+
+	_, _ = totp.Validate(token)
+	_, _ = totp.Validate("000000")
+	_, _ = totp.Validate("not a token")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Errorf("unexpected number of lines\nexpected: %d\n  actual: %d", 3, len(lines))
+	}
+
+	for _, secret := range []string{key, token, "000000", "not a token"} {
+		if strings.Contains(buf.String(), secret) {
+			t.Errorf("unexpected secret %q in audit log:\n%s", secret, buf.String())
+		}
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestWriter_Err(t *testing.T) {
+	w := &Writer{Output: failingWriter{}}
+	if w.Err() != nil {
+		t.Errorf("unexpected error: %s", w.Err())
+	}
+
+	w.Observe(otpgo.Event{})
+	if err := w.Err(); err == nil || err.Error() != "disk full" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestWriter_NilOutput(t *testing.T) {
+	w := &Writer{}
+
+	w.Observe(otpgo.Event{Type: otpgo.TypeTOTP, Success: true})
+	if err := w.Err(); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+}
@@ -2,8 +2,9 @@ package authenticator
 
 import (
 	"encoding/base64"
-	"fmt"
 	"net/url"
+	"regexp"
+	"strings"
 
 	"github.com/skip2/go-qrcode"
 )
@@ -13,6 +14,8 @@ const (
 	QRSize = 256
 )
 
+var typePattern = regexp.MustCompile(`^[a-z0-9]+$`)
+
 // The KeyUri type holds all the config necessary to generate a valid
 // registration URI for any authenticator app, e.g.: Google Authenticator, Authy,
 // etc.
@@ -20,21 +23,46 @@ type KeyUri struct {
 	Type       string          `json:"type"` // hotp | totp
 	Label      Label           `json:"label"`
 	Parameters paramsFormatter `json:"parameters"`
+	Extensions Extensions      `json:"extensions"`
 }
 
-// String encodes all the KeyUri info as a valid URI.
+// Build validates the KeyUri and encodes it as a URI compliant with the Key Uri
+// Format. Unlike String, it fails if the result would be ambiguous or could
+// not be interpreted by authenticator apps.
+func (ku *KeyUri) Build() (string, error) {
+	if !typePattern.MatchString(ku.Type) {
+		return "", ErrorInvalidUri{msg: "otp type must be a non-empty lowercase alphanumeric string"}
+	}
+
+	if err := ku.Label.Validate(); err != nil {
+		return "", err
+	}
+
+	if err := ku.Extensions.Validate(); err != nil {
+		return "", err
+	}
+
+	return ku.String(), nil
+}
+
+// String encodes all the KeyUri info as a URI. No validation is performed, use
+// Build to make sure the URI is spec compliant.
 func (ku *KeyUri) String() string {
-	format := "otpauth://%s/%s?%s"
-	raw := fmt.Sprintf(format, ku.Type, ku.Label.String(), ku.Parameters.AsUrlValues(ku.Label.Issuer).Encode())
-	uri, _ := url.Parse(raw)
-	return uri.String()
+	params := ku.Parameters.AsUrlValues(ku.Label.Issuer)
+	ku.Extensions.apply(params)
+
+	return "otpauth://" + ku.Type + "/" + ku.Label.String() + "?" + params.Encode()
 }
 
-// QRCode will encode the value returned by KeyUri.String into a base64
+// QRCode will encode the value returned by KeyUri.Build into a base64
 // encoded image containing a QR code that can be displayed and then scanned by
-// the user. The return value is the base64 encoded image data.
+// the user. The return value is the base64 encoded image data. Key URIs that
+// Build rejects, e.g.: without an account name, are not encoded.
 func (ku *KeyUri) QRCode() (string, error) {
-	uri := ku.String()
+	uri, err := ku.Build()
+	if err != nil {
+		return "", err
+	}
 
 	qr, err := qrcode.New(uri, qrcode.Medium)
 	if err != nil {
@@ -56,9 +84,34 @@ type Label struct {
 }
 
 // The String method will encode the Label in a valid format to be included in
-// the key URI.
+// the key URI. Both the issuer and the account name are percent-encoded, the
+// issuer prefix is omitted when empty.
 func (l *Label) String() string {
-	return fmt.Sprintf("%s:%s", l.Issuer, l.AccountName)
+	account := url.PathEscape(l.AccountName)
+	if l.Issuer == "" {
+		return account
+	}
+
+	return url.PathEscape(l.Issuer) + ":" + account
+}
+
+// Validate checks that the Label can be unambiguously encoded. The account name
+// is required and neither part may contain a colon, since it is the separator
+// between them.
+func (l *Label) Validate() error {
+	if l.AccountName == "" {
+		return ErrorInvalidLabel{Field: "accountName", msg: "must not be empty"}
+	}
+
+	if strings.Contains(l.AccountName, ":") {
+		return ErrorInvalidLabel{Field: "accountName", msg: "must not contain a colon"}
+	}
+
+	if strings.Contains(l.Issuer, ":") {
+		return ErrorInvalidLabel{Field: "issuer", msg: "must not contain a colon"}
+	}
+
+	return nil
 }
 
 // The paramsFormatter type has the ability to encode the OTP params into
@@ -1,7 +1,12 @@
 package authenticator
 
 import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/png"
 	"net/url"
+	"strings"
 	"testing"
 )
 
@@ -15,15 +20,52 @@ func (mf mockFormatter) AsUrlValues(issuer string) url.Values {
 }
 
 func TestLabel_String(t *testing.T) {
-	l := Label{
-		AccountName: "$0mbody@nowh3re.net",
-		Issuer:      "Nowhere Inc.",
+	cases := []struct {
+		label    string
+		name     string
+		issuer   string
+		expected string
+	}{
+		{"Plain", "$0mbody@nowh3re.net", "Nowhere Inc.", "Nowhere%20Inc.:$0mbody@nowh3re.net"},
+		{"No Issuer", "john", "", "john"},
+		{"Reserved Chars", "a/b?c#d%e", "x/y", "x%2Fy:a%2Fb%3Fc%23d%25e"},
+		{"Unicode", "jòhn", "Ñandú", "%C3%91and%C3%BA:j%C3%B2hn"},
 	}
 
-	expected := "Nowhere Inc.:$0mbody@nowh3re.net"
+	for _, c := range cases {
+		t.Run(c.label, func(t *testing.T) {
+			l := Label{AccountName: c.name, Issuer: c.issuer}
 
-	if expected != l.String() {
-		t.Errorf("unexpected string\nexpected: %s\n  actual: %s", expected, l.String())
+			if c.expected != l.String() {
+				t.Errorf("unexpected string\nexpected: %s\n  actual: %s", c.expected, l.String())
+			}
+		})
+	}
+}
+
+func TestLabel_Validate(t *testing.T) {
+	cases := []struct {
+		label       string
+		name        string
+		issuer      string
+		expectedErr error
+	}{
+		{"Valid", "john@example.com", "Acme", nil},
+		{"No Issuer", "john@example.com", "", nil},
+		{"Empty Account", "", "Acme", ErrorInvalidLabel{Field: "accountName", msg: "must not be empty"}},
+		{"Colon Account", "john:doe", "Acme", ErrorInvalidLabel{Field: "accountName", msg: "must not contain a colon"}},
+		{"Colon Issuer", "john", "Acme:Corp", ErrorInvalidLabel{Field: "issuer", msg: "must not contain a colon"}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.label, func(t *testing.T) {
+			l := Label{AccountName: c.name, Issuer: c.issuer}
+
+			err := l.Validate()
+			if c.expectedErr != err {
+				t.Errorf("unexpected error\nexpected: %v\n  actual: %v", c.expectedErr, err)
+			}
+		})
 	}
 }
 
@@ -34,6 +76,7 @@ func TestKeyUri_String(t *testing.T) {
 		issuer   string
 		otpType  string
 		params   paramsFormatter
+		ext      Extensions
 		expected string
 	}{
 		{
@@ -42,6 +85,7 @@ func TestKeyUri_String(t *testing.T) {
 			"company",
 			"mock",
 			mockFormatter("vanilla"),
+			Extensions{},
 			"otpauth://mock/company:john?issuer=company&self=vanilla",
 		},
 		{
@@ -50,8 +94,22 @@ func TestKeyUri_String(t *testing.T) {
 			"Example Co.",
 			"test",
 			mockFormatter("$p3c!al C#4rs"),
+			Extensions{},
 			"otpauth://test/Example%20Co.:J0hn@example.com?issuer=Example+Co.&self=%24p3c%21al+C%234rs",
 		},
+		{
+			"Extensions",
+			"john",
+			"company",
+			"mock",
+			mockFormatter("vanilla"),
+			Extensions{
+				Image:  "https://example.com/a b.png",
+				Color:  "ffffff",
+				Custom: map[string]string{"tag": "a&b"},
+			},
+			"otpauth://mock/company:john?color=ffffff&image=https%3A%2F%2Fexample.com%2Fa+b.png&issuer=company&self=vanilla&tag=a%26b",
+		},
 	}
 
 	for _, c := range cases {
@@ -62,6 +120,7 @@ func TestKeyUri_String(t *testing.T) {
 				Issuer:      c.issuer,
 			},
 			Parameters: c.params,
+			Extensions: c.ext,
 		}
 
 		kus := ku.String()
@@ -106,7 +165,152 @@ func TestKeyUri_QRCode(t *testing.T) {
 		t.FailNow()
 	}
 
-	if expected != qr {
-		t.Errorf("unexpected qr\nexpected: %s\n  actual: %s", expected, qr)
+	// The compressed PNG bytes depend on the Go version, compare the pixels.
+	expectedImg, actualImg := decodeDataUri(t, expected), decodeDataUri(t, qr)
+
+	if expectedImg.Bounds() != actualImg.Bounds() {
+		t.Errorf("unexpected qr size\nexpected: %v\n  actual: %v", expectedImg.Bounds(), actualImg.Bounds())
+		t.FailNow()
+	}
+
+	b := expectedImg.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			er, eg, eb, ea := expectedImg.At(x, y).RGBA()
+			ar, ag, ab, aa := actualImg.At(x, y).RGBA()
+			if er != ar || eg != ag || eb != ab || ea != aa {
+				t.Errorf("unexpected qr pixel at (%d, %d)\nexpected: %s\n  actual: %s", x, y, expected, qr)
+				t.FailNow()
+			}
+		}
+	}
+}
+
+func decodeDataUri(t *testing.T, dataUri string) image.Image {
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(dataUri, "data:image/png;base64,"))
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		t.FailNow()
+	}
+
+	img, err := png.Decode(bytes.NewReader(raw))
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		t.FailNow()
+	}
+
+	return img
+}
+
+func TestKeyUri_Build(t *testing.T) {
+	cases := []struct {
+		label       string
+		otpType     string
+		name        string
+		issuer      string
+		ext         Extensions
+		expected    string
+		expectedErr error
+	}{
+		{
+			"Valid",
+			"totp",
+			"alice@google.com",
+			"Example",
+			Extensions{},
+			"otpauth://totp/Example:alice@google.com?issuer=Example&self=vanilla",
+			nil,
+		},
+		{
+			"Reserved Chars",
+			"totp",
+			"50%/off?#1",
+			"A&B",
+			Extensions{},
+			"otpauth://totp/A&B:50%25%2Foff%3F%231?issuer=A%26B&self=vanilla",
+			nil,
+		},
+		{
+			"Bad Type",
+			"TOTP/x",
+			"alice",
+			"Example",
+			Extensions{},
+			"",
+			ErrorInvalidUri{msg: "otp type must be a non-empty lowercase alphanumeric string"},
+		},
+		{
+			"Colon",
+			"totp",
+			"alice",
+			"Example:Inc",
+			Extensions{},
+			"",
+			ErrorInvalidLabel{Field: "issuer", msg: "must not contain a colon"},
+		},
+		{
+			"Bad Extension",
+			"totp",
+			"alice",
+			"Example",
+			Extensions{Image: "ftp://example.com/a.png"},
+			"",
+			ErrorInvalidParameter{Name: "image", msg: "must be an absolute http(s) url"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.label, func(t *testing.T) {
+			ku := KeyUri{
+				Type:       c.otpType,
+				Label:      Label{AccountName: c.name, Issuer: c.issuer},
+				Parameters: mockFormatter("vanilla"),
+				Extensions: c.ext,
+			}
+
+			uri, err := ku.Build()
+
+			if c.expectedErr != err {
+				t.Errorf("unexpected error\nexpected: %v\n  actual: %v", c.expectedErr, err)
+			}
+
+			if c.expected != uri {
+				t.Errorf("unexpected uri\nexpected: %s\n  actual: %s", c.expected, uri)
+			}
+
+			if err != nil {
+				return
+			}
+
+			parsed, err := ParseKeyUri(uri + "&secret=ABC")
+			if err != nil {
+				t.Errorf("unexpected error: %s", err)
+				t.FailNow()
+			}
+
+			if parsed.Label != ku.Label {
+				t.Errorf("unexpected parsed label\nexpected: %+v\n  actual: %+v", ku.Label, parsed.Label)
+			}
+		})
+	}
+}
+
+func TestKeyUri_QRCodeInvalidExtension(t *testing.T) {
+	ku := KeyUri{
+		Type:       "mock",
+		Label:      Label{AccountName: "john", Issuer: "company"},
+		Parameters: mockFormatter("vanilla"),
+		Extensions: Extensions{Color: "blue"},
+	}
+
+	qr, err := ku.QRCode()
+
+	expectedErr := ErrorInvalidParameter{Name: "color", msg: "must be 6 hexadecimal digits"}
+	if expectedErr != err {
+		t.Errorf("unexpected error\nexpected: %v\n  actual: %v", expectedErr, err)
+	}
+
+	if qr != "" {
+		t.Errorf("expected empty qr, got: %s", qr)
 	}
 }
@@ -0,0 +1,36 @@
+package authenticator
+
+import (
+	"fmt"
+)
+
+// The ErrorInvalidParameter represents a key URI parameter that can not be
+// safely encoded or that holds an unexpected value.
+type ErrorInvalidParameter struct {
+	Name string
+	msg  string
+}
+
+func (eip ErrorInvalidParameter) Error() string {
+	return fmt.Sprintf("invalid parameter %q: %s", eip.Name, eip.msg)
+}
+
+// The ErrorInvalidUri represents a string that can not be parsed as a key URI.
+type ErrorInvalidUri struct {
+	msg string
+}
+
+func (eiu ErrorInvalidUri) Error() string {
+	return fmt.Sprintf("invalid key uri: %s", eiu.msg)
+}
+
+// The ErrorInvalidLabel represents a label that can not be unambiguously
+// encoded in a key URI.
+type ErrorInvalidLabel struct {
+	Field string
+	msg   string
+}
+
+func (eil ErrorInvalidLabel) Error() string {
+	return fmt.Sprintf("invalid label %s: %s", eil.Field, eil.msg)
+}
@@ -0,0 +1,32 @@
+package authenticator
+
+import (
+	"testing"
+)
+
+func TestErrorInvalidParameter_Error(t *testing.T) {
+	err := ErrorInvalidParameter{Name: "color", msg: "an arbitrary error message"}
+	expectedError := `invalid parameter "color": an arbitrary error message`
+
+	if err.Error() != expectedError {
+		t.Errorf("unexpected error\nexpected: %s\n  actual: %s", expectedError, err.Error())
+	}
+}
+
+func TestErrorInvalidUri_Error(t *testing.T) {
+	err := ErrorInvalidUri{msg: "an arbitrary error message"}
+	expectedError := "invalid key uri: an arbitrary error message"
+
+	if err.Error() != expectedError {
+		t.Errorf("unexpected error\nexpected: %s\n  actual: %s", expectedError, err.Error())
+	}
+}
+
+func TestErrorInvalidLabel_Error(t *testing.T) {
+	err := ErrorInvalidLabel{Field: "issuer", msg: "an arbitrary error message"}
+	expectedError := "invalid label issuer: an arbitrary error message"
+
+	if err.Error() != expectedError {
+		t.Errorf("unexpected error\nexpected: %s\n  actual: %s", expectedError, err.Error())
+	}
+}
@@ -0,0 +1,140 @@
+package authenticator
+
+import (
+	"net/url"
+	"regexp"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Names of the parameters understood by authenticator apps that extend the
+// Key Uri Format, e.g.: FreeOTP, Aegis, etc.
+const (
+	ParamImage = "image"
+	ParamColor = "color"
+	ParamIcon  = "icon"
+)
+
+// otpParams lists the parameters owned by the OTP configuration, these can not
+// be overridden by any extension.
+var otpParams = map[string]bool{
+	"secret":    true,
+	"issuer":    true,
+	"algorithm": true,
+	"digits":    true,
+	"counter":   true,
+	"period":    true,
+}
+
+var (
+	colorPattern     = regexp.MustCompile(`^[0-9A-Fa-f]{6}$`)
+	customKeyPattern = regexp.MustCompile(`^[A-Za-z0-9._~-]+$`)
+)
+
+// The Extensions type holds the optional key URI parameters that are not part
+// of the OTP configuration. They are ignored by apps that don't support them.
+type Extensions struct {
+	Image  string            `json:"image,omitempty"`  // Absolute http(s) URL of the account image
+	Color  string            `json:"color,omitempty"`  // Background color as RRGGBB hex digits
+	Icon   string            `json:"icon,omitempty"`   // Name of a well known icon
+	Custom map[string]string `json:"custom,omitempty"` // Any vendor specific parameter
+}
+
+// Validate checks that every extension holds a value that can be properly
+// encoded in a key URI and that no custom parameter shadows a known one.
+func (e *Extensions) Validate() error {
+	if e.Image != "" {
+		u, err := url.Parse(e.Image)
+		if err != nil {
+			return ErrorInvalidParameter{Name: ParamImage, msg: err.Error()}
+		}
+		if !u.IsAbs() || (u.Scheme != "http" && u.Scheme != "https") {
+			return ErrorInvalidParameter{Name: ParamImage, msg: "must be an absolute http(s) url"}
+		}
+	}
+
+	if e.Color != "" && !colorPattern.MatchString(e.Color) {
+		return ErrorInvalidParameter{Name: ParamColor, msg: "must be 6 hexadecimal digits"}
+	}
+
+	if err := validateValue(ParamIcon, e.Icon); err != nil {
+		return err
+	}
+
+	for key, value := range e.Custom {
+		if !customKeyPattern.MatchString(key) {
+			return ErrorInvalidParameter{Name: key, msg: "name must only contain unreserved characters"}
+		}
+		if otpParams[key] || key == ParamImage || key == ParamColor || key == ParamIcon {
+			return ErrorInvalidParameter{Name: key, msg: "name is reserved"}
+		}
+		if err := validateValue(key, value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// apply adds all non-empty extensions to the given parameters.
+func (e *Extensions) apply(params url.Values) {
+	if e.Image != "" {
+		params.Set(ParamImage, e.Image)
+	}
+
+	if e.Color != "" {
+		params.Set(ParamColor, e.Color)
+	}
+
+	if e.Icon != "" {
+		params.Set(ParamIcon, e.Icon)
+	}
+
+	for key, value := range e.Custom {
+		params.Set(key, value)
+	}
+}
+
+// splitExtensions separates the extensions from the OTP parameters.
+func splitExtensions(all url.Values) (Extensions, Values) {
+	ext := Extensions{}
+	params := Values{}
+
+	for key := range all {
+		value := all.Get(key)
+
+		switch {
+		case otpParams[key]:
+			params[key] = []string{value}
+		case key == ParamImage:
+			ext.Image = value
+		case key == ParamColor:
+			ext.Color = value
+		case key == ParamIcon:
+			ext.Icon = value
+		default:
+			if ext.Custom == nil {
+				ext.Custom = map[string]string{}
+			}
+			ext.Custom[key] = value
+		}
+	}
+
+	return ext, params
+}
+
+// validateValue ensures a parameter value is valid UTF-8 text without control
+// characters.
+func validateValue(name, value string) error {
+	if !utf8.ValidString(value) {
+		return ErrorInvalidParameter{Name: name, msg: "value must be valid utf-8"}
+	}
+
+	for _, r := range value {
+		if unicode.IsControl(r) {
+			return ErrorInvalidParameter{Name: name, msg: "value must not contain control characters"}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,114 @@
+package authenticator
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestExtensions_Validate(t *testing.T) {
+	cases := []struct {
+		label       string
+		ext         Extensions
+		expectedErr error
+	}{
+		{"Empty", Extensions{}, nil},
+		{
+			"All Known",
+			Extensions{Image: "https://example.com/logo.png", Color: "00ff7A", Icon: "github"},
+			nil,
+		},
+		{
+			"Custom",
+			Extensions{Custom: map[string]string{"x-vendor.tag_1~": "Some value ñ"}},
+			nil,
+		},
+		{
+			"Relative Image",
+			Extensions{Image: "/logo.png"},
+			ErrorInvalidParameter{Name: "image", msg: "must be an absolute http(s) url"},
+		},
+		{
+			"Image Scheme",
+			Extensions{Image: "javascript:alert(1)"},
+			ErrorInvalidParameter{Name: "image", msg: "must be an absolute http(s) url"},
+		},
+		{
+			"Short Color",
+			Extensions{Color: "fff"},
+			ErrorInvalidParameter{Name: "color", msg: "must be 6 hexadecimal digits"},
+		},
+		{
+			"Hash Color",
+			Extensions{Color: "#ffffff"},
+			ErrorInvalidParameter{Name: "color", msg: "must be 6 hexadecimal digits"},
+		},
+		{
+			"Control Icon",
+			Extensions{Icon: "git\nhub"},
+			ErrorInvalidParameter{Name: "icon", msg: "value must not contain control characters"},
+		},
+		{
+			"Invalid UTF-8",
+			Extensions{Custom: map[string]string{"tag": "\xff"}},
+			ErrorInvalidParameter{Name: "tag", msg: "value must be valid utf-8"},
+		},
+		{
+			"Custom Key Needs Encoding",
+			Extensions{Custom: map[string]string{"a key": "value"}},
+			ErrorInvalidParameter{Name: "a key", msg: "name must only contain unreserved characters"},
+		},
+		{
+			"Shadow OTP Param",
+			Extensions{Custom: map[string]string{"secret": "value"}},
+			ErrorInvalidParameter{Name: "secret", msg: "name is reserved"},
+		},
+		{
+			"Shadow Extension",
+			Extensions{Custom: map[string]string{"image": "value"}},
+			ErrorInvalidParameter{Name: "image", msg: "name is reserved"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.label, func(t *testing.T) {
+			err := c.ext.Validate()
+
+			if c.expectedErr != err {
+				t.Errorf("unexpected error\nexpected: %v\n  actual: %v", c.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestSplitExtensions(t *testing.T) {
+	all := url.Values{
+		"secret": {"ABC"},
+		"digits": {"6"},
+		"image":  {"https://example.com/a.png"},
+		"color":  {"ff0000"},
+		"icon":   {"acme"},
+		"tags":   {"work"},
+	}
+
+	ext, params := splitExtensions(all)
+
+	expectedExt := Extensions{
+		Image:  "https://example.com/a.png",
+		Color:  "ff0000",
+		Icon:   "acme",
+		Custom: map[string]string{"tags": "work"},
+	}
+
+	if ext.Image != expectedExt.Image || ext.Color != expectedExt.Color || ext.Icon != expectedExt.Icon {
+		t.Errorf("unexpected extensions\nexpected: %+v\n  actual: %+v", expectedExt, ext)
+	}
+
+	if len(ext.Custom) != 1 || ext.Custom["tags"] != "work" {
+		t.Errorf("unexpected custom extensions\nexpected: %v\n  actual: %v", expectedExt.Custom, ext.Custom)
+	}
+
+	expectedParams := "digits=6&secret=ABC"
+	if encoded := url.Values(params).Encode(); expectedParams != encoded {
+		t.Errorf("unexpected params\nexpected: %s\n  actual: %s", expectedParams, encoded)
+	}
+}
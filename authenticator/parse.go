@@ -0,0 +1,120 @@
+package authenticator
+
+import (
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/jltorresm/otpgo/qr"
+)
+
+// The Values type holds the raw OTP parameters found when parsing a key URI.
+// It allows a parsed KeyUri to be encoded again without knowing the concrete
+// OTP configuration it came from.
+type Values url.Values
+
+// AsUrlValues returns a copy of the raw parameters with the given issuer.
+func (v Values) AsUrlValues(issuer string) url.Values {
+	params := url.Values{}
+	for key, values := range v {
+		params[key] = append([]string{}, values...)
+	}
+
+	if issuer != "" {
+		params.Set("issuer", issuer)
+	}
+
+	return params
+}
+
+// ParseKeyUri decodes a key URI in the otpauth format. The OTP parameters are
+// kept as Values, while any other parameter is preserved as an extension.
+// Extensions are kept as found, even when other apps use them differently,
+// e.g.: a relative image path; they are only validated by KeyUri.Build.
+func ParseKeyUri(uri string) (*KeyUri, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, ErrorInvalidUri{msg: err.Error()}
+	}
+
+	if u.Scheme != "otpauth" {
+		return nil, ErrorInvalidUri{msg: "scheme must be otpauth"}
+	}
+
+	if u.Host == "" {
+		return nil, ErrorInvalidUri{msg: "missing otp type"}
+	}
+
+	label, err := parseLabel(u.EscapedPath())
+	if err != nil {
+		return nil, err
+	}
+
+	query, err := url.ParseQuery(u.RawQuery)
+	if err != nil {
+		return nil, ErrorInvalidUri{msg: err.Error()}
+	}
+
+	ext, params := splitExtensions(query)
+
+	if params.AsUrlValues("").Get("secret") == "" {
+		return nil, ErrorInvalidUri{msg: "missing secret"}
+	}
+
+	// The issuer parameter takes precedence over the label prefix.
+	if issuer := query.Get("issuer"); issuer != "" {
+		label.Issuer = issuer
+	}
+	delete(params, "issuer")
+
+	return &KeyUri{
+		Type:       strings.ToLower(u.Host),
+		Label:      label,
+		Parameters: params,
+		Extensions: ext,
+	}, nil
+}
+
+// ParseQRCode reads a PNG or JPEG image of a QR code, such as the ones made
+// by KeyUri.QRCode, and decodes the key URI it holds.
+func ParseQRCode(r io.Reader) (*KeyUri, error) {
+	uri, err := qr.DecodeReader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseKeyUri(uri)
+}
+
+// parseLabel decodes an escaped label path. The issuer and account name may be
+// separated by either a literal or an encoded colon.
+func parseLabel(path string) (Label, error) {
+	path = strings.TrimPrefix(path, "/")
+
+	var issuer, account string
+	i, n := strings.Index(path, ":"), 1
+	if j := strings.Index(strings.ToLower(path), "%3a"); j >= 0 && (i < 0 || j < i) {
+		i, n = j, 3
+	}
+
+	if i >= 0 {
+		issuer, account = path[:i], path[i+n:]
+	} else {
+		account = path
+	}
+
+	issuer, err := url.PathUnescape(issuer)
+	if err != nil {
+		return Label{}, ErrorInvalidUri{msg: err.Error()}
+	}
+
+	account, err = url.PathUnescape(account)
+	if err != nil {
+		return Label{}, ErrorInvalidUri{msg: err.Error()}
+	}
+
+	// Spaces are allowed between the separator and the account name.
+	account = strings.TrimLeft(account, " ")
+
+	return Label{AccountName: account, Issuer: issuer}, nil
+}
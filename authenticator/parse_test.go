@@ -0,0 +1,220 @@
+package authenticator
+
+import (
+	"bytes"
+	"encoding/base64"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jltorresm/otpgo/qr"
+)
+
+func TestValues_AsUrlValues(t *testing.T) {
+	v := Values{"secret": {"ABC"}, "issuer": {"Old"}}
+
+	params := v.AsUrlValues("New")
+
+	expected := "issuer=New&secret=ABC"
+	if expected != params.Encode() {
+		t.Errorf("unexpected params\nexpected: %s\n  actual: %s", expected, params.Encode())
+	}
+
+	if v["issuer"][0] != "Old" {
+		t.Error("expected the original values to remain untouched")
+	}
+}
+
+func TestParseKeyUri(t *testing.T) {
+	cases := []struct {
+		label           string
+		uri             string
+		expectedType    string
+		expectedIssuer  string
+		expectedAccount string
+		expectedExt     Extensions
+	}{
+		{
+			"Plain",
+			"otpauth://totp/ACME%20Co:john.doe@email.com?secret=HXDMVJECJJWSRB3HWIZR4IFUGFTMXBOZ&issuer=ACME%20Co&algorithm=SHA1&digits=6&period=30",
+			"totp",
+			"ACME Co",
+			"john.doe@email.com",
+			Extensions{},
+		},
+		{
+			"Encoded Separator",
+			"otpauth://hotp/Example%3A%20alice%40google.com?secret=JBSWY3DPEHPK3PXP&counter=3",
+			"hotp",
+			"Example",
+			"alice@google.com",
+			Extensions{},
+		},
+		{
+			"No Issuer",
+			"otpauth://TOTP/alice?secret=JBSWY3DPEHPK3PXP",
+			"totp",
+			"",
+			"alice",
+			Extensions{},
+		},
+		{
+			"Issuer Parameter Wins",
+			"otpauth://totp/Label:alice?secret=JBSWY3DPEHPK3PXP&issuer=Param",
+			"totp",
+			"Param",
+			"alice",
+			Extensions{},
+		},
+		{
+			"Extensions",
+			"otpauth://totp/Acme:bob?secret=JBSWY3DPEHPK3PXP&image=https%3A%2F%2Fexample.com%2Flogo.png&color=00FF00&icon=acme&x-tag=work",
+			"totp",
+			"Acme",
+			"bob",
+			Extensions{
+				Image:  "https://example.com/logo.png",
+				Color:  "00FF00",
+				Icon:   "acme",
+				Custom: map[string]string{"x-tag": "work"},
+			},
+		},
+		{
+			"Non Standard Extensions",
+			"otpauth://totp/Acme:bob?secret=JBSWY3DPEHPK3PXP&image=logo.png&color=red",
+			"totp",
+			"Acme",
+			"bob",
+			Extensions{Image: "logo.png", Color: "red"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.label, func(t *testing.T) {
+			ku, err := ParseKeyUri(c.uri)
+			if err != nil {
+				t.Errorf("unexpected error: %s", err)
+				t.FailNow()
+			}
+
+			if c.expectedType != ku.Type {
+				t.Errorf("unexpected type\nexpected: %s\n  actual: %s", c.expectedType, ku.Type)
+			}
+
+			if c.expectedIssuer != ku.Label.Issuer {
+				t.Errorf("unexpected issuer\nexpected: %s\n  actual: %s", c.expectedIssuer, ku.Label.Issuer)
+			}
+
+			if c.expectedAccount != ku.Label.AccountName {
+				t.Errorf("unexpected account\nexpected: %s\n  actual: %s", c.expectedAccount, ku.Label.AccountName)
+			}
+
+			ext := ku.Extensions
+			if c.expectedExt.Image != ext.Image || c.expectedExt.Color != ext.Color || c.expectedExt.Icon != ext.Icon {
+				t.Errorf("unexpected extensions\nexpected: %+v\n  actual: %+v", c.expectedExt, ext)
+			}
+
+			for key, value := range c.expectedExt.Custom {
+				if ext.Custom[key] != value {
+					t.Errorf("unexpected custom extension %s\nexpected: %s\n  actual: %s", key, value, ext.Custom[key])
+				}
+			}
+		})
+	}
+}
+
+func TestParseKeyUri_RoundTrip(t *testing.T) {
+	uri := "otpauth://totp/Acme:bob?color=00FF00&icon=acme&image=https%3A%2F%2Fexample.com%2Flogo.png&issuer=Acme&secret=JBSWY3DPEHPK3PXP&x-tag=work"
+
+	ku, err := ParseKeyUri(uri)
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		t.FailNow()
+	}
+
+	if uri != ku.String() {
+		t.Errorf("unexpected uri\nexpected: %s\n  actual: %s", uri, ku.String())
+	}
+}
+
+func TestParseKeyUri_BuildInvalidExtensions(t *testing.T) {
+	ku, err := ParseKeyUri("otpauth://totp/alice?secret=JBSWY3DPEHPK3PXP&color=red")
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		t.FailNow()
+	}
+
+	expectedErr := ErrorInvalidParameter{Name: "color", msg: "must be 6 hexadecimal digits"}
+	if _, err := ku.Build(); expectedErr != err {
+		t.Errorf("unexpected error\nexpected: %v\n  actual: %v", expectedErr, err)
+	}
+}
+
+func TestParseKeyUri_Errors(t *testing.T) {
+	cases := []struct {
+		label       string
+		uri         string
+		expectedErr error
+	}{
+		{"Scheme", "https://totp/alice?secret=ABC", ErrorInvalidUri{msg: "scheme must be otpauth"}},
+		{"Missing Type", "otpauth:///alice?secret=ABC", ErrorInvalidUri{msg: "missing otp type"}},
+		{"Missing Secret", "otpauth://totp/alice?digits=6", ErrorInvalidUri{msg: "missing secret"}},
+		{"Bad Label", "otpauth://totp/al%ZZice?secret=ABC", ErrorInvalidUri{msg: `parse "otpauth://totp/al%ZZice?secret=ABC": invalid URL escape "%ZZ"`}},
+		{"Bad Query", "otpauth://totp/alice?secret=A%ZZ", ErrorInvalidUri{msg: `invalid URL escape "%ZZ"`}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.label, func(t *testing.T) {
+			ku, err := ParseKeyUri(c.uri)
+
+			if c.expectedErr != err {
+				t.Errorf("unexpected error\nexpected: %v\n  actual: %v", c.expectedErr, err)
+			}
+
+			if ku != nil {
+				t.Errorf("expected no key uri, got: %+v", ku)
+			}
+		})
+	}
+}
+
+func TestParseQRCode(t *testing.T) {
+	uri := "otpauth://totp/Acme:bob?digits=8&issuer=Acme&period=60&secret=JBSWY3DPEHPK3PXP"
+
+	ku, err := ParseKeyUri(uri)
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		t.FailNow()
+	}
+
+	image, err := ku.QRCode()
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		t.FailNow()
+	}
+
+	png, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(image, "data:image/png;base64,"))
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		t.FailNow()
+	}
+
+	parsed, err := ParseQRCode(bytes.NewReader(png))
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		t.FailNow()
+	}
+
+	if parsed.Label != ku.Label {
+		t.Errorf("unexpected label\nexpected: %+v\n  actual: %+v", ku.Label, parsed.Label)
+	}
+
+	if uri != parsed.String() {
+		t.Errorf("unexpected uri\nexpected: %s\n  actual: %s", uri, parsed.String())
+	}
+
+	_, err = ParseQRCode(strings.NewReader("not an image"))
+	if reflect.TypeOf(err) != reflect.TypeOf(qr.ErrorInvalidImage{}) {
+		t.Errorf("unexpected error\nexpected: %T\n  actual: %T", qr.ErrorInvalidImage{}, err)
+	}
+}
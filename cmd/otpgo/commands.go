@@ -0,0 +1,300 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jltorresm/otpgo"
+	"github.com/skip2/go-qrcode"
+)
+
+// runGen creates a new random secret and prints it along with its key URI and
+// a QR code that can be scanned from the terminal.
+func runGen(e *env, args []string) (int, error) {
+	of := &otpFlags{}
+	fs := newFlagSet("gen", e)
+	of.register(fs)
+	noQR := fs.Bool("no-qr", false, "do not print the terminal qr code")
+	if err := fs.Parse(args); err != nil {
+		return exitError, nil
+	}
+
+	if of.account == "" {
+		return exitError, errMissingAccount
+	}
+
+	key, err := otpgo.RandomKey()
+	if err != nil {
+		return exitError, err
+	}
+
+	o, err := of.build(key)
+	if err != nil {
+		return exitError, err
+	}
+
+	uri, err := o.KeyUri(of.account, of.issuer).Build()
+	if err != nil {
+		return exitError, err
+	}
+
+	secret := keyOf(o)
+
+	if of.json {
+		return exitOK, writeJSON(e.stdout, map[string]string{"type": of.otpType, "secret": secret, "uri": uri})
+	}
+
+	fmt.Fprintf(e.stdout, "secret: %s\nuri:    %s\n", secret, uri)
+
+	if !*noQR {
+		qr, err := qrcode.New(uri, qrcode.Medium)
+		if err != nil {
+			return exitError, err
+		}
+		fmt.Fprint(e.stdout, qr.ToSmallString(false))
+	}
+
+	return exitOK, nil
+}
+
+// runCode prints the current code for the input secret or key URI.
+func runCode(e *env, args []string) (int, error) {
+	of := &otpFlags{}
+	fs := newFlagSet("code", e)
+	of.register(fs)
+	if err := fs.Parse(args); err != nil {
+		return exitError, nil
+	}
+
+	in, err := readInput(e, of)
+	if err != nil {
+		return exitError, err
+	}
+
+	code, err := in.otp.Generate()
+	if err != nil {
+		return exitError, err
+	}
+
+	result := map[string]interface{}{"code": code}
+	switch o := in.otp.(type) {
+	case *otpgo.TOTP:
+		period := int64(o.Period)
+		result["expiresIn"] = period - (time.Now().Unix()-o.T0)%period
+	case *otpgo.HOTP:
+		result["counter"] = o.Counter
+	}
+
+	if of.json {
+		return exitOK, writeJSON(e.stdout, result)
+	}
+
+	fmt.Fprintln(e.stdout, code)
+
+	return exitOK, nil
+}
+
+// runVerify checks the code given by the -token flag, the exit code is 0 when
+// valid and 1 otherwise.
+func runVerify(e *env, args []string) (int, error) {
+	of := &otpFlags{}
+	fs := newFlagSet("verify", e)
+	of.register(fs)
+	token := fs.String("token", "", "code to verify")
+	if err := fs.Parse(args); err != nil {
+		return exitError, nil
+	}
+
+	if *token == "" {
+		return exitError, errors.New("missing -token")
+	}
+
+	in, err := readInput(e, of)
+	if err != nil {
+		return exitError, err
+	}
+
+	valid, err := in.otp.Validate(*token)
+	if err != nil {
+		return exitError, err
+	}
+
+	result := map[string]interface{}{"valid": valid}
+	if h, ok := in.otp.(*otpgo.HOTP); ok && valid {
+		result["nextCounter"] = h.Counter
+	}
+
+	if of.json {
+		err = writeJSON(e.stdout, result)
+	} else if valid {
+		fmt.Fprintln(e.stdout, "valid")
+	} else {
+		fmt.Fprintln(e.stdout, "invalid")
+	}
+
+	if !valid {
+		return exitInvalid, err
+	}
+
+	return exitOK, err
+}
+
+// runUri builds a key URI from a secret, or parses an existing one.
+func runUri(e *env, args []string) (int, error) {
+	if len(args) == 0 || (args[0] != "build" && args[0] != "parse") {
+		return exitError, errors.New("expected build or parse subcommand")
+	}
+
+	of := &otpFlags{}
+	fs := newFlagSet("uri "+args[0], e)
+	of.register(fs)
+	if err := fs.Parse(args[1:]); err != nil {
+		return exitError, nil
+	}
+
+	in, err := readInput(e, of)
+	if err != nil {
+		return exitError, err
+	}
+
+	ku := in.keyUri()
+
+	if args[0] == "build" {
+		if ku.Label.AccountName == "" {
+			return exitError, errMissingAccount
+		}
+
+		uri, err := ku.Build()
+		if err != nil {
+			return exitError, err
+		}
+
+		if of.json {
+			return exitOK, writeJSON(e.stdout, map[string]string{"uri": uri})
+		}
+
+		fmt.Fprintln(e.stdout, uri)
+		return exitOK, nil
+	}
+
+	if of.json {
+		return exitOK, writeJSON(e.stdout, ku)
+	}
+
+	params := ku.Parameters.AsUrlValues(ku.Label.Issuer)
+	fmt.Fprintf(e.stdout, "type:    %s\naccount: %s\n", ku.Type, ku.Label.AccountName)
+	for _, name := range []string{"issuer", "secret", "algorithm", "digits", "period", "counter"} {
+		if value := params.Get(name); value != "" {
+			fmt.Fprintf(e.stdout, "%-8s %s\n", name+":", value)
+		}
+	}
+
+	return exitOK, nil
+}
+
+// runQR writes the key URI QR code as a PNG or SVG image.
+func runQR(e *env, args []string) (int, error) {
+	of := &otpFlags{}
+	fs := newFlagSet("qr", e)
+	of.register(fs)
+	output := fs.String("o", "", "output file, the format is taken from the extension (.png or .svg)")
+	size := fs.Int("size", 256, "image size in pixels")
+	if err := fs.Parse(args); err != nil {
+		return exitError, nil
+	}
+
+	if *output == "" {
+		return exitError, errors.New("missing -o")
+	}
+
+	in, err := readInput(e, of)
+	if err != nil {
+		return exitError, err
+	}
+
+	if in.label.AccountName == "" {
+		return exitError, errMissingAccount
+	}
+
+	uri, err := in.keyUri().Build()
+	if err != nil {
+		return exitError, err
+	}
+
+	qr, err := qrcode.New(uri, qrcode.Medium)
+	if err != nil {
+		return exitError, err
+	}
+
+	var image []byte
+	switch strings.ToLower(filepath.Ext(*output)) {
+	case ".png":
+		image, err = qr.PNG(*size)
+	case ".svg":
+		image = svg(qr.Bitmap(), *size)
+	default:
+		err = fmt.Errorf("unsupported image format %q", filepath.Ext(*output))
+	}
+	if err != nil {
+		return exitError, err
+	}
+
+	// The image holds the secret, keep it private.
+	if err := ioutil.WriteFile(*output, image, 0600); err != nil {
+		return exitError, err
+	}
+
+	if of.json {
+		return exitOK, writeJSON(e.stdout, map[string]string{"file": *output})
+	}
+
+	return exitOK, nil
+}
+
+// svg renders a QR bitmap as an SVG image of the given size.
+func svg(bitmap [][]bool, size int) []byte {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`, size, size, len(bitmap), len(bitmap))
+	fmt.Fprintf(&b, `<rect width="100%%" height="100%%" fill="#fff"/><path fill="#000" d="`)
+	for y, row := range bitmap {
+		for x, black := range row {
+			if black {
+				fmt.Fprintf(&b, "M%d %dh1v1h-1z", x, y)
+			}
+		}
+	}
+	b.WriteString(`"/></svg>`)
+
+	return []byte(b.String())
+}
+
+func newFlagSet(name string, e *env) *flag.FlagSet {
+	fs := flag.NewFlagSet("otpgo "+name, flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	return fs
+}
+
+func writeJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func keyOf(o otpgo.OTP) string {
+	switch t := o.(type) {
+	case *otpgo.TOTP:
+		return t.Key
+	case *otpgo.HOTP:
+		return t.Key
+	}
+
+	return ""
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/jltorresm/otpgo"
+	"github.com/jltorresm/otpgo/authenticator"
+	"github.com/jltorresm/otpgo/config"
+)
+
+// errMissingAccount is returned when a key URI must be built and neither the
+// -account flag nor the input key URI provide the account name.
+var errMissingAccount = errors.New("missing -account, the key uri needs an account name")
+
+// errInvalidSkew is returned when the -skew flag is not a positive number of
+// steps, it applies to key URI inputs too.
+var errInvalidSkew = errors.New("skew must be at least 1")
+
+// The otpFlags type holds the OTP parameters that can be customized from the
+// command line. They are ignored when the input is a key URI.
+type otpFlags struct {
+	otpType   string
+	algorithm string
+	digits    int
+	period    int
+	counter   uint64
+	skew      int
+	envName   string
+	issuer    string
+	account   string
+	json      bool
+}
+
+func (of *otpFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&of.otpType, "type", "totp", "otp type, totp or hotp")
+	fs.StringVar(&of.algorithm, "algorithm", "SHA1", "hash algorithm, SHA1, SHA256 or SHA512")
+	fs.IntVar(&of.digits, "digits", 6, "number of digits of the code")
+	fs.IntVar(&of.period, "period", otpgo.TOTPDefaultPeriod, "totp period in seconds")
+	fs.Uint64Var(&of.counter, "counter", 0, "hotp counter")
+	fs.IntVar(&of.skew, "skew", 1, "accepted steps before and after the expected one when verifying")
+	fs.StringVar(&of.envName, "env", "OTPGO_SECRET", "environment variable holding the secret or key uri")
+	fs.StringVar(&of.issuer, "issuer", "", "issuer of the key uri")
+	fs.StringVar(&of.account, "account", "", "account name of the key uri, required by gen, uri build and qr unless the input is a key uri")
+	fs.BoolVar(&of.json, "json", false, "print machine readable json")
+}
+
+// build returns the OTP described by the flags for the given key.
+func (of *otpFlags) build(key string) (otpgo.OTP, error) {
+	alg, err := config.ParseHmacAlgorithm(of.algorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	length, err := config.ParseLength(fmt.Sprint(of.digits))
+	if err != nil {
+		return nil, err
+	}
+
+	if of.skew < 1 {
+		return nil, errInvalidSkew
+	}
+
+	switch of.otpType {
+	case "totp":
+		if of.period < 1 {
+			return nil, errors.New("period must be at least 1")
+		}
+		return &otpgo.TOTP{Key: key, Period: of.period, Delay: of.skew, Algorithm: alg, Length: length}, nil
+	case "hotp":
+		return &otpgo.HOTP{Key: key, Counter: of.counter, Leeway: uint64(of.skew), Algorithm: alg, Length: length}, nil
+	}
+
+	return nil, fmt.Errorf("unknown otp type %q", of.otpType)
+}
+
+// The input type is the secret material provided by the user, along with the
+// label found in the key URI, if any.
+type input struct {
+	otp   otpgo.OTP
+	label authenticator.Label
+	uri   *authenticator.KeyUri
+}
+
+// readInput loads the secret or key URI from the environment or stdin and
+// builds the corresponding OTP.
+func readInput(e *env, of *otpFlags) (*input, error) {
+	raw := strings.TrimSpace(e.getenv(of.envName))
+
+	if raw == "" {
+		b, err := ioutil.ReadAll(e.stdin)
+		if err != nil {
+			return nil, err
+		}
+		raw = strings.TrimSpace(string(b))
+	}
+
+	if raw == "" {
+		return nil, fmt.Errorf("no secret provided, set $%s or pipe it through stdin", of.envName)
+	}
+
+	if of.skew < 1 {
+		return nil, errInvalidSkew
+	}
+
+	if !strings.HasPrefix(raw, "otpauth://") {
+		o, err := of.build(raw)
+		if err != nil {
+			return nil, err
+		}
+		return &input{otp: o, label: authenticator.Label{AccountName: of.account, Issuer: of.issuer}}, nil
+	}
+
+	ku, err := authenticator.ParseKeyUri(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	o, err := otpgo.OTPFromKeyUri(ku)
+	if err != nil {
+		return nil, err
+	}
+
+	// The skew is not part of the key uri, apply the flag.
+	switch t := o.(type) {
+	case *otpgo.TOTP:
+		t.Delay = of.skew
+	case *otpgo.HOTP:
+		t.Leeway = uint64(of.skew)
+	}
+
+	return &input{otp: o, label: ku.Label, uri: ku}, nil
+}
+
+// keyUri returns the key URI for the input, keeping any extension found in the
+// original URI.
+func (in *input) keyUri() *authenticator.KeyUri {
+	ku := in.otp.KeyUri(in.label.AccountName, in.label.Issuer)
+	if in.uri != nil {
+		ku.Extensions = in.uri.Extensions
+	}
+
+	return ku
+}
@@ -0,0 +1,92 @@
+// Command otpgo generates, verifies and exports HOTP and TOTP codes.
+//
+// Secrets and URIs are never taken as arguments, to keep them out of the shell
+// history. They are read from the environment variable named by -env (defaults
+// to OTPGO_SECRET) or, when it is empty, from stdin.
+//
+// Building a key URI, by gen, uri build and qr, requires an account name. It
+// is taken from the input key URI or, for a bare secret, from -account.
+//
+// Usage:
+//
+//	otpgo gen    -account name [-type totp|hotp] [-issuer name] [-json]
+//	otpgo code   [-json]
+//	otpgo verify -token code [-json]
+//	otpgo uri    build|parse [-issuer name] [-account name] [-json]
+//	otpgo qr     -o file.png|file.svg [-account name] [-size pixels]
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+const (
+	exitOK      = 0
+	exitInvalid = 1
+	exitError   = 2
+)
+
+// The env type holds everything a command interacts with, so that commands
+// can be exercised without touching the real process state.
+type env struct {
+	getenv func(string) string
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+type command func(e *env, args []string) (int, error)
+
+var commands = map[string]command{
+	"gen":    runGen,
+	"code":   runCode,
+	"verify": runVerify,
+	"uri":    runUri,
+	"qr":     runQR,
+}
+
+func main() {
+	e := &env{getenv: os.Getenv, stdin: os.Stdin, stdout: os.Stdout, stderr: os.Stderr}
+	os.Exit(run(e, os.Args[1:]))
+}
+
+// run executes the command named by the first argument and returns the exit
+// code.
+func run(e *env, args []string) int {
+	if len(args) == 0 {
+		usage(e.stderr)
+		return exitError
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(e.stderr, "otpgo: unknown command %q\n", args[0])
+		usage(e.stderr)
+		return exitError
+	}
+
+	code, err := cmd(e, args[1:])
+	if err != nil {
+		fmt.Fprintf(e.stderr, "otpgo %s: %s\n", args[0], err)
+	}
+
+	return code
+}
+
+func usage(w io.Writer) {
+	fmt.Fprint(w, `usage: otpgo <command> [flags]
+
+commands:
+  gen     generate a new secret, its key uri and a terminal qr code
+  code    print the current code for a secret or key uri
+  verify  check a code against a secret or key uri
+  uri     build or parse a key uri
+  qr      write the key uri qr code as a png or svg image
+
+Secrets and key uris are read from $OTPGO_SECRET or stdin.
+gen, uri build and qr need -account unless the input is a key uri.
+Run "otpgo <command> -h" for the command flags.
+`)
+}
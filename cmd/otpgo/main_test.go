@@ -0,0 +1,267 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jltorresm/otpgo"
+	"github.com/jltorresm/otpgo/authenticator"
+)
+
+const testSecret = "JBSWY3DPEHPK3PXP"
+
+// noEnv is the getenv of commands run without environment variables.
+func noEnv(string) string { return "" }
+
+func TestRun_Usage(t *testing.T) {
+	cases := []struct {
+		label string
+		args  []string
+	}{
+		{"No Command", nil},
+		{"Unknown Command", []string{"nope"}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.label, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			e := &env{getenv: noEnv, stdin: strings.NewReader(""), stdout: &stdout, stderr: &stderr}
+
+			if code := run(e, c.args); code != exitError {
+				t.Errorf("unexpected exit code\nexpected: %d\n  actual: %d", exitError, code)
+			}
+
+			if !strings.Contains(stderr.String(), "usage: otpgo") {
+				t.Errorf("expected usage, got: %s", stderr.String())
+			}
+		})
+	}
+}
+
+func TestRun_Gen(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	e := &env{getenv: noEnv, stdin: strings.NewReader(""), stdout: &stdout, stderr: &stderr}
+
+	code := run(e, []string{"gen", "-issuer", "Acme", "-account", "john@example.com", "-json"})
+	if code != exitOK {
+		t.Errorf("unexpected exit code %d: %s", code, stderr.String())
+		t.FailNow()
+	}
+
+	var out map[string]string
+	if err := json.Unmarshal(stdout.Bytes(), &out); err != nil {
+		t.Errorf("unexpected error: %s", err)
+		t.FailNow()
+	}
+
+	ku, err := authenticator.ParseKeyUri(out["uri"])
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		t.FailNow()
+	}
+
+	totp, err := otpgo.TOTPFromKeyUri(ku)
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		t.FailNow()
+	}
+
+	if totp.Key != out["secret"] || len(totp.Key) != 103 {
+		t.Errorf("unexpected secret\nexpected: %s\n  actual: %s", out["secret"], totp.Key)
+	}
+
+	stdout.Reset()
+	stderr.Reset()
+	if code := run(e, []string{"gen", "-type", "hotp", "-account", "john"}); code != exitOK {
+		t.Errorf("unexpected exit code %d: %s", code, stderr.String())
+	}
+
+	if !strings.Contains(stdout.String(), "otpauth://hotp/john?") || !strings.Contains(stdout.String(), "█") {
+		t.Errorf("expected uri and terminal qr, got: %s", stdout.String())
+	}
+}
+
+func TestRun_CodeAndVerify(t *testing.T) {
+	totp := &otpgo.TOTP{Key: testSecret}
+	expected, _ := totp.Generate()
+
+	cases := []struct {
+		label string
+		vars  map[string]string
+		stdin string
+		args  []string
+	}{
+		{"Secret From Stdin", nil, testSecret + "\n", nil},
+		{"Secret From Env", map[string]string{"OTPGO_SECRET": testSecret}, "", nil},
+		{"Custom Env", map[string]string{"MY_SECRET": testSecret}, "", []string{"-env", "MY_SECRET"}},
+		{"Uri From Stdin", nil, "otpauth://totp/Acme:john?secret=" + testSecret + "&issuer=Acme", nil},
+	}
+
+	for _, c := range cases {
+		t.Run(c.label, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			e := &env{getenv: func(name string) string { return c.vars[name] }, stdin: strings.NewReader(c.stdin), stdout: &stdout, stderr: &stderr}
+			if code := run(e, append([]string{"code"}, c.args...)); code != exitOK {
+				t.Errorf("unexpected exit code %d: %s", code, stderr.String())
+			}
+
+			if expected+"\n" != stdout.String() {
+				t.Errorf("unexpected code\nexpected: %s\n  actual: %s", expected, stdout.String())
+			}
+
+			stdout.Reset()
+			stderr.Reset()
+			e.stdin = strings.NewReader(c.stdin)
+			if code := run(e, append([]string{"verify", "-token", expected}, c.args...)); code != exitOK {
+				t.Errorf("unexpected exit code %d: %s", code, stderr.String())
+			}
+
+			stdout.Reset()
+			stderr.Reset()
+			e.stdin = strings.NewReader(c.stdin)
+			if code := run(e, append([]string{"verify", "-token", "000000x", "-json"}, c.args...)); code != exitInvalid {
+				t.Errorf("unexpected exit code %d: %s", code, stderr.String())
+			}
+
+			if !strings.Contains(stdout.String(), `"valid": false`) {
+				t.Errorf("unexpected output: %s", stdout.String())
+			}
+		})
+	}
+}
+
+func TestRun_CodeHOTP(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	e := &env{getenv: noEnv, stdin: strings.NewReader("otpauth://hotp/john?secret=73QK7D3A3PIZ6NUQQBF4BNFYQBRVUHUP&counter=363&algorithm=SHA256"), stdout: &stdout, stderr: &stderr}
+
+	if code := run(e, []string{"code", "-json"}); code != exitOK {
+		t.Errorf("unexpected exit code %d: %s", code, stderr.String())
+	}
+
+	expected := "{\n  \"code\": \"363033\",\n  \"counter\": 363\n}\n"
+	if expected != stdout.String() {
+		t.Errorf("unexpected output\nexpected: %s\n  actual: %s", expected, stdout.String())
+	}
+}
+
+func TestRun_Errors(t *testing.T) {
+	cases := []struct {
+		label       string
+		stdin       string
+		args        []string
+		expectedErr string
+	}{
+		{"No Secret", "", []string{"code"}, "no secret provided, set $OTPGO_SECRET or pipe it through stdin"},
+		{"Bad Secret", "not-base-32", []string{"code"}, "invalid key: illegal base32 data at input byte 3"},
+		{"Bad Type", testSecret, []string{"code", "-type", "motp"}, `unknown otp type "motp"`},
+		{"Bad Digits", testSecret, []string{"code", "-digits", "10"}, `unsupported length "10"`},
+		{"Missing Token", testSecret, []string{"verify"}, "missing -token"},
+		{"Uri Subcommand", testSecret, []string{"uri"}, "expected build or parse subcommand"},
+		{"Colon In Account", testSecret, []string{"uri", "build", "-account", "a:b"}, "invalid label accountName: must not contain a colon"},
+		{"Gen Without Account", "", []string{"gen", "-no-qr"}, "missing -account, the key uri needs an account name"},
+		{"Build Without Account", testSecret, []string{"uri", "build"}, "missing -account, the key uri needs an account name"},
+		{"QR Without Account", testSecret, []string{"qr", "-o", "qr.png"}, "missing -account, the key uri needs an account name"},
+		{"Missing Output", testSecret, []string{"qr"}, "missing -o"},
+		{"Bad Uri", "otpauth://totp/john?digits=6", []string{"code"}, "invalid key uri: missing secret"},
+		{"Zero Skew", testSecret, []string{"verify", "-token", "000000", "-skew", "0"}, "skew must be at least 1"},
+		{"Negative Skew Uri", "otpauth://hotp/john?secret=" + testSecret, []string{"verify", "-token", "000000", "-skew", "-1"}, "skew must be at least 1"},
+		{"Zero Skew Uri", "otpauth://totp/john?secret=" + testSecret, []string{"verify", "-token", "000000", "-skew", "0"}, "skew must be at least 1"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.label, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			e := &env{getenv: noEnv, stdin: strings.NewReader(c.stdin), stdout: &stdout, stderr: &stderr}
+
+			if code := run(e, c.args); code != exitError {
+				t.Errorf("unexpected exit code\nexpected: %d\n  actual: %d", exitError, code)
+			}
+
+			if !strings.Contains(stderr.String(), c.expectedErr) {
+				t.Errorf("unexpected error\nexpected: %s\n  actual: %s", c.expectedErr, stderr.String())
+			}
+		})
+	}
+}
+
+func TestRun_Uri(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	e := &env{getenv: noEnv, stdin: strings.NewReader(testSecret), stdout: &stdout, stderr: &stderr}
+	args := []string{"uri", "build", "-issuer", "Acme Inc", "-account", "john/doe", "-digits", "8"}
+
+	if code := run(e, args); code != exitOK {
+		t.Errorf("unexpected exit code %d: %s", code, stderr.String())
+	}
+
+	expected := "otpauth://totp/Acme%20Inc:john%2Fdoe?algorithm=SHA1&digits=8&issuer=Acme+Inc&period=30&secret=" + testSecret + "\n"
+	if expected != stdout.String() {
+		t.Errorf("unexpected uri\nexpected: %s\n  actual: %s", expected, stdout.String())
+	}
+
+	stdout.Reset()
+	stderr.Reset()
+	e.stdin = strings.NewReader(expected)
+	if code := run(e, []string{"uri", "parse"}); code != exitOK {
+		t.Errorf("unexpected exit code %d: %s", code, stderr.String())
+	}
+
+	for _, line := range []string{"type:    totp", "account: john/doe", "issuer:  Acme Inc", "digits:  8"} {
+		if !strings.Contains(stdout.String(), line) {
+			t.Errorf("expected %q in output: %s", line, stdout.String())
+		}
+	}
+
+	// The account name of an input key uri makes -account optional.
+	stdout.Reset()
+	stderr.Reset()
+	e.stdin = strings.NewReader(expected)
+	if code := run(e, []string{"uri", "build"}); code != exitOK {
+		t.Errorf("unexpected exit code %d: %s", code, stderr.String())
+	}
+
+	if expected != stdout.String() {
+		t.Errorf("unexpected uri\nexpected: %s\n  actual: %s", expected, stdout.String())
+	}
+}
+
+func TestRun_QR(t *testing.T) {
+	dir, err := ioutil.TempDir("", "otpgo")
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		t.FailNow()
+	}
+	defer os.RemoveAll(dir)
+
+	for _, name := range []string{"qr.png", "qr.svg"} {
+		t.Run(name, func(t *testing.T) {
+			output := filepath.Join(dir, name)
+			var stdout, stderr bytes.Buffer
+			e := &env{getenv: noEnv, stdin: strings.NewReader(testSecret), stdout: &stdout, stderr: &stderr}
+
+			if code := run(e, []string{"qr", "-account", "john", "-o", output}); code != exitOK {
+				t.Errorf("unexpected exit code %d: %s", code, stderr.String())
+			}
+
+			info, err := os.Stat(output)
+			if err != nil {
+				t.Errorf("unexpected error: %s", err)
+				t.FailNow()
+			}
+
+			if info.Mode().Perm() != 0600 || info.Size() == 0 {
+				t.Errorf("unexpected image file, mode %s size %d", info.Mode(), info.Size())
+			}
+		})
+	}
+
+	var stdout, stderr bytes.Buffer
+	e := &env{getenv: noEnv, stdin: strings.NewReader(testSecret), stdout: &stdout, stderr: &stderr}
+	if code := run(e, []string{"qr", "-account", "john", "-o", filepath.Join(dir, "qr.gif")}); code != exitError {
+		t.Errorf("unexpected exit code\nexpected: %d\n  actual: %d", exitError, code)
+	}
+}
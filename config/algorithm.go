@@ -5,7 +5,9 @@ import (
 	"crypto/sha256"
 	"crypto/sha512"
 	"encoding/json"
+	"fmt"
 	"hash"
+	"strings"
 )
 
 // HmacAlgorithm type describes the supported hash algorithms for usage in OTP generation.
@@ -57,3 +59,36 @@ func (alg HmacAlgorithm) String() string {
 func (alg HmacAlgorithm) MarshalJSON() ([]byte, error) {
 	return json.Marshal(alg.String())
 }
+
+// UnmarshalJSON parses the JSON representation of HmacAlgorithm, as returned by
+// MarshalJSON.
+func (alg *HmacAlgorithm) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err != nil {
+		return err
+	}
+
+	parsed, err := ParseHmacAlgorithm(name)
+	if err != nil {
+		return err
+	}
+
+	*alg = parsed
+
+	return nil
+}
+
+// ParseHmacAlgorithm returns the HmacAlgorithm corresponding to the given name,
+// as returned by HmacAlgorithm.String. The name is case insensitive.
+func ParseHmacAlgorithm(name string) (HmacAlgorithm, error) {
+	switch strings.ToUpper(name) {
+	case "SHA1":
+		return HmacSHA1, nil
+	case "SHA256":
+		return HmacSHA256, nil
+	case "SHA512":
+		return HmacSHA512, nil
+	}
+
+	return 0, fmt.Errorf("unknown hash algorithm %q", name)
+}
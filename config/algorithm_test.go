@@ -123,3 +123,58 @@ func TestHmacAlgorithm_MarshalJSON(t *testing.T) {
 		}
 	}
 }
+
+func TestHmacAlgorithm_UnmarshalJSON(t *testing.T) {
+	cases := []struct {
+		label       string
+		json        string
+		expectedAlg HmacAlgorithm
+		expectedErr string
+	}{
+		{label: "HmacSHA1", json: `"SHA1"`, expectedAlg: HmacSHA1},
+		{label: "HmacSHA256", json: `"SHA256"`, expectedAlg: HmacSHA256},
+		{label: "HmacSHA512", json: `"SHA512"`, expectedAlg: HmacSHA512},
+		{label: "Unknown", json: `"MD5"`, expectedErr: `unknown hash algorithm "MD5"`},
+		{label: "Not A String", json: `1`, expectedErr: "json: cannot unmarshal number into Go value of type string"},
+	}
+
+	for _, c := range cases {
+		var alg HmacAlgorithm
+		err := alg.UnmarshalJSON([]byte(c.json))
+
+		if c.expectedAlg != alg {
+			t.Errorf("case %s: wrong hash algorithm\nexpected: %d\n  actual: %d", c.label, c.expectedAlg, alg)
+		}
+
+		if (err == nil && c.expectedErr != "") || (err != nil && c.expectedErr != err.Error()) {
+			t.Errorf("case %s: unexpected error\nexpected: %s\n  actual: %v", c.label, c.expectedErr, err)
+		}
+	}
+}
+
+func TestParseHmacAlgorithm(t *testing.T) {
+	cases := []struct {
+		label       string
+		name        string
+		expectedAlg HmacAlgorithm
+		expectedErr string
+	}{
+		{label: "SHA1", name: "SHA1", expectedAlg: HmacSHA1},
+		{label: "SHA256", name: "SHA256", expectedAlg: HmacSHA256},
+		{label: "SHA512", name: "SHA512", expectedAlg: HmacSHA512},
+		{label: "Lower Case", name: "sha256", expectedAlg: HmacSHA256},
+		{label: "Unknown", name: "MD5", expectedErr: `unknown hash algorithm "MD5"`},
+	}
+
+	for _, c := range cases {
+		alg, err := ParseHmacAlgorithm(c.name)
+
+		if c.expectedAlg != alg {
+			t.Errorf("case %s: wrong hash algorithm\nexpected: %d\n  actual: %d", c.label, c.expectedAlg, alg)
+		}
+
+		if (err == nil && c.expectedErr != "") || (err != nil && c.expectedErr != err.Error()) {
+			t.Errorf("case %s: unexpected error\nexpected: %s\n  actual: %v", c.label, c.expectedErr, err)
+		}
+	}
+}
@@ -36,3 +36,14 @@ func (l Length) LeftPad(number int) string {
 func (l Length) String() string {
 	return strconv.Itoa(int(l))
 }
+
+// ParseLength converts a string into a supported Length, between Length1 and
+// Length8.
+func ParseLength(s string) (Length, error) {
+	l, err := strconv.Atoi(s)
+	if err != nil || l < int(Length1) || l > int(Length8) {
+		return 0, fmt.Errorf("unsupported length %q", s)
+	}
+
+	return Length(l), nil
+}
@@ -60,3 +60,31 @@ func TestLength_LeftPad(t *testing.T) {
 		}
 	}
 }
+
+func TestParseLength(t *testing.T) {
+	cases := []struct {
+		label          string
+		raw            string
+		expectedLength Length
+		expectedErr    string
+	}{
+		{"Length 1", "1", Length1, ""},
+		{"Length 6", "6", Length6, ""},
+		{"Length 8", "8", Length8, ""},
+		{"Zero", "0", 0, `unsupported length "0"`},
+		{"Too Long", "9", 0, `unsupported length "9"`},
+		{"Not A Number", "six", 0, `unsupported length "six"`},
+	}
+
+	for _, c := range cases {
+		length, err := ParseLength(c.raw)
+
+		if c.expectedLength != length {
+			t.Errorf("case %s: wrong length\nexpected: %d\n  actual: %d", c.label, c.expectedLength, length)
+		}
+
+		if (err == nil && c.expectedErr != "") || (err != nil && c.expectedErr != err.Error()) {
+			t.Errorf("case %s: unexpected error\nexpected: %s\n  actual: %v", c.label, c.expectedErr, err)
+		}
+	}
+}
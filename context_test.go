@@ -0,0 +1,74 @@
+package otpgo
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jltorresm/otpgo/config"
+)
+
+func TestContext_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	dir, err := ioutil.TempDir("", "otpgo")
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		t.FailNow()
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	s := &SyncHOTP{ID: "john", HOTP: HOTP{Key: "73QK7D3A3PIZ6NUQQBF4BNFYQBRVUHUP", Counter: 363, Algorithm: config.HmacSHA256}, Store: &MemoryCounterStore{}}
+	code := codeAt(t, s, s.HOTP.Counter)
+	now := time.Date(2020, 10, 20, 8, 0, 0, 0, time.UTC)
+	d := &DriftTOTP{ID: "john", TOTP: TOTP{Key: "73QK7D3A3PIZ6NUQQBF4BNFYQBRVUHUQ", Algorithm: config.HmacSHA256}, Store: &MemoryDriftStore{}, MaxDrift: 3, Now: func() time.Time { return now }}
+
+	cases := []struct {
+		label string
+		call  func() error
+	}{
+		{"HOTP Generate", func() error { _, err := (&HOTP{}).GenerateContext(ctx); return err }},
+		{"HOTP Validate", func() error { _, err := (&HOTP{Key: s.HOTP.Key}).ValidateContext(ctx, code); return err }},
+		{"TOTP Generate", func() error { _, err := (&TOTP{}).GenerateContext(ctx); return err }},
+		{"TOTP Validate", func() error { _, err := (&TOTP{Key: s.HOTP.Key}).ValidateContext(ctx, code); return err }},
+		{"SyncHOTP Generate", func() error { _, err := s.GenerateContext(ctx); return err }},
+		{"SyncHOTP Validate", func() error { _, err := s.ValidateContext(ctx, code); return err }},
+		{"DriftTOTP Validate", func() error { _, err := d.ValidateContext(ctx, driftCodeAt(t, d, 0)); return err }},
+		{"Memory Load", func() error { _, _, err := (&MemoryCounterStore{}).Load(ctx, "john"); return err }},
+		{"Memory Swap", func() error { _, err := (&MemoryCounterStore{}).CompareAndSwap(ctx, "john", 0, 1); return err }},
+		{"File Load", func() error {
+			_, _, err := (&FileCounterStore{Path: filepath.Join(dir, "counters.json")}).Load(ctx, "john")
+			return err
+		}},
+		{"File Swap", func() error {
+			_, err := (&FileCounterStore{Path: filepath.Join(dir, "counters.json")}).CompareAndSwap(ctx, "john", 0, 1)
+			return err
+		}},
+		{"Drift Load", func() error { _, err := (&MemoryDriftStore{}).LoadDrift(ctx, "john"); return err }},
+		{"Drift Save", func() error { return (&MemoryDriftStore{}).SaveDrift(ctx, "john", 1) }},
+	}
+
+	for _, c := range cases {
+		if err := c.call(); err != context.Canceled {
+			t.Errorf("%s: unexpected error\nexpected: %v\n  actual: %v", c.label, context.Canceled, err)
+		}
+	}
+
+	// Nothing must have been consumed by the canceled calls
+	if valid, err := s.ValidateContext(context.Background(), code); !valid || err != nil {
+		t.Errorf("unexpected validation after cancel: %v, %v", valid, err)
+	}
+}
+
+func TestContext_Deadline(t *testing.T) {
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+
+	if _, err := (&TOTP{}).ValidateContext(ctx, "123456"); err != context.DeadlineExceeded {
+		t.Errorf("unexpected error\nexpected: %v\n  actual: %v", context.DeadlineExceeded, err)
+	}
+}
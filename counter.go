@@ -0,0 +1,170 @@
+package otpgo
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// The CounterStore interface keeps the current counter of HOTP credentials,
+// identified by an arbitrary id. Implementations must be safe for concurrent
+// use, and CompareAndSwap must be atomic so that a counter value can only be
+// consumed once. Both methods should give up when the context is done.
+type CounterStore interface {
+	// Load returns the counter stored for id, ok is false if there is none.
+	Load(ctx context.Context, id string) (counter uint64, ok bool, err error)
+
+	// CompareAndSwap sets the counter for id to new only if it currently holds
+	// old, and reports whether it did. When no counter is stored for id yet,
+	// the swap always succeeds and the counter is created.
+	CompareAndSwap(ctx context.Context, id string, old, new uint64) (swapped bool, err error)
+}
+
+// The MemoryCounterStore type is a CounterStore that keeps counters in memory.
+// The zero value is ready to use.
+type MemoryCounterStore struct {
+	mu       sync.Mutex
+	counters map[string]uint64
+}
+
+// Load returns the counter stored for id.
+func (m *MemoryCounterStore) Load(ctx context.Context, id string) (uint64, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, false, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	counter, ok := m.counters[id]
+
+	return counter, ok, nil
+}
+
+// CompareAndSwap sets the counter for id to new if it currently holds old.
+func (m *MemoryCounterStore) CompareAndSwap(ctx context.Context, id string, old, new uint64) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if counter, ok := m.counters[id]; ok && counter != old {
+		return false, nil
+	}
+
+	if m.counters == nil {
+		m.counters = map[string]uint64{}
+	}
+	m.counters[id] = new
+
+	return true, nil
+}
+
+// The FileCounterStore type is a CounterStore that keeps counters in a JSON
+// file, which is created on the first swap. Every swap rewrites the file
+// atomically, so a crash never leaves it half written.
+//
+// Access is serialized within the process only, the file must not be shared
+// by several processes.
+type FileCounterStore struct {
+	Path string // Location of the JSON file
+
+	mu sync.Mutex
+}
+
+// Load returns the counter stored for id.
+func (f *FileCounterStore) Load(ctx context.Context, id string) (uint64, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, false, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	counters, err := f.read()
+	if err != nil {
+		return 0, false, err
+	}
+
+	counter, ok := counters[id]
+
+	return counter, ok, nil
+}
+
+// CompareAndSwap sets the counter for id to new if it currently holds old.
+func (f *FileCounterStore) CompareAndSwap(ctx context.Context, id string, old, new uint64) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	// Waiting for the lock may have taken a while, check the context after.
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	counters, err := f.read()
+	if err != nil {
+		return false, err
+	}
+
+	if counter, ok := counters[id]; ok && counter != old {
+		return false, nil
+	}
+
+	counters[id] = new
+
+	return true, f.write(counters)
+}
+
+// read loads all the counters, a missing file holds no counters.
+func (f *FileCounterStore) read() (map[string]uint64, error) {
+	counters := map[string]uint64{}
+
+	raw, err := ioutil.ReadFile(f.Path)
+	if os.IsNotExist(err) {
+		return counters, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(raw, &counters); err != nil {
+		return nil, err
+	}
+
+	return counters, nil
+}
+
+// write atomically replaces the file with the given counters.
+func (f *FileCounterStore) write(counters map[string]uint64) error {
+	raw, err := json.Marshal(counters)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(f.Path), filepath.Base(f.Path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(raw); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), f.Path)
+}
@@ -0,0 +1,125 @@
+package otpgo
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestCounterStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "otpgo")
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		t.FailNow()
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	stores := map[string]CounterStore{
+		"Memory": &MemoryCounterStore{},
+		"File":   &FileCounterStore{Path: filepath.Join(dir, "counters.json")},
+	}
+
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			testCounterStoreSwap(t, store)
+			testCounterStoreConcurrentSwap(t, store)
+		})
+	}
+}
+
+func testCounterStoreSwap(t *testing.T, store CounterStore) {
+	if _, ok, err := store.Load(context.Background(), "john"); ok || err != nil {
+		t.Errorf("unexpected counter for unknown id: %v, %v", ok, err)
+	}
+
+	cases := []struct {
+		label           string
+		old, new        uint64
+		expectedSwapped bool
+		expectedCounter uint64
+	}{
+		{"Create", 5, 10, true, 10},
+		{"Match", 10, 11, true, 11},
+		{"Mismatch", 10, 12, false, 11},
+	}
+
+	for _, c := range cases {
+		swapped, err := store.CompareAndSwap(context.Background(), "john", c.old, c.new)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", c.label, err)
+		}
+
+		if swapped != c.expectedSwapped {
+			t.Errorf("%s: unexpected swap\nexpected: %v\n  actual: %v", c.label, c.expectedSwapped, swapped)
+		}
+
+		counter, ok, err := store.Load(context.Background(), "john")
+		if !ok || err != nil || counter != c.expectedCounter {
+			t.Errorf("%s: unexpected counter\nexpected: %d\n  actual: %d (%v, %v)", c.label, c.expectedCounter, counter, ok, err)
+		}
+	}
+}
+
+func testCounterStoreConcurrentSwap(t *testing.T, store CounterStore) {
+	const workers = 20
+
+	var wg sync.WaitGroup
+	results := make(chan bool, workers)
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			swapped, err := store.CompareAndSwap(context.Background(), "jane", 0, 1)
+			if err != nil {
+				t.Errorf("unexpected error: %s", err)
+			}
+			results <- swapped
+		}()
+	}
+
+	wg.Wait()
+	close(results)
+
+	swaps := 0
+	for swapped := range results {
+		if swapped {
+			swaps++
+		}
+	}
+
+	if swaps != 1 {
+		t.Errorf("unexpected number of swaps\nexpected: %d\n  actual: %d", 1, swaps)
+	}
+}
+
+func TestFileCounterStore_Persistence(t *testing.T) {
+	dir, err := ioutil.TempDir("", "otpgo")
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		t.FailNow()
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	path := filepath.Join(dir, "counters.json")
+
+	if _, err := (&FileCounterStore{Path: path}).CompareAndSwap(context.Background(), "john", 0, 42); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	counter, ok, err := (&FileCounterStore{Path: path}).Load(context.Background(), "john")
+	if !ok || err != nil || counter != 42 {
+		t.Errorf("unexpected counter\nexpected: %d\n  actual: %d (%v, %v)", 42, counter, ok, err)
+	}
+
+	if err := ioutil.WriteFile(path, []byte("not json"), 0600); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	if _, _, err := (&FileCounterStore{Path: path}).Load(context.Background(), "john"); err == nil {
+		t.Error("expected error for corrupted file")
+	}
+}
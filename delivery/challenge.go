@@ -0,0 +1,164 @@
+package delivery
+
+import (
+	"crypto/hmac"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/jltorresm/otpgo"
+	"github.com/jltorresm/otpgo/config"
+	"github.com/jltorresm/otpgo/internal/clock"
+)
+
+// The Claims type is what a challenge token binds its code to.
+type Claims struct {
+	UserID    string `json:"sub"`
+	Purpose   string `json:"pur"`
+	IssuedAt  int64  `json:"iat"` // Unix timestamp
+	ExpiresAt int64  `json:"exp"` // Unix timestamp
+	Nonce     string `json:"non"` // Unique to every issued code
+}
+
+// The Challenger type issues codes without storing anything. Along with every
+// code it returns a challenge token, signed with Key, which the client sends
+// back with the code to verify it. Any server holding the Key can verify it.
+//
+// Being stateless, a challenge can be verified more than once until it
+// expires. Throttle verifications, e.g.: with an otphttp.Limiter keyed by the
+// user, and remember the nonces of verified challenges until they expire when
+// a code must only be used once.
+type Challenger struct {
+	Key       []byte               // Secret key signing the challenges, at least 32 random bytes
+	Algorithm config.HmacAlgorithm // Defaults to config.HmacSHA256
+	Length    config.Length        // Defaults to config.Length6
+	TTL       time.Duration        // Defaults to DefaultTTL
+	Now       func() time.Time     // Defaults to time.Now
+}
+
+// Issue returns a new code for the user and purpose, to be sent to the user,
+// and the challenge token bound to it, to be kept by the client.
+func (c *Challenger) Issue(userID, purpose string) (code, challenge string, err error) {
+	if len(c.Key) == 0 {
+		return "", "", ErrorInvalidChallenge{msg: "missing signing key"}
+	}
+
+	length := c.length()
+	if length < config.Length1 || length > config.Length8 {
+		return "", "", ErrorInvalidChallenge{msg: "unsupported code length " + length.String()}
+	}
+
+	nonce, err := randomID()
+	if err != nil {
+		return "", "", err
+	}
+
+	// A fresh random key makes every code unpredictable.
+	key, err := otpgo.RandomKey()
+	if err != nil {
+		return "", "", err
+	}
+
+	h := otpgo.HOTP{Key: key, Length: length}
+	if code, err = h.Generate(); err != nil {
+		return "", "", err
+	}
+
+	now := clock.Now(c.Now)
+	claims := Claims{
+		UserID:    userID,
+		Purpose:   purpose,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(c.ttl()).Unix(),
+		Nonce:     nonce,
+	}
+
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", "", err
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	challenge = encoded + "." + base64.RawURLEncoding.EncodeToString(c.sign(encoded, code))
+
+	return code, challenge, nil
+}
+
+// Verify checks the code typed by the user against the challenge, which must
+// have been issued to the same user and for the same purpose, and returns its
+// claims.
+func (c *Challenger) Verify(challenge, userID, purpose, code string) (*Claims, error) {
+	if len(c.Key) == 0 {
+		return nil, ErrorInvalidChallenge{msg: "missing signing key"}
+	}
+
+	parts := strings.Split(challenge, ".")
+	if len(parts) != 2 {
+		return nil, ErrorInvalidChallenge{msg: "malformed token"}
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, ErrorInvalidChallenge{msg: "malformed payload"}
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, ErrorInvalidChallenge{msg: "malformed signature"}
+	}
+
+	claims := &Claims{}
+	if err := json.Unmarshal(payload, claims); err != nil {
+		return nil, ErrorInvalidChallenge{msg: "malformed payload"}
+	}
+
+	if claims.UserID != userID || claims.Purpose != purpose {
+		return nil, ErrorInvalidChallenge{msg: "issued to another user or purpose"}
+	}
+
+	if clock.Now(c.Now).Unix() >= claims.ExpiresAt {
+		return nil, ErrorExpired{ID: claims.Nonce}
+	}
+
+	// A tampered payload fails here too, as does a wrong code.
+	if !hmac.Equal(signature, c.sign(parts[0], code)) {
+		return nil, ErrorInvalidCode{ID: claims.Nonce}
+	}
+
+	return claims, nil
+}
+
+// sign returns the MAC of the encoded payload and the code.
+func (c *Challenger) sign(payload, code string) []byte {
+	mac := hmac.New(c.algorithm().Hash, c.Key)
+	mac.Write([]byte(payload))
+	mac.Write([]byte{'.'})
+	mac.Write([]byte(code))
+
+	return mac.Sum(nil)
+}
+
+func (c *Challenger) algorithm() config.HmacAlgorithm {
+	if c.Algorithm == 0 {
+		return config.HmacSHA256
+	}
+
+	return c.Algorithm
+}
+
+func (c *Challenger) length() config.Length {
+	if c.Length == 0 {
+		return config.Length6
+	}
+
+	return c.Length
+}
+
+func (c *Challenger) ttl() time.Duration {
+	if c.TTL <= 0 {
+		return DefaultTTL
+	}
+
+	return c.TTL
+}
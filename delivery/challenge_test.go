@@ -0,0 +1,129 @@
+package delivery
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jltorresm/otpgo/config"
+)
+
+func TestChallenger_Verify(t *testing.T) {
+	now := time.Date(2020, 10, 20, 8, 0, 0, 0, time.UTC)
+	c := &Challenger{Key: []byte("0123456789abcdef0123456789abcdef"), Length: config.Length8, Now: func() time.Time { return now }}
+
+	code, challenge, err := c.Issue("user-1", "login")
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		t.FailNow()
+	}
+
+	if len(code) != 8 || strings.Contains(challenge, code) {
+		t.Errorf("unexpected code %s for challenge %s", code, challenge)
+	}
+
+	claims, err := c.Verify(challenge, "user-1", "login", code)
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		t.FailNow()
+	}
+
+	expected := Claims{UserID: "user-1", Purpose: "login", IssuedAt: 1603180800, ExpiresAt: 1603181400, Nonce: claims.Nonce}
+	if *claims != expected || claims.Nonce == "" {
+		t.Errorf("unexpected claims\nexpected: %+v\n  actual: %+v", expected, *claims)
+	}
+
+	// Forge a challenge for another user with the original signature
+	parts := strings.Split(challenge, ".")
+	forged := strings.Replace(string(mustDecode(t, parts[0])), "user-1", "user-2", 1)
+	forged = base64.RawURLEncoding.EncodeToString([]byte(forged)) + "." + parts[1]
+
+	other := &Challenger{Key: []byte("another key"), Length: config.Length8, Now: c.Now}
+
+	cases := []struct {
+		label     string
+		c         *Challenger
+		challenge string
+		userID    string
+		purpose   string
+		code      string
+		expected  error
+	}{
+		{"Wrong Code", c, challenge, "user-1", "login", "00000000", ErrorInvalidCode{ID: claims.Nonce}},
+		{"Other User", c, challenge, "user-2", "login", code, ErrorInvalidChallenge{msg: "issued to another user or purpose"}},
+		{"Other Purpose", c, challenge, "user-1", "reset", code, ErrorInvalidChallenge{msg: "issued to another user or purpose"}},
+		{"Forged", c, forged, "user-2", "login", code, ErrorInvalidCode{ID: claims.Nonce}},
+		{"Other Key", other, challenge, "user-1", "login", code, ErrorInvalidCode{ID: claims.Nonce}},
+		{"Malformed", c, "garbage", "user-1", "login", code, ErrorInvalidChallenge{msg: "malformed token"}},
+		{"Malformed Payload", c, "!!!." + parts[1], "user-1", "login", code, ErrorInvalidChallenge{msg: "malformed payload"}},
+		{"Malformed Signature", c, parts[0] + ".!!!", "user-1", "login", code, ErrorInvalidChallenge{msg: "malformed signature"}},
+		{"Missing Key", &Challenger{}, challenge, "user-1", "login", code, ErrorInvalidChallenge{msg: "missing signing key"}},
+	}
+
+	for _, tc := range cases {
+		if _, err := tc.c.Verify(tc.challenge, tc.userID, tc.purpose, tc.code); err != tc.expected {
+			t.Errorf("%s: unexpected error\nexpected: %v\n  actual: %v", tc.label, tc.expected, err)
+		}
+	}
+}
+
+func TestChallenger_IssueErrors(t *testing.T) {
+	key := []byte("0123456789abcdef0123456789abcdef")
+
+	cases := []struct {
+		label    string
+		c        *Challenger
+		expected error
+	}{
+		{"Missing Key", &Challenger{}, ErrorInvalidChallenge{msg: "missing signing key"}},
+		{"Negative Length", &Challenger{Key: key, Length: -1}, ErrorInvalidChallenge{msg: "unsupported code length -1"}},
+		{"Long Length", &Challenger{Key: key, Length: 9}, ErrorInvalidChallenge{msg: "unsupported code length 9"}},
+	}
+
+	for _, tc := range cases {
+		if _, _, err := tc.c.Issue("user-1", "login"); err != tc.expected {
+			t.Errorf("%s: unexpected error\nexpected: %v\n  actual: %v", tc.label, tc.expected, err)
+		}
+	}
+}
+
+func TestChallenger_Expired(t *testing.T) {
+	now := time.Date(2020, 10, 20, 8, 0, 0, 0, time.UTC)
+	c := &Challenger{Key: []byte("0123456789abcdef0123456789abcdef"), Length: config.Length8, TTL: time.Minute, Algorithm: config.HmacSHA512, Now: func() time.Time { return now }}
+
+	code, challenge, _ := c.Issue("user-1", "login")
+
+	now = now.Add(59 * time.Second)
+	if _, err := c.Verify(challenge, "user-1", "login", code); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	now = now.Add(time.Second)
+	if _, err := c.Verify(challenge, "user-1", "login", code); err == nil {
+		t.Error("expected error for expired challenge")
+	} else if _, ok := err.(ErrorExpired); !ok {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestChallenger_Unique(t *testing.T) {
+	c := &Challenger{Key: []byte("0123456789abcdef0123456789abcdef"), Length: config.Length8}
+
+	_, first, _ := c.Issue("user-1", "login")
+	_, second, _ := c.Issue("user-1", "login")
+
+	if first == second {
+		t.Errorf("challenges must be unique: %s", first)
+	}
+}
+
+func mustDecode(t *testing.T, s string) []byte {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		t.FailNow()
+	}
+
+	return b
+}
@@ -0,0 +1,231 @@
+// Package delivery implements short one-time codes delivered by email or SMS,
+// for users who don't have an authenticator app.
+//
+// A Manager issues a random code bound to a user and a purpose, hands it to a
+// Sender and only keeps its hash. The code can be verified once, before it
+// expires and within a limited number of attempts. Issuing codes is rate
+// limited per user and purpose, so the delivery channel can't be abused.
+//
+// A Challenger issues codes without any storage instead, binding each one to
+// a signed challenge token that is verified along with the code.
+package delivery
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/jltorresm/otpgo"
+	"github.com/jltorresm/otpgo/config"
+	"github.com/jltorresm/otpgo/internal/clock"
+)
+
+const (
+	// DefaultTTL is how long an issued code can be verified.
+	DefaultTTL = 10 * time.Minute
+	// DefaultMaxAttempts is the number of wrong codes accepted before an issued
+	// code is discarded.
+	DefaultMaxAttempts = 3
+	// DefaultMaxIssued is the number of codes that can be issued to a user for
+	// the same purpose within the IssueWindow.
+	DefaultMaxIssued = 5
+	// DefaultIssueWindow is the period over which issued codes are counted.
+	DefaultIssueWindow = time.Hour
+)
+
+// The Code type is an issued code as kept in the Store. It holds the hash of
+// the code, never the code itself.
+type Code struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"userId"`
+	Purpose   string    `json:"purpose"`
+	Hash      string    `json:"hash"` // Hex encoded HMAC-SHA256 of the code
+	Attempts  int       `json:"attempts"`
+	CreatedAt time.Time `json:"createdAt"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// The Issued type tells the caller which code to verify, without revealing it.
+type Issued struct {
+	ID        string    `json:"id"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// The Manager type issues and verifies delivered codes on top of a Store.
+type Manager struct {
+	Store       Store
+	Sender      Sender
+	Length      config.Length    // Defaults to config.Length6
+	Pepper      []byte           // Optional secret mixed into the hashes, so they can't be brute forced without it
+	TTL         time.Duration    // Defaults to DefaultTTL
+	MaxAttempts int              // Defaults to DefaultMaxAttempts
+	MaxIssued   int              // Defaults to DefaultMaxIssued
+	IssueWindow time.Duration    // Defaults to DefaultIssueWindow
+	Now         func() time.Time // Defaults to time.Now
+}
+
+// Issue creates a new code for the user and purpose, and sends it to the given
+// destination. It fails with ErrorRateLimited when MaxIssued codes were
+// already issued within the IssueWindow.
+func (m *Manager) Issue(ctx context.Context, userID, purpose, to string) (*Issued, error) {
+	now := clock.Now(m.Now)
+
+	// Reserving the issuance before creating the code bounds the codes sent,
+	// even when they are requested concurrently.
+	ok, err := m.Store.ReserveIssue(ctx, userID, purpose, now, now.Add(-m.issueWindow()), m.maxIssued())
+	if err != nil {
+		return nil, err
+	}
+
+	if !ok {
+		return nil, ErrorRateLimited{UserID: userID, Purpose: purpose}
+	}
+
+	id, err := randomID()
+	if err != nil {
+		return nil, err
+	}
+
+	// A fresh random key makes every code unpredictable.
+	key, err := otpgo.RandomKey()
+	if err != nil {
+		return nil, err
+	}
+
+	h := otpgo.HOTP{Key: key, Length: m.length()}
+	code, err := h.Generate()
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Code{
+		ID:        id,
+		UserID:    userID,
+		Purpose:   purpose,
+		CreatedAt: now,
+		ExpiresAt: now.Add(m.ttl()),
+	}
+	c.Hash = m.hash(c, code)
+
+	if err := m.Store.Create(ctx, c); err != nil {
+		return nil, err
+	}
+
+	msg := Message{To: to, UserID: userID, Purpose: purpose, Code: code, ExpiresAt: c.ExpiresAt}
+	if err := m.Sender.Send(ctx, msg); err != nil {
+		_ = m.Store.Delete(ctx, id)
+		return nil, err
+	}
+
+	return &Issued{ID: id, ExpiresAt: c.ExpiresAt}, nil
+}
+
+// Verify checks the code sent to the user for the given purpose, and consumes
+// it if it matches. Wrong codes can be retried until MaxAttempts is reached,
+// after which the issued code is discarded.
+func (m *Manager) Verify(ctx context.Context, id, userID, purpose, code string) error {
+	c, err := m.Store.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	// Codes of other users or purposes are reported as missing.
+	if c.UserID != userID || c.Purpose != purpose {
+		return ErrorNotFound{ID: id}
+	}
+
+	if !clock.Now(m.Now).Before(c.ExpiresAt) {
+		_ = m.Store.Delete(ctx, id)
+		return ErrorExpired{ID: id}
+	}
+
+	// Counting the attempt before checking the code bounds the guesses, even
+	// when they are made concurrently.
+	attempts, err := m.Store.AddAttempt(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if attempts > m.maxAttempts() {
+		_ = m.Store.Delete(ctx, id)
+		return ErrorTooManyAttempts{ID: id}
+	}
+
+	if !hmac.Equal([]byte(c.Hash), []byte(m.hash(c, code))) {
+		if attempts == m.maxAttempts() {
+			if err := m.Store.Delete(ctx, id); err != nil {
+				return err
+			}
+		}
+
+		return ErrorInvalidCode{ID: id}
+	}
+
+	// Only one concurrent verification can delete the code.
+	return m.Store.Delete(ctx, id)
+}
+
+// hash returns the hex encoded HMAC of the code, bound to the issued code id,
+// user and purpose.
+func (m *Manager) hash(c *Code, code string) string {
+	mac := hmac.New(sha256.New, m.Pepper)
+	for _, part := range []string{c.ID, c.UserID, c.Purpose, code} {
+		mac.Write([]byte(part))
+		mac.Write([]byte{0})
+	}
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (m *Manager) length() config.Length {
+	if m.Length == 0 {
+		return config.Length6
+	}
+
+	return m.Length
+}
+
+func (m *Manager) ttl() time.Duration {
+	if m.TTL <= 0 {
+		return DefaultTTL
+	}
+
+	return m.TTL
+}
+
+func (m *Manager) maxAttempts() int {
+	if m.MaxAttempts <= 0 {
+		return DefaultMaxAttempts
+	}
+
+	return m.MaxAttempts
+}
+
+func (m *Manager) maxIssued() int {
+	if m.MaxIssued <= 0 {
+		return DefaultMaxIssued
+	}
+
+	return m.MaxIssued
+}
+
+func (m *Manager) issueWindow() time.Duration {
+	if m.IssueWindow <= 0 {
+		return DefaultIssueWindow
+	}
+
+	return m.IssueWindow
+}
+
+// randomID returns a random identifier for an issued code.
+func randomID() (string, error) {
+	buff := make([]byte, 16)
+	if _, err := rand.Read(buff); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(buff), nil
+}
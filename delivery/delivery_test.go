@@ -0,0 +1,247 @@
+package delivery
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jltorresm/otpgo/config"
+)
+
+// outbox is a Sender that keeps the messages it is given.
+type outbox struct {
+	mu       sync.Mutex
+	messages []Message
+	err      error
+}
+
+func (o *outbox) Send(ctx context.Context, m Message) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.err != nil {
+		return o.err
+	}
+
+	o.messages = append(o.messages, m)
+
+	return nil
+}
+
+func (o *outbox) last() Message {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	return o.messages[len(o.messages)-1]
+}
+
+func TestManager_Issue(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	store := &MemoryStore{}
+	sender := &outbox{}
+	m := &Manager{Store: store, Sender: sender, Length: config.Length8, Pepper: []byte("pepper"), Now: func() time.Time { return now }}
+
+	issued, err := m.Issue(ctx, "user-1", "login", "john@example.com")
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		t.FailNow()
+	}
+
+	if !issued.ExpiresAt.Equal(now.Add(DefaultTTL)) {
+		t.Errorf("unexpected expiration\nexpected: %s\n  actual: %s", now.Add(DefaultTTL), issued.ExpiresAt)
+	}
+
+	msg := sender.last()
+	if msg.To != "john@example.com" || msg.UserID != "user-1" || msg.Purpose != "login" || len(msg.Code) != 8 {
+		t.Errorf("unexpected message: %+v", msg)
+	}
+
+	c, err := store.Get(ctx, issued.ID)
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		t.FailNow()
+	}
+
+	if c.Hash == "" || strings.Contains(c.Hash, msg.Code) {
+		t.Errorf("unexpected hash: %s", c.Hash)
+	}
+
+	other, _ := m.Issue(ctx, "user-1", "login", "john@example.com")
+	if other.ID == issued.ID || sender.last().Code == msg.Code {
+		t.Errorf("codes must be unique: %s, %s", msg.Code, sender.last().Code)
+	}
+}
+
+func TestManager_IssueRateLimit(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	m := &Manager{Store: &MemoryStore{}, Sender: &outbox{}, Length: config.Length8, Pepper: []byte("pepper"), Now: func() time.Time { return now }}
+	m.MaxIssued = 2
+	m.IssueWindow = time.Minute
+
+	for i := 0; i < 2; i++ {
+		if _, err := m.Issue(ctx, "user-1", "login", "john@example.com"); err != nil {
+			t.Errorf("unexpected error: %s", err)
+		}
+	}
+
+	expected := ErrorRateLimited{UserID: "user-1", Purpose: "login"}
+	if _, err := m.Issue(ctx, "user-1", "login", "john@example.com"); err != expected {
+		t.Errorf("unexpected error\nexpected: %v\n  actual: %v", expected, err)
+	}
+
+	// Other purposes are counted separately
+	if _, err := m.Issue(ctx, "user-1", "reset", "john@example.com"); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	now = now.Add(time.Minute)
+	if _, err := m.Issue(ctx, "user-1", "login", "john@example.com"); err != nil {
+		t.Errorf("unexpected error after the window: %s", err)
+	}
+}
+
+func TestManager_IssueConcurrent(t *testing.T) {
+	ctx := context.Background()
+	sender := &outbox{}
+	m := &Manager{Store: &MemoryStore{}, Sender: sender, Length: config.Length8, Pepper: []byte("pepper")}
+	m.MaxIssued = 3
+
+	const workers = 20
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = m.Issue(ctx, "user-1", "login", "john@example.com")
+		}()
+	}
+
+	wg.Wait()
+
+	if len(sender.messages) != m.MaxIssued {
+		t.Errorf("unexpected number of codes sent\nexpected: %d\n  actual: %d", m.MaxIssued, len(sender.messages))
+	}
+}
+
+func TestManager_IssueSendError(t *testing.T) {
+	ctx := context.Background()
+	store := &MemoryStore{}
+	sender := &outbox{}
+	m := &Manager{Store: store, Sender: sender, Length: config.Length8, Pepper: []byte("pepper")}
+	sender.err = errors.New("smtp is down")
+
+	if _, err := m.Issue(ctx, "user-1", "login", "john@example.com"); err != sender.err {
+		t.Errorf("unexpected error\nexpected: %v\n  actual: %v", sender.err, err)
+	}
+
+	if len(store.codes) != 0 {
+		t.Errorf("unexpected codes left in the store: %+v", store.codes)
+	}
+}
+
+func TestManager_Verify(t *testing.T) {
+	ctx := context.Background()
+	sender := &outbox{}
+	m := &Manager{Store: &MemoryStore{}, Sender: sender, Length: config.Length8, Pepper: []byte("pepper")}
+
+	issued, _ := m.Issue(ctx, "user-1", "login", "john@example.com")
+	code := sender.last().Code
+
+	cases := []struct {
+		label           string
+		userID, purpose string
+		code            string
+		expected        error
+	}{
+		{"Other User", "user-2", "login", code, ErrorNotFound{ID: issued.ID}},
+		{"Other Purpose", "user-1", "reset", code, ErrorNotFound{ID: issued.ID}},
+		{"Wrong Code", "user-1", "login", "00000000x", ErrorInvalidCode{ID: issued.ID}},
+		{"Valid", "user-1", "login", code, nil},
+		{"Reused", "user-1", "login", code, ErrorNotFound{ID: issued.ID}},
+	}
+
+	for _, c := range cases {
+		if err := m.Verify(ctx, issued.ID, c.userID, c.purpose, c.code); err != c.expected {
+			t.Errorf("%s: unexpected error\nexpected: %v\n  actual: %v", c.label, c.expected, err)
+		}
+	}
+}
+
+func TestManager_VerifyExpired(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	sender := &outbox{}
+	m := &Manager{Store: &MemoryStore{}, Sender: sender, Length: config.Length8, Pepper: []byte("pepper"), Now: func() time.Time { return now }}
+
+	issued, _ := m.Issue(ctx, "user-1", "login", "john@example.com")
+	now = now.Add(DefaultTTL)
+
+	if err := m.Verify(ctx, issued.ID, "user-1", "login", sender.last().Code); err != (ErrorExpired{ID: issued.ID}) {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if err := m.Verify(ctx, issued.ID, "user-1", "login", sender.last().Code); err != (ErrorNotFound{ID: issued.ID}) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestManager_VerifyMaxAttempts(t *testing.T) {
+	ctx := context.Background()
+	sender := &outbox{}
+	m := &Manager{Store: &MemoryStore{}, Sender: sender, Length: config.Length8, Pepper: []byte("pepper")}
+
+	issued, _ := m.Issue(ctx, "user-1", "login", "john@example.com")
+
+	for i := 0; i < DefaultMaxAttempts; i++ {
+		if err := m.Verify(ctx, issued.ID, "user-1", "login", "bad"); err != (ErrorInvalidCode{ID: issued.ID}) {
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+
+	if err := m.Verify(ctx, issued.ID, "user-1", "login", sender.last().Code); err != (ErrorNotFound{ID: issued.ID}) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestManager_VerifyConcurrent(t *testing.T) {
+	ctx := context.Background()
+	sender := &outbox{}
+	m := &Manager{Store: &MemoryStore{}, Sender: sender, Length: config.Length8, Pepper: []byte("pepper")}
+	m.MaxAttempts = 100
+
+	issued, _ := m.Issue(ctx, "user-1", "login", "john@example.com")
+	code := sender.last().Code
+
+	const workers = 20
+
+	var wg sync.WaitGroup
+	results := make(chan error, workers)
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results <- m.Verify(ctx, issued.ID, "user-1", "login", code)
+		}()
+	}
+
+	wg.Wait()
+	close(results)
+
+	successes := 0
+	for err := range results {
+		if err == nil {
+			successes++
+		}
+	}
+
+	if successes != 1 {
+		t.Errorf("unexpected number of successes\nexpected: %d\n  actual: %d", 1, successes)
+	}
+}
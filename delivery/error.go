@@ -0,0 +1,64 @@
+package delivery
+
+import (
+	"fmt"
+)
+
+// The ErrorNotFound represents a code that doesn't exist, was already used, or
+// that was issued to another user or for another purpose.
+type ErrorNotFound struct {
+	ID string
+}
+
+func (enf ErrorNotFound) Error() string {
+	return fmt.Sprintf("code not found: %s", enf.ID)
+}
+
+// The ErrorExpired represents a code that can no longer be verified.
+type ErrorExpired struct {
+	ID string
+}
+
+func (ee ErrorExpired) Error() string {
+	return fmt.Sprintf("code expired: %s", ee.ID)
+}
+
+// The ErrorInvalidCode represents a wrong code.
+type ErrorInvalidCode struct {
+	ID string
+}
+
+func (eic ErrorInvalidCode) Error() string {
+	return fmt.Sprintf("invalid code: %s", eic.ID)
+}
+
+// The ErrorTooManyAttempts represents a code discarded after too many wrong
+// attempts.
+type ErrorTooManyAttempts struct {
+	ID string
+}
+
+func (etma ErrorTooManyAttempts) Error() string {
+	return fmt.Sprintf("too many attempts for code: %s", etma.ID)
+}
+
+// The ErrorRateLimited represents a code that was not issued because too many
+// were issued recently to the same user for the same purpose.
+type ErrorRateLimited struct {
+	UserID  string
+	Purpose string
+}
+
+func (erl ErrorRateLimited) Error() string {
+	return fmt.Sprintf("too many codes issued to %s for %s", erl.UserID, erl.Purpose)
+}
+
+// The ErrorInvalidChallenge represents a challenge token that is malformed, or
+// that was issued to another user or for another purpose.
+type ErrorInvalidChallenge struct {
+	msg string
+}
+
+func (eic ErrorInvalidChallenge) Error() string {
+	return fmt.Sprintf("invalid challenge: %s", eic.msg)
+}
@@ -0,0 +1,26 @@
+package delivery
+
+import (
+	"testing"
+)
+
+func TestErrors(t *testing.T) {
+	cases := []struct {
+		label    string
+		err      error
+		expected string
+	}{
+		{"Not Found", ErrorNotFound{ID: "abc"}, "code not found: abc"},
+		{"Expired", ErrorExpired{ID: "abc"}, "code expired: abc"},
+		{"Invalid Code", ErrorInvalidCode{ID: "abc"}, "invalid code: abc"},
+		{"Too Many Attempts", ErrorTooManyAttempts{ID: "abc"}, "too many attempts for code: abc"},
+		{"Rate Limited", ErrorRateLimited{UserID: "user-1", Purpose: "login"}, "too many codes issued to user-1 for login"},
+		{"Invalid Challenge", ErrorInvalidChallenge{msg: "malformed token"}, "invalid challenge: malformed token"},
+	}
+
+	for _, c := range cases {
+		if c.expected != c.err.Error() {
+			t.Errorf("case %s: unexpected error\nexpected: %s\n  actual: %s", c.label, c.expected, c.err.Error())
+		}
+	}
+}
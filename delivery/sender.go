@@ -0,0 +1,53 @@
+package delivery
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// The Message type is what a Sender delivers to the user.
+type Message struct {
+	To        string    // Email address, phone number or any destination known to the Sender
+	UserID    string    // User the code was issued to
+	Purpose   string    // What the code is for, e.g.: "login"
+	Code      string    // The code itself, never stored
+	ExpiresAt time.Time // After which the code is no longer accepted
+}
+
+// The Sender interface delivers codes to the users, e.g.: by email or SMS.
+type Sender interface {
+	Send(ctx context.Context, m Message) error
+}
+
+// The WriterSender type is a Sender that writes messages to Output instead of
+// delivering them, as a stand-in during development. Point Output to a file to
+// keep them, it defaults to the standard output. It is safe for concurrent use.
+type WriterSender struct {
+	Output io.Writer // Defaults to os.Stdout
+
+	mu sync.Mutex
+}
+
+// Send writes the message as a single line.
+func (ws *WriterSender) Send(ctx context.Context, m Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	output := ws.Output
+	if output == nil {
+		output = os.Stdout
+	}
+
+	_, err := fmt.Fprintf(output, "to=%q purpose=%q code=%s expires=%s\n",
+		m.To, m.Purpose, m.Code, m.ExpiresAt.UTC().Format(time.RFC3339))
+
+	return err
+}
@@ -0,0 +1,37 @@
+package delivery
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+)
+
+func TestWriterSender(t *testing.T) {
+	var buff bytes.Buffer
+	ws := &WriterSender{Output: &buff}
+
+	msg := Message{
+		To:        "john@example.com",
+		UserID:    "user-1",
+		Purpose:   "login",
+		Code:      "123456",
+		ExpiresAt: time.Date(2020, 10, 20, 8, 0, 0, 0, time.UTC),
+	}
+
+	if err := ws.Send(context.Background(), msg); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	expected := "to=\"john@example.com\" purpose=\"login\" code=123456 expires=2020-10-20T08:00:00Z\n"
+	if actual := buff.String(); expected != actual {
+		t.Errorf("unexpected output\nexpected: %s\n  actual: %s", expected, actual)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := ws.Send(ctx, msg); err != context.Canceled {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,148 @@
+package delivery
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// The Store interface persists issued codes, and the issuance history used for
+// rate limiting. Every method should give up when the context is done.
+type Store interface {
+	// Create stores a new code.
+	Create(ctx context.Context, c *Code) error
+	// Get returns the code or ErrorNotFound.
+	Get(ctx context.Context, id string) (*Code, error)
+	// AddAttempt atomically increments the attempts of the code and returns
+	// the new count, or ErrorNotFound.
+	AddAttempt(ctx context.Context, id string) (attempts int, err error)
+	// Delete removes the code, or returns ErrorNotFound if it doesn't exist,
+	// so that concurrent verifications can only consume a code once.
+	Delete(ctx context.Context, id string) error
+	// ReserveIssue atomically records the issuance of a code for the user and
+	// purpose at the given time, unless max codes were already issued after
+	// since. It reports whether the issuance was recorded.
+	ReserveIssue(ctx context.Context, userID, purpose string, at, since time.Time, max int) (ok bool, err error)
+}
+
+// The MemoryStore type is a Store that keeps everything in memory, suitable
+// for tests and single instance deployments. It is safe for concurrent use.
+type MemoryStore struct {
+	mu     sync.Mutex
+	codes  map[string]Code
+	issued map[string][]time.Time
+}
+
+// Create stores a copy of the code.
+func (ms *MemoryStore) Create(ctx context.Context, c *Code) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if ms.codes == nil {
+		ms.codes = map[string]Code{}
+	}
+
+	ms.codes[c.ID] = *c
+
+	return nil
+}
+
+// Get returns a copy of the code.
+func (ms *MemoryStore) Get(ctx context.Context, id string) (*Code, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	c, ok := ms.codes[id]
+	if !ok {
+		return nil, ErrorNotFound{ID: id}
+	}
+
+	return &c, nil
+}
+
+// AddAttempt increments the attempts of the code.
+func (ms *MemoryStore) AddAttempt(ctx context.Context, id string) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	c, ok := ms.codes[id]
+	if !ok {
+		return 0, ErrorNotFound{ID: id}
+	}
+
+	c.Attempts++
+	ms.codes[id] = c
+
+	return c.Attempts, nil
+}
+
+// Delete removes the code.
+func (ms *MemoryStore) Delete(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if _, ok := ms.codes[id]; !ok {
+		return ErrorNotFound{ID: id}
+	}
+
+	delete(ms.codes, id)
+
+	return nil
+}
+
+// ReserveIssue counts the codes issued to the user and purpose after since,
+// forgetting the older ones, and records a new one if there are less than max.
+func (ms *MemoryStore) ReserveIssue(ctx context.Context, userID, purpose string, at, since time.Time, max int) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if ms.issued == nil {
+		ms.issued = map[string][]time.Time{}
+	}
+
+	key := issuedKey(userID, purpose)
+
+	var recent []time.Time
+	for _, t := range ms.issued[key] {
+		if t.After(since) {
+			recent = append(recent, t)
+		}
+	}
+
+	ok := len(recent) < max
+	if ok {
+		recent = append(recent, at)
+	}
+
+	if len(recent) == 0 {
+		delete(ms.issued, key)
+	} else {
+		ms.issued[key] = recent
+	}
+
+	return ok, nil
+}
+
+func issuedKey(userID, purpose string) string {
+	return userID + "\x00" + purpose
+}
@@ -0,0 +1,109 @@
+package delivery
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	ms := &MemoryStore{}
+	now := time.Now()
+
+	if _, err := ms.Get(ctx, "missing"); err != (ErrorNotFound{ID: "missing"}) {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	c := &Code{ID: "abc", UserID: "user-1", Purpose: "login", Hash: "HASH", CreatedAt: now}
+	if err := ms.Create(ctx, c); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	// The store keeps its own copy.
+	c.Hash = "CHANGED"
+
+	stored, err := ms.Get(ctx, "abc")
+	if err != nil || stored.Hash != "HASH" {
+		t.Errorf("unexpected code: %+v, %v", stored, err)
+	}
+
+	for expected := 1; expected <= 2; expected++ {
+		if attempts, err := ms.AddAttempt(ctx, "abc"); attempts != expected || err != nil {
+			t.Errorf("unexpected attempts\nexpected: %d\n  actual: %d (%v)", expected, attempts, err)
+		}
+	}
+
+	if err := ms.Delete(ctx, "abc"); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	if err := ms.Delete(ctx, "abc"); err != (ErrorNotFound{ID: "abc"}) {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if _, err := ms.AddAttempt(ctx, "abc"); err != (ErrorNotFound{ID: "abc"}) {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+}
+
+func TestMemoryStore_ReserveIssue(t *testing.T) {
+	ctx := context.Background()
+	ms := &MemoryStore{}
+	now := time.Now()
+
+	cases := []struct {
+		label    string
+		purpose  string
+		at       time.Time
+		since    time.Time
+		expected bool
+	}{
+		{"First", "login", now, now.Add(-time.Minute), true},
+		{"Second", "login", now, now.Add(-time.Minute), true},
+		{"Over The Limit", "login", now, now.Add(-time.Minute), false},
+		{"Other Purpose", "reset", now, now.Add(-time.Minute), true},
+		{"Window Passed", "login", now.Add(time.Minute), now, true},
+		{"Rejected Not Recorded", "login", now.Add(time.Minute), now, true},
+		{"Over The Limit Again", "login", now.Add(time.Minute), now, false},
+	}
+
+	for _, c := range cases {
+		ok, err := ms.ReserveIssue(ctx, "user-1", c.purpose, c.at, c.since, 2)
+		if err != nil {
+			t.Errorf("case %s: unexpected error: %s", c.label, err)
+		}
+
+		if c.expected != ok {
+			t.Errorf("case %s: unexpected reservation\nexpected: %v\n  actual: %v", c.label, c.expected, ok)
+		}
+	}
+}
+
+func TestMemoryStore_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	ms := &MemoryStore{}
+
+	if err := ms.Create(ctx, &Code{ID: "abc"}); err != context.Canceled {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if _, err := ms.Get(ctx, "abc"); err != context.Canceled {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if _, err := ms.AddAttempt(ctx, "abc"); err != context.Canceled {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if err := ms.Delete(ctx, "abc"); err != context.Canceled {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if _, err := ms.ReserveIssue(ctx, "user-1", "login", time.Now(), time.Now(), 1); err != context.Canceled {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,25 @@
+package device
+
+import (
+	"fmt"
+)
+
+// The ErrorDeviceNotFound represents a device that is not registered.
+type ErrorDeviceNotFound struct {
+	UserID string
+	Name   string
+}
+
+func (ednf ErrorDeviceNotFound) Error() string {
+	return fmt.Sprintf("device %q not found for user %s", ednf.Name, ednf.UserID)
+}
+
+// The ErrorDeviceExists represents a device name already taken by the user.
+type ErrorDeviceExists struct {
+	UserID string
+	Name   string
+}
+
+func (ede ErrorDeviceExists) Error() string {
+	return fmt.Sprintf("device %q already registered for user %s", ede.Name, ede.UserID)
+}
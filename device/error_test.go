@@ -0,0 +1,23 @@
+package device
+
+import (
+	"testing"
+)
+
+func TestErrorDeviceNotFound_Error(t *testing.T) {
+	err := ErrorDeviceNotFound{UserID: "john", Name: "phone"}
+	expectedError := `device "phone" not found for user john`
+
+	if err.Error() != expectedError {
+		t.Errorf("unexpected error\nexpected: %s\n  actual: %s", expectedError, err.Error())
+	}
+}
+
+func TestErrorDeviceExists_Error(t *testing.T) {
+	err := ErrorDeviceExists{UserID: "john", Name: "phone"}
+	expectedError := `device "phone" already registered for user john`
+
+	if err.Error() != expectedError {
+		t.Errorf("unexpected error\nexpected: %s\n  actual: %s", expectedError, err.Error())
+	}
+}
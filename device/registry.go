@@ -0,0 +1,187 @@
+// Package device keeps track of the authenticators registered by each user, so
+// that a token can be accepted from any of them.
+package device
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/jltorresm/otpgo"
+	"github.com/jltorresm/otpgo/internal/clock"
+)
+
+// The Device type is a named authenticator holding any OTP configuration.
+type Device struct {
+	Name     string    `json:"name"`
+	OTP      otpgo.OTP `json:"-"` // Stored along with its type, see otpgo.Envelope
+	Created  time.Time `json:"created"`
+	LastUsed time.Time `json:"lastUsed"`
+}
+
+// The deviceJSON type is the JSON representation of a Device.
+type deviceJSON struct {
+	deviceAlias
+	OTP otpgo.Envelope `json:"otp"`
+}
+
+type deviceAlias Device
+
+// MarshalJSON encodes the device, wrapping the OTP in an otpgo.Envelope.
+func (d Device) MarshalJSON() ([]byte, error) {
+	return json.Marshal(deviceJSON{deviceAlias: deviceAlias(d), OTP: otpgo.Envelope{OTP: d.OTP}})
+}
+
+// UnmarshalJSON decodes a device encoded by MarshalJSON.
+func (d *Device) UnmarshalJSON(data []byte) error {
+	raw := deviceJSON{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*d = Device(raw.deviceAlias)
+	d.OTP = raw.OTP.OTP
+
+	return nil
+}
+
+// clone returns a deep copy of the device, so that validating or returning it
+// never exposes the registry state.
+func (d *Device) clone() *Device {
+	c := *d
+	c.OTP = otpgo.Clone(d.OTP)
+
+	return &c
+}
+
+// hasKey tells whether the OTP has a secret key, a missing key would otherwise
+// be replaced by a random one the user doesn't know.
+func hasKey(o otpgo.OTP) bool {
+	switch t := o.(type) {
+	case *otpgo.HOTP:
+		return t.Key != ""
+	case *otpgo.TOTP:
+		return t.Key != ""
+	case *otpgo.MOTP:
+		return t.Key != ""
+	}
+
+	return true
+}
+
+// The Registry type holds the devices of every user. It is safe for concurrent
+// use, validations of the same registry are serialized so an HOTP token can
+// only be used once.
+type Registry struct {
+	Now func() time.Time // Defaults to time.Now
+
+	mu      sync.Mutex
+	devices map[string][]*Device
+}
+
+// Register adds a device for the user. Device names are unique per user.
+func (r *Registry) Register(userID string, d Device) error {
+	if d.Name == "" {
+		return errors.New("device name must not be empty")
+	}
+
+	if d.OTP == nil {
+		return errors.New("device must hold an otp")
+	}
+
+	if !hasKey(d.OTP) {
+		return errors.New("device must have a secret key")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.devices[userID] {
+		if existing.Name == d.Name {
+			return ErrorDeviceExists{UserID: userID, Name: d.Name}
+		}
+	}
+
+	c := d.clone()
+	c.OTP = otpgo.Normalize(c.OTP)
+	if c.Created.IsZero() {
+		c.Created = clock.Now(r.Now)
+	}
+
+	if r.devices == nil {
+		r.devices = map[string][]*Device{}
+	}
+	r.devices[userID] = append(r.devices[userID], c)
+
+	return nil
+}
+
+// Remove deletes the named device of the user.
+func (r *Registry) Remove(userID, name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	devices := r.devices[userID]
+	for i, d := range devices {
+		if d.Name == name {
+			r.devices[userID] = append(devices[:i:i], devices[i+1:]...)
+			return nil
+		}
+	}
+
+	return ErrorDeviceNotFound{UserID: userID, Name: name}
+}
+
+// Devices returns a copy of the devices registered by the user, in
+// registration order.
+func (r *Registry) Devices(userID string) []Device {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	list := make([]Device, 0, len(r.devices[userID]))
+	for _, d := range r.devices[userID] {
+		list = append(list, *d.clone())
+	}
+
+	return list
+}
+
+// Validate checks the token against every device of the user and returns the
+// name of the one that matched. All devices are always checked, so the time
+// taken doesn't reveal which one matched. On success the device's last used
+// time is updated and, for event based devices like HOTP, its counter is
+// advanced.
+func (r *Registry) Validate(userID, token string) (name string, ok bool, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var matched, candidate *Device
+	var firstErr error
+
+	for _, d := range r.devices[userID] {
+		candidate = d.clone()
+
+		valid, err := candidate.OTP.Validate(token)
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+
+		if valid && matched == nil {
+			matched = candidate
+		}
+	}
+
+	if matched == nil {
+		return "", false, firstErr
+	}
+
+	for _, d := range r.devices[userID] {
+		if d.Name == matched.Name {
+			d.OTP = matched.OTP
+			d.LastUsed = clock.Now(r.Now)
+		}
+	}
+
+	return matched.Name, true, nil
+}
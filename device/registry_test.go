@@ -0,0 +1,177 @@
+package device
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jltorresm/otpgo"
+	"github.com/jltorresm/otpgo/config"
+)
+
+const (
+	phoneKey    = "73QK7D3A3PIZ6NUQQBF4BNFYQBRVUHUQ"
+	hardwareKey = "73QK7D3A3PIZ6NUQQBF4BNFYQBRVUHUP"
+)
+
+func TestRegistry_Register(t *testing.T) {
+	now := time.Date(2020, 9, 10, 12, 0, 0, 0, time.UTC)
+	r := &Registry{Now: func() time.Time { return now }}
+	_ = r.Register("john", Device{Name: "phone", OTP: &otpgo.TOTP{Key: phoneKey}})
+	_ = r.Register("john", Device{Name: "token", OTP: &otpgo.HOTP{Key: hardwareKey, Counter: 363, Algorithm: config.HmacSHA256}})
+
+	cases := []struct {
+		label       string
+		device      Device
+		expectedErr string
+	}{
+		{"Duplicate", Device{Name: "phone", OTP: &otpgo.TOTP{Key: phoneKey}}, `device "phone" already registered for user john`},
+		{"No Name", Device{OTP: &otpgo.TOTP{Key: phoneKey}}, "device name must not be empty"},
+		{"No OTP", Device{Name: "x"}, "device must hold an otp"},
+		{"No Key", Device{Name: "x", OTP: &otpgo.TOTP{}}, "device must have a secret key"},
+		{"No HOTP Key", Device{Name: "x", OTP: &otpgo.HOTP{}}, "device must have a secret key"},
+		{"No MOTP Key", Device{Name: "x", OTP: &otpgo.MOTP{PIN: "1234"}}, "device must have a secret key"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.label, func(t *testing.T) {
+			err := r.Register("john", c.device)
+
+			if err == nil || c.expectedErr != err.Error() {
+				t.Errorf("unexpected error\nexpected: %s\n  actual: %v", c.expectedErr, err)
+			}
+		})
+	}
+
+	devices := r.Devices("john")
+	if len(devices) != 2 || devices[0].Name != "phone" || devices[1].Name != "token" {
+		t.Errorf("unexpected devices: %+v", devices)
+	}
+
+	if !devices[0].Created.Equal(now) {
+		t.Errorf("unexpected created time\nexpected: %s\n  actual: %s", now, devices[0].Created)
+	}
+
+	// Other users are unaffected.
+	if err := r.Register("jane", Device{Name: "phone", OTP: &otpgo.TOTP{Key: phoneKey}}); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	// Devices are stored with the defaults applied, so they can be encoded.
+	expected := &otpgo.TOTP{Key: phoneKey, Period: 30, Delay: 1, Algorithm: config.HmacSHA1, Length: config.Length6}
+	if stored := r.Devices("jane")[0].OTP; !reflect.DeepEqual(expected, stored) {
+		t.Errorf("unexpected otp\nexpected: %+v\n  actual: %+v", expected, stored)
+	}
+
+	if _, err := json.Marshal(r.Devices("jane")); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+}
+
+func TestRegistry_Validate(t *testing.T) {
+	now := time.Date(2020, 9, 10, 12, 0, 0, 0, time.UTC)
+	r := &Registry{Now: func() time.Time { return now }}
+	_ = r.Register("john", Device{Name: "phone", OTP: &otpgo.TOTP{Key: phoneKey}})
+	_ = r.Register("john", Device{Name: "token", OTP: &otpgo.HOTP{Key: hardwareKey, Counter: 363, Algorithm: config.HmacSHA256}})
+
+	phone := &otpgo.TOTP{Key: phoneKey}
+	phoneCode, _ := phone.Generate()
+
+	cases := []struct {
+		label        string
+		token        string
+		expectedName string
+		expectedOk   bool
+	}{
+		{"Phone", phoneCode, "phone", true},
+		{"Hardware Token", "363033", "token", true},
+		{"Wrong Token", "12345x", "", false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.label, func(t *testing.T) {
+			name, ok, err := r.Validate("john", c.token)
+			if err != nil {
+				t.Errorf("unexpected error: %s", err)
+			}
+
+			if c.expectedName != name || c.expectedOk != ok {
+				t.Errorf("unexpected result\nexpected: %s, %v\n  actual: %s, %v", c.expectedName, c.expectedOk, name, ok)
+			}
+		})
+	}
+
+	devices := r.Devices("john")
+	if counter := devices[1].OTP.(*otpgo.HOTP).Counter; counter != 364 {
+		t.Errorf("unexpected hotp counter\nexpected: %d\n  actual: %d", 364, counter)
+	}
+
+	for _, d := range devices {
+		if !d.LastUsed.Equal(now) {
+			t.Errorf("unexpected last used time for %s\nexpected: %s\n  actual: %s", d.Name, now, d.LastUsed)
+		}
+	}
+
+	if name, ok, err := r.Validate("nobody", phoneCode); name != "" || ok || err != nil {
+		t.Errorf("unexpected result for unknown user: %s, %v, %v", name, ok, err)
+	}
+}
+
+func TestRegistry_ValidateError(t *testing.T) {
+	r := &Registry{}
+	_ = r.Register("john", Device{Name: "broken", OTP: &otpgo.TOTP{Key: "not-base-32"}})
+
+	_, ok, err := r.Validate("john", "123456")
+	if ok || err == nil {
+		t.Errorf("expected error for invalid key, got: %v, %v", ok, err)
+	}
+
+	// A valid device still matches despite the broken one.
+	_ = r.Register("john", Device{Name: "token", OTP: &otpgo.HOTP{Key: hardwareKey, Counter: 363, Algorithm: config.HmacSHA256}})
+	name, ok, err := r.Validate("john", "363033")
+	if name != "token" || !ok || err != nil {
+		t.Errorf("unexpected result: %s, %v, %v", name, ok, err)
+	}
+}
+
+func TestRegistry_Remove(t *testing.T) {
+	r := &Registry{}
+	_ = r.Register("john", Device{Name: "phone", OTP: &otpgo.TOTP{Key: phoneKey}})
+	_ = r.Register("john", Device{Name: "token", OTP: &otpgo.HOTP{Key: hardwareKey, Counter: 363, Algorithm: config.HmacSHA256}})
+
+	if err := r.Remove("john", "phone"); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	expectedErr := ErrorDeviceNotFound{UserID: "john", Name: "phone"}
+	if err := r.Remove("john", "phone"); err != expectedErr {
+		t.Errorf("unexpected error\nexpected: %v\n  actual: %v", expectedErr, err)
+	}
+
+	devices := r.Devices("john")
+	if len(devices) != 1 || devices[0].Name != "token" {
+		t.Errorf("unexpected devices: %+v", devices)
+	}
+}
+
+func TestDevice_JSON(t *testing.T) {
+	created := time.Date(2020, 10, 20, 8, 0, 0, 0, time.UTC)
+	d := Device{Name: "token", OTP: &otpgo.HOTP{Key: hardwareKey, Counter: 7, Leeway: 1, Algorithm: config.HmacSHA1, Length: config.Length6}, Created: created}
+
+	raw, err := json.Marshal(d)
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		t.FailNow()
+	}
+
+	decoded := Device{}
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		t.Errorf("unexpected error: %s", err)
+		t.FailNow()
+	}
+
+	if !reflect.DeepEqual(d, decoded) {
+		t.Errorf("unexpected device\nexpected: %+v\n  actual: %+v", d, decoded)
+	}
+}
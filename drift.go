@@ -0,0 +1,209 @@
+package otpgo
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/jltorresm/otpgo/internal/clock"
+)
+
+// TOTPDefaultMaxDrift is the default limit, in steps, of the clock drift
+// learned by DriftTOTP. With the default period it allows devices running up
+// to 5 minutes off.
+const TOTPDefaultMaxDrift = 10
+
+// The DriftStore interface keeps the clock drift learned for TOTP credentials,
+// identified by an arbitrary id. Implementations must be safe for concurrent
+// use, and should give up when the context is done.
+type DriftStore interface {
+	// LoadDrift returns the drift stored for id in steps, 0 if there is none.
+	LoadDrift(ctx context.Context, id string) (steps int, err error)
+
+	// SaveDrift stores the drift learned for id in steps.
+	SaveDrift(ctx context.Context, id string, steps int) error
+}
+
+// The MemoryDriftStore type is a DriftStore that keeps drifts in memory. The
+// zero value is ready to use.
+type MemoryDriftStore struct {
+	mu     sync.Mutex
+	drifts map[string]int
+}
+
+// LoadDrift returns the drift stored for id.
+func (m *MemoryDriftStore) LoadDrift(ctx context.Context, id string) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.drifts[id], nil
+}
+
+// SaveDrift stores the drift for id.
+func (m *MemoryDriftStore) SaveDrift(ctx context.Context, id string, steps int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.drifts == nil {
+		m.drifts = map[string]int{}
+	}
+	m.drifts[id] = steps
+
+	return nil
+}
+
+// The DriftTOTP type validates a TOTP credential whose device clock may be
+// off. The step offset of every successful validation is stored as the
+// credential drift, and the next validation window is centered on it instead of
+// on the server time. Codes matching the server time are still accepted, so a
+// device whose clock is fixed recovers. The TOTP config itself is never
+// modified.
+//
+// The learned drift is capped to MaxDrift steps, so a device can't be made to
+// accept codes arbitrarily far from the server time.
+type DriftTOTP struct {
+	ID       string           // Identifies the drift in the store
+	TOTP     TOTP             // Key and parameters, the window is given by Delay or PastSkew and FutureSkew
+	Store    DriftStore       // Where the learned drift is kept
+	MaxDrift int              // Limit of the learned drift in steps, defaults to TOTPDefaultMaxDrift
+	Now      func() time.Time // Defaults to time.Now
+}
+
+// Drift returns the drift learned for the credential in steps, negative values
+// mean the device clock is behind.
+func (d *DriftTOTP) Drift() (int, error) {
+	return d.drift(context.Background())
+}
+
+func (d *DriftTOTP) drift(ctx context.Context) (int, error) {
+	if d.Store == nil {
+		return 0, ErrorInvalidConfig{msg: "missing drift store"}
+	}
+
+	if d.MaxDrift < 0 {
+		return 0, ErrorInvalidConfig{msg: "max drift must not be negative"}
+	}
+
+	drift, err := d.Store.LoadDrift(ctx, d.ID)
+	if err != nil {
+		return 0, err
+	}
+
+	return d.capDrift(drift), nil
+}
+
+// Validate checks the token against the window centered on the learned drift,
+// and then against the one centered on the server time. If the validation is
+// successful the drift is updated with the offset of the matching step.
+//
+// The TOTP Observer, if any, is notified of the outcome with ID as the
+// credential id, and the offset from the server time.
+func (d *DriftTOTP) Validate(token string) (bool, error) {
+	return d.ValidateContext(context.Background(), token)
+}
+
+// ValidateContext is like Validate, passing the context to the store.
+func (d *DriftTOTP) ValidateContext(ctx context.Context, token string) (bool, error) {
+	valid, reason, offset, err := d.validate(ctx, token)
+	if valid {
+		notify(d.TOTP.Observer, success(d.ID, TypeTOTP, int64(offset)))
+	} else {
+		notify(d.TOTP.Observer, failure(d.ID, TypeTOTP, reason))
+	}
+
+	return valid, err
+}
+
+// validate does the work of Validate, returning the reason of a failure or the
+// offset of the matching step.
+func (d *DriftTOTP) validate(ctx context.Context, token string) (valid bool, reason string, offset int, err error) {
+	t, err := d.config()
+	if err != nil {
+		return false, ReasonError, 0, err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return false, ReasonError, 0, err
+	}
+
+	if !wellFormed(token, int(t.Length)) {
+		return false, ReasonInvalidFormat, 0, nil
+	}
+
+	drift, err := d.drift(ctx)
+	if err != nil {
+		return false, ReasonError, 0, err
+	}
+
+	now := clock.Now(d.Now).Unix()
+
+	offset, ok, err := t.match(now, drift, token, nil)
+	if err != nil {
+		return false, ReasonError, 0, err
+	}
+
+	// A device whose clock was fixed would otherwise be locked out, the window
+	// around the server time is checked too and the drift learned again.
+	if !ok && drift != 0 {
+		offset, ok, err = t.match(now, 0, token, nil)
+		if err != nil {
+			return false, ReasonError, 0, err
+		}
+	}
+
+	if !ok {
+		return false, ReasonInvalidToken, 0, nil
+	}
+
+	if learned := d.capDrift(offset); learned != drift {
+		if err := d.Store.SaveDrift(ctx, d.ID, learned); err != nil {
+			return false, ReasonError, 0, err
+		}
+	}
+
+	return true, "", offset, nil
+}
+
+// config returns a copy of the TOTP config with the defaults applied, leaving
+// the shared one untouched.
+func (d *DriftTOTP) config() (TOTP, error) {
+	t := d.TOTP
+
+	if t.Key == "" {
+		return t, ErrorInvalidConfig{msg: "missing secret key"}
+	}
+
+	if d.Store == nil {
+		return t, ErrorInvalidConfig{msg: "missing drift store"}
+	}
+
+	t.ensureDefaults()
+
+	return t, nil
+}
+
+// capDrift limits the drift to MaxDrift steps in either direction.
+func (d *DriftTOTP) capDrift(steps int) int {
+	max := d.MaxDrift
+	if max == 0 {
+		max = TOTPDefaultMaxDrift
+	}
+
+	if steps > max {
+		return max
+	}
+
+	if steps < -max {
+		return -max
+	}
+
+	return steps
+}
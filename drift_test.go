@@ -0,0 +1,131 @@
+package otpgo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jltorresm/otpgo/config"
+)
+
+// driftCodeAt returns the code of the DriftTOTP for the given step offset from
+// its current time.
+func driftCodeAt(t *testing.T, d *DriftTOTP, offset int) string {
+	step := d.Now().Unix()/30 + int64(offset)
+
+	code, err := generateOTP(d.TOTP.Key, uint64(step), config.Length6, config.HmacSHA256)
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		t.FailNow()
+	}
+
+	return code
+}
+
+func TestDriftTOTP_Validate(t *testing.T) {
+	now := time.Date(2020, 10, 20, 8, 0, 0, 0, time.UTC)
+	d := &DriftTOTP{ID: "john", TOTP: TOTP{Key: "73QK7D3A3PIZ6NUQQBF4BNFYQBRVUHUQ", Algorithm: config.HmacSHA256}, Store: &MemoryDriftStore{}, MaxDrift: 3, Now: func() time.Time { return now }}
+
+	cases := []struct {
+		label         string
+		offset        int
+		expectedValid bool
+		expectedDrift int
+	}{
+		{"On Time", 0, true, 0},
+		{"Too Slow", -2, false, 0},
+		{"One Step Slow", -1, true, -1},
+		{"Two Steps Slow", -2, true, -2},
+		{"Three Steps Slow", -3, true, -3},
+		{"Four Steps Slow", -4, true, -3},
+		{"Five Steps Slow", -5, false, -3},
+		{"Back On Time", 0, true, 0},
+		{"Slow Again", -1, true, -1},
+	}
+
+	for _, c := range cases {
+		valid, err := d.Validate(driftCodeAt(t, d, c.offset))
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", c.label, err)
+		}
+
+		if valid != c.expectedValid {
+			t.Errorf("%s: unexpected validation\nexpected: %v\n  actual: %v", c.label, c.expectedValid, valid)
+		}
+
+		if drift, _ := d.Drift(); drift != c.expectedDrift {
+			t.Errorf("%s: unexpected drift\nexpected: %d\n  actual: %d", c.label, c.expectedDrift, drift)
+		}
+	}
+
+	if d.TOTP.Period != 0 || d.TOTP.Delay != 0 {
+		t.Errorf("unexpected change in the totp config: %+v", d.TOTP)
+	}
+}
+
+func TestDriftTOTP_Window(t *testing.T) {
+	now := time.Date(2020, 10, 20, 8, 0, 0, 0, time.UTC)
+	d := &DriftTOTP{ID: "john", TOTP: TOTP{Key: "73QK7D3A3PIZ6NUQQBF4BNFYQBRVUHUQ", Algorithm: config.HmacSHA256}, Store: &MemoryDriftStore{}, MaxDrift: 3, Now: func() time.Time { return now }}
+	d.TOTP.PastSkew = 1
+	_ = d.Store.SaveDrift(context.Background(), "john", 2)
+
+	cases := []struct {
+		label         string
+		offset        int
+		expectedValid bool
+	}{
+		{"Future", 3, false},
+		{"Too Far Past", -2, false},
+		{"Centered", 2, true},
+		{"Past", 1, true},
+	}
+
+	for _, c := range cases {
+		if valid, _ := d.Validate(driftCodeAt(t, d, c.offset)); valid != c.expectedValid {
+			t.Errorf("%s: unexpected validation\nexpected: %v\n  actual: %v", c.label, c.expectedValid, valid)
+		}
+	}
+
+	if drift, _ := d.Drift(); drift != 1 {
+		t.Errorf("unexpected drift\nexpected: %d\n  actual: %d", 1, drift)
+	}
+}
+
+func TestDriftTOTP_Cap(t *testing.T) {
+	now := time.Date(2020, 10, 20, 8, 0, 0, 0, time.UTC)
+	d := &DriftTOTP{ID: "john", TOTP: TOTP{Key: "73QK7D3A3PIZ6NUQQBF4BNFYQBRVUHUQ", Algorithm: config.HmacSHA256}, Store: &MemoryDriftStore{}, MaxDrift: 3, Now: func() time.Time { return now }}
+	_ = d.Store.SaveDrift(context.Background(), "john", 100)
+
+	if drift, _ := d.Drift(); drift != 3 {
+		t.Errorf("unexpected drift\nexpected: %d\n  actual: %d", 3, drift)
+	}
+
+	d.MaxDrift = 0
+	if drift, _ := d.Drift(); drift != TOTPDefaultMaxDrift {
+		t.Errorf("unexpected drift\nexpected: %d\n  actual: %d", TOTPDefaultMaxDrift, drift)
+	}
+}
+
+func TestDriftTOTP_InvalidConfig(t *testing.T) {
+	cases := []struct {
+		label       string
+		d           *DriftTOTP
+		expectedErr error
+	}{
+		{"No Key", &DriftTOTP{Store: &MemoryDriftStore{}}, ErrorInvalidConfig{msg: "missing secret key"}},
+		{"No Store", &DriftTOTP{TOTP: TOTP{Key: "73QK7D3A3PIZ6NUQQBF4BNFYQBRVUHUQ"}}, ErrorInvalidConfig{msg: "missing drift store"}},
+		{
+			"Negative Max Drift",
+			&DriftTOTP{TOTP: TOTP{Key: "73QK7D3A3PIZ6NUQQBF4BNFYQBRVUHUQ"}, Store: &MemoryDriftStore{}, MaxDrift: -1},
+			ErrorInvalidConfig{msg: "max drift must not be negative"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.label, func(t *testing.T) {
+			if _, err := c.d.Validate("123456"); err != c.expectedErr {
+				t.Errorf("unexpected error\nexpected: %v\n  actual: %v", c.expectedErr, err)
+			}
+		})
+	}
+}
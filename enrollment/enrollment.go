@@ -0,0 +1,203 @@
+// Package enrollment implements the registration of new TOTP secrets, which
+// only become active once the user proves their authenticator app works.
+//
+// A Manager begins an enrollment by creating a pending secret along with its
+// key URI and QR code. The secret is activated by confirming the first code,
+// pending enrollments that are not confirmed expire and never replace the
+// credential the user already had.
+package enrollment
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/jltorresm/otpgo"
+	"github.com/jltorresm/otpgo/authenticator"
+	"github.com/jltorresm/otpgo/internal/clock"
+)
+
+const (
+	// DefaultTTL is how long a pending enrollment can be confirmed.
+	DefaultTTL = 10 * time.Minute
+	// DefaultMaxAttempts is the number of wrong codes accepted before a pending
+	// enrollment is discarded.
+	DefaultMaxAttempts = 5
+)
+
+// The Pending type is an enrollment awaiting confirmation.
+type Pending struct {
+	ID        string              `json:"id"`
+	UserID    string              `json:"userId"`
+	Label     authenticator.Label `json:"label"`
+	TOTP      *otpgo.TOTP         `json:"totp"`
+	Attempts  int                 `json:"attempts"`
+	ExpiresAt time.Time           `json:"expiresAt"`
+}
+
+// The Challenge type holds what the user needs to register the pending secret
+// in an authenticator app.
+type Challenge struct {
+	ID        string    `json:"id"`
+	Secret    string    `json:"secret"` // For manual registration
+	Uri       string    `json:"uri"`
+	QRCode    string    `json:"qrCode"` // Base64 encoded PNG, as returned by KeyUri.QRCode
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// The Manager type drives the enrollment workflow on top of a Store.
+type Manager struct {
+	Store       Store
+	Issuer      string           // Issuer shown in the authenticator app
+	Template    otpgo.TOTP       // Parameters for new secrets, the key is ignored
+	TTL         time.Duration    // Defaults to DefaultTTL
+	MaxAttempts int              // Defaults to DefaultMaxAttempts
+	Now         func() time.Time // Defaults to time.Now
+}
+
+// Begin creates a pending enrollment with a new random secret for the user.
+func (m *Manager) Begin(ctx context.Context, userID, accountName string) (*Challenge, error) {
+	id, err := randomID()
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := otpgo.RandomKey()
+	if err != nil {
+		return nil, err
+	}
+
+	totp := m.Template
+	totp.Key = key
+
+	// Generating the first code checks the template and populates the
+	// defaults.
+	if _, err := totp.Generate(); err != nil {
+		return nil, err
+	}
+
+	p := &Pending{
+		ID:        id,
+		UserID:    userID,
+		Label:     authenticator.Label{AccountName: accountName, Issuer: m.Issuer},
+		TOTP:      &totp,
+		ExpiresAt: clock.Now(m.Now).Add(m.ttl()),
+	}
+
+	ku := totp.KeyUri(accountName, m.Issuer)
+
+	uri, err := ku.Build()
+	if err != nil {
+		return nil, err
+	}
+
+	qr, err := ku.QRCode()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := m.Store.SavePending(ctx, p); err != nil {
+		return nil, err
+	}
+
+	return &Challenge{ID: id, Secret: totp.Key, Uri: uri, QRCode: qr, ExpiresAt: p.ExpiresAt}, nil
+}
+
+// Confirm validates the first code generated by the user's authenticator and
+// activates the pending secret. Wrong codes can be retried until MaxAttempts
+// is reached, after which the enrollment is discarded.
+func (m *Manager) Confirm(ctx context.Context, id, userID, code string) (*otpgo.TOTP, error) {
+	p, err := m.Store.GetPending(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Enrollments of other users are reported as missing.
+	if p.UserID != userID {
+		return nil, ErrorNotFound{ID: id}
+	}
+
+	if !clock.Now(m.Now).Before(p.ExpiresAt) {
+		_ = m.Store.DeletePending(ctx, id)
+		return nil, ErrorExpired{ID: id}
+	}
+
+	// Counting the attempt before checking the code bounds the guesses, even
+	// when they are made concurrently.
+	attempts, err := m.Store.AddAttempt(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if attempts > m.maxAttempts() {
+		_ = m.Store.DeletePending(ctx, id)
+		return nil, ErrorNotFound{ID: id}
+	}
+
+	ok, err := p.TOTP.ValidateContext(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+
+	if !ok {
+		if attempts == m.maxAttempts() {
+			if err := m.Store.DeletePending(ctx, id); err != nil {
+				return nil, err
+			}
+		}
+
+		return nil, ErrorInvalidCode{ID: id}
+	}
+
+	if err := m.Store.Activate(ctx, p); err != nil {
+		return nil, err
+	}
+
+	if err := m.Store.DeletePending(ctx, id); err != nil {
+		return nil, err
+	}
+
+	return p.TOTP, nil
+}
+
+// Cancel discards a pending enrollment, the active credential of the user, if
+// any, is left untouched.
+func (m *Manager) Cancel(ctx context.Context, id, userID string) error {
+	p, err := m.Store.GetPending(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if p.UserID != userID {
+		return ErrorNotFound{ID: id}
+	}
+
+	return m.Store.DeletePending(ctx, id)
+}
+
+func (m *Manager) ttl() time.Duration {
+	if m.TTL <= 0 {
+		return DefaultTTL
+	}
+
+	return m.TTL
+}
+
+func (m *Manager) maxAttempts() int {
+	if m.MaxAttempts <= 0 {
+		return DefaultMaxAttempts
+	}
+
+	return m.MaxAttempts
+}
+
+// randomID returns a random identifier for a pending enrollment.
+func randomID() (string, error) {
+	buff := make([]byte, 16)
+	if _, err := rand.Read(buff); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(buff), nil
+}
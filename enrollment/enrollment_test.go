@@ -0,0 +1,213 @@
+package enrollment
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jltorresm/otpgo"
+	"github.com/jltorresm/otpgo/authenticator"
+	"github.com/jltorresm/otpgo/config"
+)
+
+// authenticatorCode returns the code the user's app would show for the
+// challenge.
+func authenticatorCode(t *testing.T, c *Challenge) string {
+	ku, err := authenticator.ParseKeyUri(c.Uri)
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		t.FailNow()
+	}
+
+	totp, err := otpgo.TOTPFromKeyUri(ku)
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		t.FailNow()
+	}
+
+	code, _ := totp.Generate()
+
+	return code
+}
+
+func TestManager_Begin(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	store := &MemoryStore{}
+	m := &Manager{
+		Store:    store,
+		Issuer:   "Acme",
+		Template: otpgo.TOTP{Algorithm: config.HmacSHA256, Length: config.Length8},
+		Now:      func() time.Time { return now },
+	}
+
+	c, err := m.Begin(ctx, "user-1", "john@example.com")
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		t.FailNow()
+	}
+
+	if c.ID == "" || c.Secret == "" {
+		t.Errorf("unexpected challenge: %+v", c)
+	}
+
+	if !strings.HasPrefix(c.Uri, "otpauth://totp/Acme:john@example.com?algorithm=SHA256&digits=8") {
+		t.Errorf("unexpected uri: %s", c.Uri)
+	}
+
+	if !strings.HasPrefix(c.QRCode, "data:image/png;base64,") {
+		t.Errorf("unexpected qr code: %s", c.QRCode)
+	}
+
+	if !c.ExpiresAt.Equal(now.Add(DefaultTTL)) {
+		t.Errorf("unexpected expiry\nexpected: %s\n  actual: %s", now.Add(DefaultTTL), c.ExpiresAt)
+	}
+
+	if _, ok := store.Active("user-1"); ok {
+		t.Error("expected no active credential before confirmation")
+	}
+
+	if m.Template.Key != "" {
+		t.Error("expected the template to remain untouched")
+	}
+
+	if _, err := m.Begin(ctx, "user-1", "john:doe"); err == nil {
+		t.Error("expected invalid label error")
+	}
+}
+
+func TestManager_Confirm(t *testing.T) {
+	ctx := context.Background()
+	store := &MemoryStore{}
+	m := &Manager{Store: store, Issuer: "Acme"}
+
+	c, _ := m.Begin(ctx, "user-1", "john@example.com")
+	code := authenticatorCode(t, c)
+
+	if _, err := m.Confirm(ctx, c.ID, "user-2", code); err != (ErrorNotFound{ID: c.ID}) {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if _, err := m.Confirm(ctx, c.ID, "user-1", "00000000x"); err != (ErrorInvalidCode{ID: c.ID}) {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	totp, err := m.Confirm(ctx, c.ID, "user-1", code)
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		t.FailNow()
+	}
+
+	if totp.Key != c.Secret {
+		t.Errorf("unexpected key\nexpected: %s\n  actual: %s", c.Secret, totp.Key)
+	}
+
+	active, ok := store.Active("user-1")
+	if !ok || active.Key != c.Secret {
+		t.Errorf("unexpected active credential: %+v", active)
+	}
+
+	if _, err := m.Confirm(ctx, c.ID, "user-1", code); err != (ErrorNotFound{ID: c.ID}) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestManager_ConfirmKeepsActiveCredential(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	store := &MemoryStore{}
+	m := &Manager{Store: store, Issuer: "Acme", Now: func() time.Time { return now }}
+
+	first, _ := m.Begin(ctx, "user-1", "john@example.com")
+	if _, err := m.Confirm(ctx, first.ID, "user-1", authenticatorCode(t, first)); err != nil {
+		t.Errorf("unexpected error: %s", err)
+		t.FailNow()
+	}
+
+	// Expired enrollment.
+	second, _ := m.Begin(ctx, "user-1", "john@example.com")
+	now = now.Add(DefaultTTL)
+	if _, err := m.Confirm(ctx, second.ID, "user-1", authenticatorCode(t, second)); err != (ErrorExpired{ID: second.ID}) {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	// Too many wrong codes.
+	m.MaxAttempts = 2
+	third, _ := m.Begin(ctx, "user-1", "john@example.com")
+	for i := 0; i < 2; i++ {
+		if _, err := m.Confirm(ctx, third.ID, "user-1", "bad"); err != (ErrorInvalidCode{ID: third.ID}) {
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+	if _, err := m.Confirm(ctx, third.ID, "user-1", authenticatorCode(t, third)); err != (ErrorNotFound{ID: third.ID}) {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	// Cancelled enrollment.
+	fourth, _ := m.Begin(ctx, "user-1", "john@example.com")
+	if err := m.Cancel(ctx, fourth.ID, "user-2"); err != (ErrorNotFound{ID: fourth.ID}) {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := m.Cancel(ctx, fourth.ID, "user-1"); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+	if _, err := m.Confirm(ctx, fourth.ID, "user-1", authenticatorCode(t, fourth)); err != (ErrorNotFound{ID: fourth.ID}) {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	active, _ := store.Active("user-1")
+	if active.Key != first.Secret {
+		t.Errorf("expected the first credential to remain active\nexpected: %s\n  actual: %s", first.Secret, active.Key)
+	}
+}
+
+func TestManager_BeginConstructedTemplate(t *testing.T) {
+	template, err := otpgo.NewTOTP(otpgo.WithRandomKey(), otpgo.WithDigits(8))
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		t.FailNow()
+	}
+
+	m := &Manager{Store: &MemoryStore{}, Issuer: "Acme", Template: *template}
+
+	c, err := m.Begin(context.Background(), "user-1", "john@example.com")
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		t.FailNow()
+	}
+
+	if c.Secret == "" || c.Secret == template.Key || !strings.Contains(c.Uri, "secret="+c.Secret) {
+		t.Errorf("unexpected challenge: %+v", c)
+	}
+}
+
+func TestManager_ConfirmConcurrentAttempts(t *testing.T) {
+	ctx := context.Background()
+	m := &Manager{Store: &MemoryStore{}, Issuer: "Acme", MaxAttempts: 3}
+
+	c, _ := m.Begin(ctx, "user-1", "john@example.com")
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		invalid int
+	)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := m.Confirm(ctx, c.ID, "user-1", "000000"); err == (ErrorInvalidCode{ID: c.ID}) {
+				mu.Lock()
+				invalid++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if invalid != m.MaxAttempts {
+		t.Errorf("unexpected checked guesses\nexpected: %d\n  actual: %d", m.MaxAttempts, invalid)
+	}
+}
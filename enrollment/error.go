@@ -0,0 +1,34 @@
+package enrollment
+
+import (
+	"fmt"
+)
+
+// The ErrorNotFound represents a pending enrollment that doesn't exist, or
+// that belongs to another user.
+type ErrorNotFound struct {
+	ID string
+}
+
+func (enf ErrorNotFound) Error() string {
+	return fmt.Sprintf("enrollment not found: %s", enf.ID)
+}
+
+// The ErrorExpired represents a pending enrollment that can no longer be
+// confirmed.
+type ErrorExpired struct {
+	ID string
+}
+
+func (ee ErrorExpired) Error() string {
+	return fmt.Sprintf("enrollment expired: %s", ee.ID)
+}
+
+// The ErrorInvalidCode represents a wrong confirmation code.
+type ErrorInvalidCode struct {
+	ID string
+}
+
+func (eic ErrorInvalidCode) Error() string {
+	return fmt.Sprintf("invalid confirmation code for enrollment: %s", eic.ID)
+}
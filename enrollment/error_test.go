@@ -0,0 +1,23 @@
+package enrollment
+
+import (
+	"testing"
+)
+
+func TestErrors(t *testing.T) {
+	cases := []struct {
+		label    string
+		err      error
+		expected string
+	}{
+		{"Not Found", ErrorNotFound{ID: "abc"}, "enrollment not found: abc"},
+		{"Expired", ErrorExpired{ID: "abc"}, "enrollment expired: abc"},
+		{"Invalid Code", ErrorInvalidCode{ID: "abc"}, "invalid confirmation code for enrollment: abc"},
+	}
+
+	for _, c := range cases {
+		if c.expected != c.err.Error() {
+			t.Errorf("case %s: unexpected error\nexpected: %s\n  actual: %s", c.label, c.expected, c.err.Error())
+		}
+	}
+}
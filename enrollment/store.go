@@ -0,0 +1,138 @@
+package enrollment
+
+import (
+	"context"
+	"sync"
+
+	"github.com/jltorresm/otpgo"
+)
+
+// The Store interface persists pending enrollments and the credentials they
+// become once confirmed. Every method should give up when the context is done.
+type Store interface {
+	// SavePending creates or replaces a pending enrollment.
+	SavePending(ctx context.Context, p *Pending) error
+	// GetPending returns the pending enrollment or ErrorNotFound.
+	GetPending(ctx context.Context, id string) (*Pending, error)
+	// AddAttempt atomically increments the attempts of the pending enrollment
+	// and returns the new count, or ErrorNotFound.
+	AddAttempt(ctx context.Context, id string) (attempts int, err error)
+	// DeletePending removes the pending enrollment, if it exists.
+	DeletePending(ctx context.Context, id string) error
+	// Activate stores the confirmed secret as the user's credential.
+	Activate(ctx context.Context, p *Pending) error
+}
+
+// The MemoryStore type is a Store that keeps everything in memory, suitable
+// for tests and single instance deployments. It is safe for concurrent use.
+type MemoryStore struct {
+	mu      sync.Mutex
+	pending map[string]Pending
+	active  map[string]otpgo.TOTP
+}
+
+// SavePending stores a copy of the pending enrollment.
+func (ms *MemoryStore) SavePending(ctx context.Context, p *Pending) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if ms.pending == nil {
+		ms.pending = map[string]Pending{}
+	}
+
+	c := *p
+	totp := *p.TOTP
+	c.TOTP = &totp
+	ms.pending[p.ID] = c
+
+	return nil
+}
+
+// GetPending returns a copy of the pending enrollment.
+func (ms *MemoryStore) GetPending(ctx context.Context, id string) (*Pending, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	p, ok := ms.pending[id]
+	if !ok {
+		return nil, ErrorNotFound{ID: id}
+	}
+
+	totp := *p.TOTP
+	p.TOTP = &totp
+
+	return &p, nil
+}
+
+// AddAttempt increments the attempts of the pending enrollment.
+func (ms *MemoryStore) AddAttempt(ctx context.Context, id string) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	p, ok := ms.pending[id]
+	if !ok {
+		return 0, ErrorNotFound{ID: id}
+	}
+
+	p.Attempts++
+	ms.pending[id] = p
+
+	return p.Attempts, nil
+}
+
+// DeletePending removes the pending enrollment.
+func (ms *MemoryStore) DeletePending(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	delete(ms.pending, id)
+
+	return nil
+}
+
+// Activate replaces the active credential of the user.
+func (ms *MemoryStore) Activate(ctx context.Context, p *Pending) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if ms.active == nil {
+		ms.active = map[string]otpgo.TOTP{}
+	}
+
+	ms.active[p.UserID] = *p.TOTP
+
+	return nil
+}
+
+// Active returns a copy of the active credential of the user, if any.
+func (ms *MemoryStore) Active(userID string) (*otpgo.TOTP, bool) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	totp, ok := ms.active[userID]
+	if !ok {
+		return nil, false
+	}
+
+	return &totp, true
+}
@@ -0,0 +1,89 @@
+package enrollment
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jltorresm/otpgo"
+)
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	ms := &MemoryStore{}
+
+	if _, err := ms.GetPending(ctx, "missing"); err != (ErrorNotFound{ID: "missing"}) {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	p := &Pending{ID: "abc", UserID: "user-1", TOTP: &otpgo.TOTP{Key: "KEY"}}
+	if err := ms.SavePending(ctx, p); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	// The store keeps its own copy.
+	p.TOTP.Key = "CHANGED"
+
+	stored, err := ms.GetPending(ctx, "abc")
+	if err != nil || stored.TOTP.Key != "KEY" {
+		t.Errorf("unexpected pending enrollment: %+v, %v", stored, err)
+	}
+
+	for expected := 1; expected <= 2; expected++ {
+		if attempts, err := ms.AddAttempt(ctx, "abc"); attempts != expected || err != nil {
+			t.Errorf("unexpected attempts: %d, %v", attempts, err)
+		}
+	}
+
+	if _, err := ms.AddAttempt(ctx, "missing"); err != (ErrorNotFound{ID: "missing"}) {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if err := ms.Activate(ctx, stored); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	active, ok := ms.Active("user-1")
+	if !ok || active.Key != "KEY" {
+		t.Errorf("unexpected active credential: %+v", active)
+	}
+
+	if err := ms.DeletePending(ctx, "abc"); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	if _, err := ms.GetPending(ctx, "abc"); err != (ErrorNotFound{ID: "abc"}) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestMemoryStore_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	ms := &MemoryStore{}
+	p := &Pending{ID: "abc", UserID: "user-1", TOTP: &otpgo.TOTP{Key: "KEY"}}
+
+	if err := ms.SavePending(ctx, p); err != context.Canceled {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if _, err := ms.GetPending(ctx, "abc"); err != context.Canceled {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if _, err := ms.AddAttempt(ctx, "abc"); err != context.Canceled {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if err := ms.DeletePending(ctx, "abc"); err != context.Canceled {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if err := ms.Activate(ctx, p); err != context.Canceled {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if _, ok := ms.Active("user-1"); ok {
+		t.Error("unexpected active credential after cancel")
+	}
+}
@@ -0,0 +1,169 @@
+package otpgo
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/jltorresm/otpgo/authenticator"
+)
+
+// Types of the OTP kinds implemented in the package, as used in key URIs and
+// in the Envelope JSON encoding.
+const (
+	TypeHOTP = "hotp"
+	TypeTOTP = "totp"
+	TypeMOTP = "motp"
+)
+
+// The OTP interface is the behaviour shared by every OTP kind, so that callers
+// can generate, validate and export codes without knowing the concrete type.
+type OTP interface {
+	Generate() (string, error)
+	Validate(token string) (bool, error)
+	KeyUri(accountName, issuer string) *authenticator.KeyUri
+	Type() string
+}
+
+// The EventBased interface is implemented by OTPs whose moving factor is a
+// counter, which must be advanced once a generated code is handed out.
+type EventBased interface {
+	OTP
+	Advance()
+}
+
+var (
+	_ EventBased = (*HOTP)(nil)
+	_ OTP        = (*TOTP)(nil)
+	_ OTP        = (*MOTP)(nil)
+)
+
+var (
+	typesMu sync.RWMutex
+	types   = map[string]func() OTP{
+		TypeHOTP: func() OTP { return &HOTP{} },
+		TypeTOTP: func() OTP { return &TOTP{} },
+		TypeMOTP: func() OTP { return &MOTP{} },
+	}
+)
+
+// RegisterType makes an OTP kind available to Envelope decoding. The factory
+// must return a pointer to an empty value of the type.
+func RegisterType(name string, factory func() OTP) {
+	typesMu.Lock()
+	defer typesMu.Unlock()
+
+	types[name] = factory
+}
+
+// newOTP returns an empty OTP of the given type.
+func newOTP(name string) (OTP, error) {
+	typesMu.RLock()
+	defer typesMu.RUnlock()
+
+	factory, ok := types[name]
+	if !ok {
+		return nil, ErrorInvalidConfig{msg: fmt.Sprintf("unknown otp type %q", name)}
+	}
+
+	return factory(), nil
+}
+
+// Clone returns a copy of the OTP, so that it can be modified, e.g.: by
+// validating an HOTP, without affecting the original.
+func Clone(o OTP) OTP {
+	v := reflect.ValueOf(o)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return o
+	}
+
+	c := reflect.New(v.Elem().Type())
+	c.Elem().Set(v.Elem())
+
+	return c.Interface().(OTP)
+}
+
+// The defaulter interface is implemented by the OTP kinds whose zero fields
+// stand for default values, e.g.: the SHA1 algorithm.
+type defaulter interface {
+	ensureDefaults()
+}
+
+// Normalize returns a copy of the OTP with the defaults applied to its zero
+// fields, so that it can be encoded and compared as it is used. The key is
+// left untouched, even when empty.
+func Normalize(o OTP) OTP {
+	c := Clone(o)
+	if d, ok := c.(defaulter); ok {
+		d.ensureDefaults()
+	}
+
+	return c
+}
+
+// OTPFromKeyUri builds the OTP described by a key URI, whatever its type.
+func OTPFromKeyUri(ku *authenticator.KeyUri) (OTP, error) {
+	switch ku.Type {
+	case TypeHOTP:
+		return HOTPFromKeyUri(ku)
+	case TypeTOTP:
+		return TOTPFromKeyUri(ku)
+	}
+
+	return nil, ErrorInvalidConfig{msg: fmt.Sprintf("unsupported key uri type %q", ku.Type)}
+}
+
+// The Envelope type wraps any OTP to encode it as JSON along with its type,
+// e.g.: {"type":"totp","config":{"key":"...","period":30,...}}. This allows
+// storing and loading OTPs without knowing their concrete type.
+type Envelope struct {
+	OTP OTP
+}
+
+type rawEnvelope struct {
+	Type   string          `json:"type"`
+	Config json.RawMessage `json:"config"`
+}
+
+// MarshalJSON returns the JSON representation of the wrapped OTP and its type.
+func (e Envelope) MarshalJSON() ([]byte, error) {
+	if e.OTP == nil {
+		return []byte("null"), nil
+	}
+
+	// The zero fields are encoded with the values they stand for, e.g.: an
+	// algorithm of 0 has no JSON representation.
+	config, err := json.Marshal(Normalize(e.OTP))
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(rawEnvelope{Type: e.OTP.Type(), Config: config})
+}
+
+// UnmarshalJSON decodes an OTP of any registered type.
+func (e *Envelope) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		e.OTP = nil
+		return nil
+	}
+
+	raw := rawEnvelope{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	o, err := newOTP(raw.Type)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(raw.Config, o); err != nil {
+		return err
+	}
+
+	e.OTP = o
+
+	return nil
+}
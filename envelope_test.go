@@ -0,0 +1,207 @@
+package otpgo
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/jltorresm/otpgo/authenticator"
+	"github.com/jltorresm/otpgo/config"
+)
+
+// mockOTP is a trivial OTP kind used to check that custom types can be
+// registered.
+type mockOTP struct {
+	Code string `json:"code"`
+}
+
+func (m *mockOTP) Generate() (string, error)                { return m.Code, nil }
+func (m *mockOTP) Validate(token string) (bool, error)      { return token == m.Code, nil }
+func (m *mockOTP) KeyUri(_, _ string) *authenticator.KeyUri { return nil }
+func (m *mockOTP) Type() string                             { return "mock" }
+
+func TestOTP_Type(t *testing.T) {
+	cases := []struct {
+		label        string
+		otp          OTP
+		expectedType string
+	}{
+		{"HOTP", &HOTP{}, TypeHOTP},
+		{"TOTP", &TOTP{}, TypeTOTP},
+		{"MOTP", &MOTP{}, TypeMOTP},
+	}
+
+	for _, c := range cases {
+		t.Run(c.label, func(t *testing.T) {
+			if c.expectedType != c.otp.Type() {
+				t.Errorf("unexpected type\nexpected: %s\n  actual: %s", c.expectedType, c.otp.Type())
+			}
+
+			if c.expectedType != c.otp.KeyUri("john", "Acme").Type {
+				t.Errorf("unexpected key uri type\nexpected: %s\n  actual: %s", c.expectedType, c.otp.KeyUri("john", "Acme").Type)
+			}
+		})
+	}
+}
+
+func TestHOTP_Advance(t *testing.T) {
+	h := &HOTP{Counter: 41}
+	h.Advance()
+
+	if h.Counter != 42 {
+		t.Errorf("unexpected counter\nexpected: %d\n  actual: %d", 42, h.Counter)
+	}
+}
+
+func TestEnvelope_JSON(t *testing.T) {
+	cases := []struct {
+		label        string
+		otp          OTP
+		expectedJson string
+	}{
+		{
+			"HOTP",
+			&HOTP{Key: "73QK7D3A3PIZ6NUQQBF4BNFYQBRVUHUQ", Counter: 9338, Leeway: 1, Algorithm: config.HmacSHA256, Length: config.Length7},
+			`{"type":"hotp","config":{"key":"73QK7D3A3PIZ6NUQQBF4BNFYQBRVUHUQ","counter":9338,"leeway":1,"algorithm":"SHA256","length":7}}`,
+		},
+		{
+			"TOTP",
+			&TOTP{Key: "73QK7D3A3PIZ6NUQQBF4BNFYQBRVUHUQ", Period: 30, Delay: 1, Algorithm: config.HmacSHA512, Length: config.Length6},
+			`{"type":"totp","config":{"key":"73QK7D3A3PIZ6NUQQBF4BNFYQBRVUHUQ","period":30,"delay":1,"algorithm":"SHA512","length":6}}`,
+		},
+		{
+			"MOTP",
+			&MOTP{Key: "1234567890abcdef", PIN: "1234", Skew: 3},
+			`{"type":"motp","config":{"key":"1234567890abcdef","pin":"1234","skew":3}}`,
+		},
+		{
+			"Defaults",
+			&TOTP{Key: "73QK7D3A3PIZ6NUQQBF4BNFYQBRVUHUQ"},
+			`{"type":"totp","config":{"key":"73QK7D3A3PIZ6NUQQBF4BNFYQBRVUHUQ","period":30,"delay":1,"algorithm":"SHA1","length":6}}`,
+		},
+		{"Empty", nil, `null`},
+	}
+
+	for _, c := range cases {
+		t.Run(c.label, func(t *testing.T) {
+			j, err := json.Marshal(Envelope{OTP: c.otp})
+			if err != nil {
+				t.Errorf("unexpected error: %s", err)
+				t.FailNow()
+			}
+
+			if c.expectedJson != string(j) {
+				t.Errorf("unexpected json:\nexpected: %s\n  actual: %s", c.expectedJson, j)
+			}
+
+			decoded := Envelope{}
+			if err := json.Unmarshal(j, &decoded); err != nil {
+				t.Errorf("unexpected error: %s", err)
+				t.FailNow()
+			}
+
+			reencoded, _ := json.Marshal(decoded)
+			if c.expectedJson != string(reencoded) {
+				t.Errorf("unexpected round trip json:\nexpected: %s\n  actual: %s", c.expectedJson, reencoded)
+			}
+		})
+	}
+}
+
+func TestEnvelope_UnmarshalJSONErrors(t *testing.T) {
+	cases := []struct {
+		label       string
+		json        string
+		expectedErr string
+	}{
+		{"Unknown Type", `{"type":"nope","config":{}}`, `invalid config: unknown otp type "nope"`},
+		{"Bad Config", `{"type":"totp","config":{"algorithm":"MD5"}}`, `unknown hash algorithm "MD5"`},
+		{"Not An Object", `[]`, "json: cannot unmarshal array into Go value of type otpgo.rawEnvelope"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.label, func(t *testing.T) {
+			err := json.Unmarshal([]byte(c.json), &Envelope{})
+
+			if err == nil || c.expectedErr != err.Error() {
+				t.Errorf("unexpected error\nexpected: %s\n  actual: %v", c.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestRegisterType(t *testing.T) {
+	RegisterType("mock", func() OTP { return &mockOTP{} })
+
+	e := Envelope{}
+	if err := json.Unmarshal([]byte(`{"type":"mock","config":{"code":"42"}}`), &e); err != nil {
+		t.Errorf("unexpected error: %s", err)
+		t.FailNow()
+	}
+
+	if ok, _ := e.OTP.Validate("42"); !ok {
+		t.Errorf("unexpected otp: %+v", e.OTP)
+	}
+}
+
+func TestClone(t *testing.T) {
+	original := &HOTP{Key: "73QK7D3A3PIZ6NUQQBF4BNFYQBRVUHUQ", Counter: 1}
+
+	c := Clone(original).(*HOTP)
+	c.Advance()
+
+	if original.Counter != 1 || c.Counter != 2 || c.Key != original.Key {
+		t.Errorf("unexpected clone\noriginal: %+v\n   clone: %+v", original, c)
+	}
+
+	if Clone(nil) != nil {
+		t.Error("expected nil clone")
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	cases := []struct {
+		label    string
+		otp      OTP
+		expected OTP
+	}{
+		{"HOTP", &HOTP{Key: "KEY", Counter: 3}, &HOTP{Key: "KEY", Counter: 3, Leeway: 1, Algorithm: config.HmacSHA1, Length: config.Length6}},
+		{"TOTP", &TOTP{Algorithm: config.HmacSHA256}, &TOTP{Period: 30, Delay: 1, Algorithm: config.HmacSHA256, Length: config.Length6}},
+		{"MOTP", &MOTP{Key: "1234567890abcdef"}, &MOTP{Key: "1234567890abcdef"}},
+		{"Empty", nil, nil},
+	}
+
+	for _, c := range cases {
+		original := Clone(c.otp)
+
+		if actual := Normalize(c.otp); !reflect.DeepEqual(c.expected, actual) {
+			t.Errorf("case %s: unexpected otp\nexpected: %+v\n  actual: %+v", c.label, c.expected, actual)
+		}
+
+		if !reflect.DeepEqual(original, c.otp) {
+			t.Errorf("case %s: expected the original otp to remain untouched", c.label)
+		}
+	}
+}
+
+func TestOTPFromKeyUri(t *testing.T) {
+	for _, otpType := range []string{TypeHOTP, TypeTOTP} {
+		ku, _ := authenticator.ParseKeyUri("otpauth://" + otpType + "/john?secret=JBSWY3DPEHPK3PXP")
+
+		o, err := OTPFromKeyUri(ku)
+		if err != nil {
+			t.Errorf("unexpected error: %s", err)
+			t.FailNow()
+		}
+
+		if otpType != o.Type() {
+			t.Errorf("unexpected type\nexpected: %s\n  actual: %s", otpType, o.Type())
+		}
+	}
+
+	ku, _ := authenticator.ParseKeyUri("otpauth://motp/john?secret=JBSWY3DPEHPK3PXP")
+	expectedErr := ErrorInvalidConfig{msg: `unsupported key uri type "motp"`}
+	if _, err := OTPFromKeyUri(ku); err != expectedErr {
+		t.Errorf("unexpected error\nexpected: %v\n  actual: %v", expectedErr, err)
+	}
+}
@@ -12,3 +12,25 @@ type ErrorInvalidKey struct {
 func (eik ErrorInvalidKey) Error() string {
 	return fmt.Sprintf("invalid key: %s", eik.msg)
 }
+
+// NewErrorInvalidKey returns an ErrorInvalidKey with the given message, for the
+// OTP types implemented outside of this package.
+func NewErrorInvalidKey(msg string) ErrorInvalidKey {
+	return ErrorInvalidKey{msg: msg}
+}
+
+// The ErrorInvalidConfig represents OTP parameters that can not be used to
+// generate or validate OTPs.
+type ErrorInvalidConfig struct {
+	msg string
+}
+
+func (eic ErrorInvalidConfig) Error() string {
+	return fmt.Sprintf("invalid config: %s", eic.msg)
+}
+
+// NewErrorInvalidConfig returns an ErrorInvalidConfig with the given message,
+// for the OTP types implemented outside of this package.
+func NewErrorInvalidConfig(msg string) ErrorInvalidConfig {
+	return ErrorInvalidConfig{msg: msg}
+}
@@ -12,3 +12,22 @@ func TestErrorInvalidKey_Error(t *testing.T) {
 		t.Errorf("unexpected error\nexpected: %s\n  actual: %s", expectedError, err.Error())
 	}
 }
+
+func TestErrorInvalidConfig_Error(t *testing.T) {
+	err := ErrorInvalidConfig{msg: "an arbitrary error message"}
+	expectedError := "invalid config: an arbitrary error message"
+
+	if err.Error() != expectedError {
+		t.Errorf("unexpected error\nexpected: %s\n  actual: %s", expectedError, err.Error())
+	}
+}
+
+func TestNewError(t *testing.T) {
+	if err := NewErrorInvalidKey("missing secret"); err != (ErrorInvalidKey{msg: "missing secret"}) {
+		t.Errorf("unexpected error: %#v", err)
+	}
+
+	if err := NewErrorInvalidConfig("missing pin"); err != (ErrorInvalidConfig{msg: "missing pin"}) {
+		t.Errorf("unexpected error: %#v", err)
+	}
+}
@@ -2,4 +2,7 @@ module github.com/jltorresm/otpgo
 
 go 1.14
 
-require github.com/skip2/go-qrcode v0.0.0-20200617195104-da1b6568686e
+require (
+	github.com/skip2/go-qrcode v0.0.0-20200617195104-da1b6568686e
+	golang.org/x/crypto v0.0.0-20201016220609-9e8e0b390897
+)
@@ -1,6 +1,7 @@
 package otpgo
 
 import (
+	"context"
 	"errors"
 	"net/url"
 	"strconv"
@@ -17,12 +18,26 @@ const (
 )
 
 // The HOTP type used to generate HMAC-Based One-Time Passwords.
+//
+// Tokens are accepted within Leeway counters of the current one, in both
+// directions. Setting LookBehind or LookAhead replaces Leeway with an
+// asymmetric window, e.g.: LookAhead 3 alone accepts the next 3 counters and no
+// previous one.
+//
+// An HOTP is not safe for concurrent use, generating and validating codes
+// modifies it. Use SyncHOTP to share counters between goroutines.
 type HOTP struct {
-	Key       string               `json:"key"`       // Secret base32 encoded string
-	Counter   uint64               `json:"counter"`   // Current value to calculate around
-	Leeway    uint64               `json:"leeway"`    // Acceptable steps for sync error
-	Algorithm config.HmacAlgorithm `json:"algorithm"` // Hash algorithm to use in the calculation
-	Length    config.Length        `json:"length"`    // Length of the resulting code
+	Key        string               `json:"key"`                  // Secret base32 encoded string
+	Counter    uint64               `json:"counter"`              // Current value to calculate around
+	Leeway     uint64               `json:"leeway"`               // Acceptable steps for sync error
+	LookBehind uint64               `json:"lookBehind,omitempty"` // Acceptable steps before the counter, overrides Leeway
+	LookAhead  uint64               `json:"lookAhead,omitempty"`  // Acceptable steps after the counter, overrides Leeway
+	Algorithm  config.HmacAlgorithm `json:"algorithm"`            // Hash algorithm to use in the calculation
+	Length     config.Length        `json:"length"`               // Length of the resulting code
+	ID         string               `json:"-"`                    // Identifies the credential in validation events
+	Observer   Observer             `json:"-"`                    // Optional, notified of every validation
+
+	frozen bool // Set by NewHOTP, the config is complete and must not be modified
 }
 
 // Generate a HMAC-Based One-Time Password.
@@ -42,45 +57,103 @@ func (h *HOTP) Generate() (string, error) {
 // current HOTP config. If the validation is successful the internal Counter
 // will be incremented by one.
 func (h *HOTP) Validate(token string) (bool, error) {
+	valid, reason, offset, err := h.validate(token)
+	notify(h.Observer, outcome(h.ID, TypeHOTP, valid, reason, offset))
+
+	return valid, err
+}
+
+// validate does the work of Validate without notifying the Observer, returning
+// the reason of a failure or the offset of the matching counter.
+func (h *HOTP) validate(token string) (valid bool, reason string, offset int64, err error) {
 	// Validating without a proper key shouldn't happen
 	if h.Key == "" {
-		return false, errors.New("missing secret key for validation")
+		return false, ReasonError, 0, errors.New("missing secret key for validation")
 	}
 
 	// Make sure we have sensible values to generate secure OTPs
 	h.ensureDefaults()
 
-	// A token is considered valid if it matches the current counter or any
-	// within the leeway.
-	isValid := false
-	for step := uint64(0); step <= h.Leeway; step++ {
-		under := h.Counter - step
+	if !wellFormed(token, int(h.Length)) {
+		return false, ReasonInvalidFormat, 0, nil
+	}
+
+	offset, isValid, err := h.match(token)
+	if err != nil {
+		return false, ReasonError, 0, err
+	}
 
-		expected, err := generateOTP(h.Key, under, h.Length, h.Algorithm)
-		if err != nil {
-			return false, err
-		}
-		if expected == token {
-			isValid = true
-			break
-		}
+	if !isValid {
+		return false, ReasonInvalidToken, 0, nil
+	}
+
+	h.Counter++
+
+	return true, "", offset, nil
+}
+
+// GenerateContext is like Generate, but fails with the context error if it is
+// already done.
+func (h *HOTP) GenerateContext(ctx context.Context) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	return h.Generate()
+}
+
+// ValidateContext is like Validate, but fails with the context error if it is
+// already done.
+func (h *HOTP) ValidateContext(ctx context.Context, token string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		notify(h.Observer, failure(h.ID, TypeHOTP, ReasonError))
+		return false, err
+	}
+
+	return h.Validate(token)
+}
 
-		over := h.Counter + step
-		expected, err = generateOTP(h.Key, over, h.Length, h.Algorithm)
-		if err != nil {
-			return false, err
+// match checks the token against the current counter and any within the
+// look-behind and look-ahead window. It returns the offset of the matching
+// counter from the current one.
+func (h *HOTP) match(token string) (int64, bool, error) {
+	behind, ahead := h.window()
+	for step := uint64(0); step <= behind || step <= ahead; step++ {
+		if step <= behind && step <= h.Counter {
+			under := h.Counter - step
+
+			expected, err := generateOTP(h.Key, under, h.Length, h.Algorithm)
+			if err != nil {
+				return 0, false, err
+			}
+			if tokensEqual(expected, token) {
+				return -int64(step), true, nil
+			}
 		}
-		if expected == token {
-			isValid = true
-			break
+
+		if step <= ahead {
+			over := h.Counter + step
+			expected, err := generateOTP(h.Key, over, h.Length, h.Algorithm)
+			if err != nil {
+				return 0, false, err
+			}
+			if tokensEqual(expected, token) {
+				return int64(step), true, nil
+			}
 		}
 	}
 
-	if isValid {
-		h.Counter++
+	return 0, false, nil
+}
+
+// window returns the number of counters accepted before and after the current
+// one, LookBehind and LookAhead, or Leeway for both if they are not set.
+func (h *HOTP) window() (behind, ahead uint64) {
+	if h.LookBehind == 0 && h.LookAhead == 0 {
+		return h.Leeway, h.Leeway
 	}
 
-	return isValid, nil
+	return h.LookBehind, h.LookAhead
 }
 
 // KeyUri return an authenticator.KeyUri configured with the current HOTP params.
@@ -88,7 +161,7 @@ func (h *HOTP) Validate(token string) (bool, error) {
 //     - issuer is the site or org
 func (h *HOTP) KeyUri(accountName, issuer string) *authenticator.KeyUri {
 	return &authenticator.KeyUri{
-		Type: "hotp",
+		Type: TypeHOTP,
 		Label: authenticator.Label{
 			AccountName: accountName,
 			Issuer:      issuer,
@@ -97,6 +170,17 @@ func (h *HOTP) KeyUri(accountName, issuer string) *authenticator.KeyUri {
 	}
 }
 
+// Type returns the OTP type, TypeHOTP.
+func (h *HOTP) Type() string {
+	return TypeHOTP
+}
+
+// Advance moves the counter forward, to be called once a generated code has
+// been handed out.
+func (h *HOTP) Advance() {
+	h.Counter++
+}
+
 // AsUrlValues returns the HOTP parameters represented as url.Values.
 func (h *HOTP) AsUrlValues(issuer string) url.Values {
 	params := url.Values{}
@@ -116,6 +200,10 @@ func (h *HOTP) AsUrlValues(issuer string) url.Values {
 //     - Algorithm = SHA1
 //     - Length = 6
 func (h *HOTP) ensureDefaults() {
+	if h.frozen {
+		return
+	}
+
 	if h.Leeway == 0 {
 		h.Leeway = HOTPDefaultLeeway
 	}
@@ -130,11 +218,17 @@ func (h *HOTP) ensureDefaults() {
 }
 
 // ensureKey generates a proper random key if no value is provided by the caller.
+// The key of a config built by NewHOTP is never replaced, an empty one is an
+// error.
 func (h *HOTP) ensureKey() (err error) {
 	if h.Key != "" {
 		return nil
 	}
 
+	if h.frozen {
+		return ErrorInvalidConfig{msg: "missing secret key"}
+	}
+
 	h.Key, err = randomKey(RandomKeyLength)
 
 	return err
@@ -2,6 +2,7 @@ package otpgo
 
 import (
 	"encoding/json"
+	"math"
 	"testing"
 
 	"github.com/jltorresm/otpgo/config"
@@ -123,6 +124,7 @@ func TestHOTP_Validate(t *testing.T) {
 	t.Run("Success", testHOTPValidateSuccess)
 	t.Run("Failure", testHOTPValidateFailure)
 	t.Run("Look Ahead Validation", testHOTPValidateLeeway)
+	t.Run("Asymmetric Window", testHOTPValidateAsymmetric)
 	t.Run("Missing Key", testHOTPValidateMissingKey)
 }
 
@@ -232,6 +234,57 @@ func testHOTPValidateLeeway(t *testing.T) {
 	}
 }
 
+func testHOTPValidateAsymmetric(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		label         string
+		modifier      int
+		shouldBeValid bool
+	}{
+		{"Correct Step", 0, true},
+		{"One Step Behind", -1, false},
+		{"One Step Ahead", 1, true},
+		{"Three Step Ahead", 3, true},
+		{"Four Step Ahead", 4, false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.label, func(t *testing.T) {
+			h := &HOTP{
+				Key:       "73QK7D3A3PIZ6NUQQBF4BNFYQBRVUHUT",
+				Counter:   362,
+				Leeway:    2,
+				LookAhead: 3,
+				Algorithm: config.HmacSHA512,
+				Length:    config.Length7,
+			}
+
+			expectedOTP, err := generateOTP(h.Key, uint64(362+c.modifier), h.Length, h.Algorithm)
+			if err != nil {
+				t.Errorf("unexpected error: %s", err)
+				t.FailNow()
+			}
+
+			isValid, err := h.Validate(expectedOTP)
+			if err != nil {
+				t.Errorf("unexpected error: %s", err)
+			}
+
+			if isValid != c.shouldBeValid {
+				t.Errorf("unexpected result from Validate()\nexpected %s to be %v", c.label, c.shouldBeValid)
+			}
+		})
+	}
+
+	// Looking behind never wraps around the first counter.
+	h := &HOTP{Key: "73QK7D3A3PIZ6NUQQBF4BNFYQBRVUHUT", LookBehind: 1}
+	expectedOTP, _ := generateOTP(h.Key, math.MaxUint64, config.Length6, config.HmacSHA1)
+	if isValid, _ := h.Validate(expectedOTP); isValid {
+		t.Error("unexpected result from Validate()\nexpected the last counter to be invalid")
+	}
+}
+
 func testHOTPValidateMissingKey(t *testing.T) {
 	h := &HOTP{}
 
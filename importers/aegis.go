@@ -0,0 +1,355 @@
+package importers
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+
+	"github.com/jltorresm/otpgo"
+	"github.com/jltorresm/otpgo/authenticator"
+	"github.com/jltorresm/otpgo/internal/kdf"
+	"golang.org/x/crypto/scrypt"
+)
+
+// AegisDefaultN is the scrypt cost used to protect new encrypted vaults, the
+// same as the Aegis app.
+const AegisDefaultN = 1 << 15
+
+// aegisPasswordSlot is the type of the slots holding the master key encrypted
+// with a password.
+const aegisPasswordSlot = 1
+
+// The Aegis type reads and writes Aegis vault exports. Vaults are encrypted
+// when Password is set: the entries are sealed with AES-256-GCM using a random
+// master key, itself sealed with a key derived from the password with scrypt.
+type Aegis struct {
+	Password string // Password of encrypted vaults, leave empty for plain ones
+	N        int    // scrypt cost of exported vaults, defaults to AegisDefaultN
+}
+
+type aegisFile struct {
+	Version int             `json:"version"`
+	Header  aegisHeader     `json:"header"`
+	DB      json.RawMessage `json:"db"` // An object, or a base64 string when encrypted
+}
+
+type aegisHeader struct {
+	Slots  []aegisSlot  `json:"slots"`
+	Params *aegisParams `json:"params"`
+}
+
+type aegisSlot struct {
+	Type      int         `json:"type"`
+	UUID      string      `json:"uuid"`
+	Key       string      `json:"key"` // Hex encoded
+	KeyParams aegisParams `json:"key_params"`
+	N         int         `json:"n"`
+	R         int         `json:"r"`
+	P         int         `json:"p"`
+	Salt      string      `json:"salt"` // Hex encoded
+	Repaired  bool        `json:"repaired"`
+}
+
+// The aegisParams type holds the hex encoded nonce and tag of an AES-GCM
+// sealed value.
+type aegisParams struct {
+	Nonce string `json:"nonce"`
+	Tag   string `json:"tag"`
+}
+
+type aegisDB struct {
+	Version int          `json:"version"`
+	Entries []aegisEntry `json:"entries"`
+}
+
+type aegisEntry struct {
+	Type     string    `json:"type"`
+	UUID     string    `json:"uuid"`
+	Name     string    `json:"name"`
+	Issuer   string    `json:"issuer"`
+	Note     string    `json:"note"`
+	Favorite bool      `json:"favorite"`
+	Icon     *string   `json:"icon"`
+	Info     aegisInfo `json:"info"`
+}
+
+type aegisInfo struct {
+	Secret  string  `json:"secret"`
+	Algo    string  `json:"algo"`
+	Digits  int     `json:"digits"`
+	Period  *int    `json:"period,omitempty"`
+	Counter *uint64 `json:"counter,omitempty"`
+}
+
+// Import reads the HOTP and TOTP accounts of an Aegis vault.
+func (a *Aegis) Import(r io.Reader) ([]Account, error) {
+	var f aegisFile
+	if err := json.NewDecoder(r).Decode(&f); err != nil {
+		return nil, ErrorInvalidFile{msg: err.Error()}
+	}
+
+	if f.Version != 1 {
+		return nil, ErrorUnsupported{msg: "aegis vault version"}
+	}
+
+	raw := []byte(f.DB)
+	if f.Header.Slots != nil {
+		var err error
+		if raw, err = a.open(f); err != nil {
+			return nil, err
+		}
+	}
+
+	var db aegisDB
+	if err := json.Unmarshal(raw, &db); err != nil {
+		return nil, ErrorInvalidFile{msg: err.Error()}
+	}
+
+	accounts := make([]Account, 0, len(db.Entries))
+	var skipped ErrorSkipped
+	for _, e := range db.Entries {
+		p := params{kind: e.Type, secret: e.Info.Secret, algorithm: e.Info.Algo, digits: e.Info.Digits}
+		if e.Info.Period != nil {
+			p.period = *e.Info.Period
+		}
+
+		if e.Info.Counter != nil {
+			p.counter = *e.Info.Counter
+		}
+
+		otp, err := p.otp(e.Name)
+		if skipped.skip(err) {
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		accounts = append(accounts, Account{Label: authenticator.Label{AccountName: e.Name, Issuer: e.Issuer}, OTP: otp})
+	}
+
+	return accounts, skipped.orNil()
+}
+
+// Export writes the accounts as an Aegis vault, encrypted if Password is set.
+func (a *Aegis) Export(w io.Writer, accounts []Account) error {
+	db := aegisDB{Version: 2, Entries: make([]aegisEntry, 0, len(accounts))}
+	for _, acc := range accounts {
+		p, err := paramsOf(acc)
+		if err != nil {
+			return err
+		}
+
+		id, err := newUUID()
+		if err != nil {
+			return err
+		}
+
+		e := aegisEntry{
+			Type:   p.kind,
+			UUID:   id,
+			Name:   acc.Label.AccountName,
+			Issuer: acc.Label.Issuer,
+			Info:   aegisInfo{Secret: p.secret, Algo: p.algorithm, Digits: p.digits},
+		}
+
+		if p.kind == otpgo.TypeTOTP {
+			e.Info.Period = &p.period
+		} else {
+			e.Info.Counter = &p.counter
+		}
+
+		db.Entries = append(db.Entries, e)
+	}
+
+	raw, err := json.Marshal(db)
+	if err != nil {
+		return err
+	}
+
+	f := aegisFile{Version: 1, DB: raw}
+	if a.Password != "" {
+		if err := a.seal(&f, raw); err != nil {
+			return err
+		}
+	}
+
+	return writeJSON(w, f)
+}
+
+// open returns the decrypted database of the vault, using the first password
+// slot the password opens.
+func (a *Aegis) open(f aegisFile) ([]byte, error) {
+	var encoded string
+	if err := json.Unmarshal(f.DB, &encoded); err != nil {
+		return nil, ErrorInvalidFile{msg: "encrypted database must be a string"}
+	}
+
+	if f.Header.Params == nil {
+		return nil, ErrorInvalidFile{msg: "missing database params"}
+	}
+
+	db, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrorInvalidFile{msg: "malformed encrypted database"}
+	}
+
+	for _, s := range f.Header.Slots {
+		if s.Type != aegisPasswordSlot {
+			continue
+		}
+
+		salt, err := hex.DecodeString(s.Salt)
+		if err != nil {
+			return nil, ErrorInvalidFile{msg: "malformed slot salt"}
+		}
+
+		// scrypt checks the parameters are valid, not that they are affordable.
+		if !kdf.Affordable(s.N, s.R, s.P) {
+			return nil, ErrorInvalidFile{msg: "slot kdf cost exceeds the supported limits"}
+		}
+
+		key, err := scrypt.Key([]byte(a.Password), salt, s.N, s.R, s.P, 32)
+		if err != nil {
+			return nil, ErrorInvalidFile{msg: err.Error()}
+		}
+
+		sealed, err := hex.DecodeString(s.Key)
+		if err != nil {
+			return nil, ErrorInvalidFile{msg: "malformed slot key"}
+		}
+
+		masterKey, err := openGCM(key, s.KeyParams, sealed)
+		if err != nil {
+			// Slots are tried in turn, the password may open another one.
+			continue
+		}
+
+		return openGCM(masterKey, *f.Header.Params, db)
+	}
+
+	return nil, ErrorDecryption{}
+}
+
+// seal encrypts the database with a random master key, stored in a single
+// password slot.
+func (a *Aegis) seal(f *aegisFile, db []byte) error {
+	masterKey, salt := make([]byte, 32), make([]byte, 32)
+	if _, err := rand.Read(masterKey); err != nil {
+		return err
+	}
+
+	if _, err := rand.Read(salt); err != nil {
+		return err
+	}
+
+	id, err := newUUID()
+	if err != nil {
+		return err
+	}
+
+	s := aegisSlot{Type: aegisPasswordSlot, UUID: id, N: a.n(), R: 8, P: 1, Salt: hex.EncodeToString(salt), Repaired: true}
+
+	key, err := scrypt.Key([]byte(a.Password), salt, s.N, s.R, s.P, 32)
+	if err != nil {
+		return err
+	}
+
+	var sealed []byte
+	if sealed, s.KeyParams, err = sealGCM(key, masterKey); err != nil {
+		return err
+	}
+
+	s.Key = hex.EncodeToString(sealed)
+
+	sealed, dbParams, err := sealGCM(masterKey, db)
+	if err != nil {
+		return err
+	}
+
+	encoded, err := json.Marshal(base64.StdEncoding.EncodeToString(sealed))
+	if err != nil {
+		return err
+	}
+
+	f.Header = aegisHeader{Slots: []aegisSlot{s}, Params: &dbParams}
+	f.DB = encoded
+
+	return nil
+}
+
+// n returns the scrypt cost of exported vaults, AegisDefaultN if unset.
+func (a *Aegis) n() int {
+	if a.N == 0 {
+		return AegisDefaultN
+	}
+
+	return a.N
+}
+
+// openGCM decrypts the ciphertext, whose tag is stored apart as Aegis does.
+func openGCM(key []byte, p aegisParams, sealed []byte) ([]byte, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce, errNonce := hex.DecodeString(p.Nonce)
+	tag, errTag := hex.DecodeString(p.Tag)
+	if errNonce != nil || errTag != nil || len(nonce) != aead.NonceSize() {
+		return nil, ErrorInvalidFile{msg: "malformed encryption params"}
+	}
+
+	plaintext, err := aead.Open(nil, nonce, append(append([]byte{}, sealed...), tag...), nil)
+	if err != nil {
+		return nil, ErrorDecryption{}
+	}
+
+	return plaintext, nil
+}
+
+// sealGCM encrypts the plaintext with a random nonce, and returns the
+// ciphertext without its tag, and the hex encoded nonce and tag.
+func sealGCM(key, plaintext []byte) ([]byte, aegisParams, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, aegisParams{}, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, aegisParams{}, err
+	}
+
+	sealed := aead.Seal(nil, nonce, plaintext, nil)
+	split := len(sealed) - aead.Overhead()
+
+	return sealed[:split], aegisParams{Nonce: hex.EncodeToString(nonce), Tag: hex.EncodeToString(sealed[split:])}, nil
+}
+
+// newGCM returns the AES-GCM cipher of the key.
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, ErrorDecryption{}
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// writeJSON writes the indented JSON representation of v.
+func writeJSON(w io.Writer, v interface{}) error {
+	out, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(append(out, '\n'))
+
+	return err
+}
@@ -0,0 +1,141 @@
+package importers
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jltorresm/otpgo"
+	"github.com/jltorresm/otpgo/authenticator"
+	"github.com/jltorresm/otpgo/config"
+)
+
+const aegisPlain = `{
+  "version": 1,
+  "header": {"slots": null, "params": null},
+  "db": {
+    "version": 2,
+    "entries": [
+      {
+        "type": "totp",
+        "uuid": "3ae6f1ad-2e65-4ed2-a953-1ec0dff2386d",
+        "name": "Mason",
+        "issuer": "Deno",
+        "note": "",
+        "icon": null,
+        "info": {"secret": "4SJHB4GSD43FZBAI7C2HLRJGPQ", "algo": "SHA1", "digits": 6, "period": 30}
+      },
+      {
+        "type": "hotp",
+        "uuid": "0b5f8c5f-7b3c-4b4e-9a8f-0b0b0bdfb7f1",
+        "name": "James",
+        "issuer": "Issuu",
+        "note": "",
+        "icon": null,
+        "info": {"secret": "YOOMIXWS5GN6RTBPUFFWKTW5M4", "algo": "SHA512", "digits": 8, "counter": 3}
+      }
+    ]
+  }
+}`
+
+func TestAegis_Import(t *testing.T) {
+	accounts, err := (&Aegis{}).Import(strings.NewReader(aegisPlain))
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		t.FailNow()
+	}
+
+	expected := []Account{
+		{
+			Label: authenticator.Label{AccountName: "Mason", Issuer: "Deno"},
+			OTP:   &otpgo.TOTP{Key: "4SJHB4GSD43FZBAI7C2HLRJGPQ", Period: 30, Delay: 1, Algorithm: config.HmacSHA1, Length: config.Length6},
+		},
+		{
+			Label: authenticator.Label{AccountName: "James", Issuer: "Issuu"},
+			OTP:   &otpgo.HOTP{Key: "YOOMIXWS5GN6RTBPUFFWKTW5M4", Counter: 3, Leeway: 1, Algorithm: config.HmacSHA512, Length: config.Length8},
+		},
+	}
+
+	if !reflect.DeepEqual(expected, accounts) {
+		t.Errorf("unexpected accounts\nexpected: %+v\n  actual: %+v", expected, accounts)
+	}
+}
+
+func TestAegis_Import_Errors(t *testing.T) {
+	cases := []struct {
+		label    string
+		file     string
+		expected error
+	}{
+		{"Not JSON", "not json", ErrorInvalidFile{}},
+		{"Version", strings.Replace(aegisPlain, `"version": 1`, `"version": 2`, 1), ErrorUnsupported{}},
+		{"Encrypted Plain Database", strings.Replace(aegisPlain, `"slots": null`, `"slots": []`, 1), ErrorInvalidFile{}},
+	}
+
+	for _, c := range cases {
+		_, err := (&Aegis{}).Import(strings.NewReader(c.file))
+		if reflect.TypeOf(err) != reflect.TypeOf(c.expected) {
+			t.Errorf("case %s: unexpected error\nexpected: %T\n  actual: %v", c.label, c.expected, err)
+		}
+	}
+}
+
+func TestAegis_Encrypted(t *testing.T) {
+	a := &Aegis{Password: "test", N: 1 << 10}
+
+	var out bytes.Buffer
+	if err := a.Export(&out, testAccounts()); err != nil {
+		t.Errorf("unexpected error: %s", err)
+		t.FailNow()
+	}
+
+	var f aegisFile
+	if err := json.Unmarshal(out.Bytes(), &f); err != nil {
+		t.Errorf("unexpected error: %s", err)
+		t.FailNow()
+	}
+
+	if len(f.Header.Slots) != 1 || f.Header.Slots[0].Type != aegisPasswordSlot || f.Header.Params == nil || f.DB[0] != '"' {
+		t.Errorf("unexpected encrypted vault\n%s", out.String())
+	}
+
+	if strings.Contains(out.String(), "4SJHB4GSD43FZBAI7C2HLRJGPQ") {
+		t.Errorf("unexpected plain secret in\n%s", out.String())
+	}
+
+	encrypted := out.String()
+	tampered := strings.Replace(encrypted, f.Header.Params.Tag, strings.Repeat("0", len(f.Header.Params.Tag)), 1)
+
+	cases := []struct {
+		label    string
+		password string
+		file     string
+	}{
+		{"Wrong Password", "wrong", encrypted},
+		{"No Password", "", encrypted},
+		{"Tampered", "test", tampered},
+	}
+
+	for _, c := range cases {
+		_, err := (&Aegis{Password: c.password}).Import(strings.NewReader(c.file))
+		if _, ok := err.(ErrorDecryption); !ok {
+			t.Errorf("case %s: unexpected error\nexpected: %T\n  actual: %v", c.label, ErrorDecryption{}, err)
+		}
+	}
+
+	// The cost is checked before deriving, scrypt would need 1TiB otherwise.
+	f.Header.Slots[0].N = 1 << 30
+	costly, err := json.Marshal(f)
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		t.FailNow()
+	}
+
+	if _, err := a.Import(bytes.NewReader(costly)); err == nil {
+		t.Error("expected error for a costly slot")
+	} else if _, ok := err.(ErrorInvalidFile); !ok {
+		t.Errorf("unexpected error\nexpected: %T\n  actual: %v", ErrorInvalidFile{}, err)
+	}
+}
@@ -0,0 +1,95 @@
+package importers
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+
+	"github.com/jltorresm/otpgo"
+	"github.com/jltorresm/otpgo/authenticator"
+)
+
+// The AndOTP type reads and writes unencrypted andOTP backups, a JSON array
+// with one object per account.
+type AndOTP struct{}
+
+type andOTPEntry struct {
+	Secret        string   `json:"secret"`
+	Issuer        string   `json:"issuer"`
+	Label         string   `json:"label"`
+	Digits        int      `json:"digits"`
+	Type          string   `json:"type"`
+	Algorithm     string   `json:"algorithm"`
+	Thumbnail     string   `json:"thumbnail"`
+	LastUsed      int64    `json:"last_used"`
+	UsedFrequency int      `json:"used_frequency"`
+	Period        *int     `json:"period,omitempty"`
+	Counter       *uint64  `json:"counter,omitempty"`
+	Tags          []string `json:"tags"`
+}
+
+// Import reads the HOTP and TOTP accounts of an andOTP backup.
+func (*AndOTP) Import(r io.Reader) ([]Account, error) {
+	var entries []andOTPEntry
+	if err := json.NewDecoder(r).Decode(&entries); err != nil {
+		return nil, ErrorInvalidFile{msg: err.Error()}
+	}
+
+	accounts := make([]Account, 0, len(entries))
+	var skipped ErrorSkipped
+	for _, e := range entries {
+		p := params{kind: e.Type, secret: e.Secret, algorithm: e.Algorithm, digits: e.Digits}
+		if e.Period != nil {
+			p.period = *e.Period
+		}
+
+		if e.Counter != nil {
+			p.counter = *e.Counter
+		}
+
+		otp, err := p.otp(e.Label)
+		if skipped.skip(err) {
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		accounts = append(accounts, Account{Label: authenticator.Label{AccountName: e.Label, Issuer: e.Issuer}, OTP: otp})
+	}
+
+	return accounts, skipped.orNil()
+}
+
+// Export writes the accounts as an andOTP backup.
+func (*AndOTP) Export(w io.Writer, accounts []Account) error {
+	entries := make([]andOTPEntry, 0, len(accounts))
+	for _, acc := range accounts {
+		p, err := paramsOf(acc)
+		if err != nil {
+			return err
+		}
+
+		e := andOTPEntry{
+			Secret:    p.secret,
+			Issuer:    acc.Label.Issuer,
+			Label:     acc.Label.AccountName,
+			Digits:    p.digits,
+			Type:      strings.ToUpper(p.kind),
+			Algorithm: p.algorithm,
+			Thumbnail: "Default",
+			Tags:      []string{},
+		}
+
+		if p.kind == otpgo.TypeTOTP {
+			e.Period = &p.period
+		} else {
+			e.Counter = &p.counter
+		}
+
+		entries = append(entries, e)
+	}
+
+	return writeJSON(w, entries)
+}
@@ -0,0 +1,81 @@
+package importers
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jltorresm/otpgo"
+	"github.com/jltorresm/otpgo/authenticator"
+	"github.com/jltorresm/otpgo/config"
+)
+
+const andOTPBackup = `[
+  {
+    "secret": "4SJHB4GSD43FZBAI7C2HLRJGPQ",
+    "issuer": "Deno",
+    "label": "Mason",
+    "digits": 6,
+    "type": "TOTP",
+    "algorithm": "SHA1",
+    "thumbnail": "Default",
+    "last_used": 1594380950402,
+    "used_frequency": 0,
+    "period": 30,
+    "tags": []
+  },
+  {
+    "secret": "YOOMIXWS5GN6RTBPUFFWKTW5M4",
+    "issuer": "Issuu",
+    "label": "James",
+    "digits": 6,
+    "type": "HOTP",
+    "algorithm": "SHA256",
+    "thumbnail": "Default",
+    "last_used": 1594380950402,
+    "used_frequency": 0,
+    "counter": 5,
+    "tags": ["work"]
+  }
+]`
+
+func TestAndOTP_Import(t *testing.T) {
+	accounts, err := (&AndOTP{}).Import(strings.NewReader(andOTPBackup))
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		t.FailNow()
+	}
+
+	expected := []Account{
+		{
+			Label: authenticator.Label{AccountName: "Mason", Issuer: "Deno"},
+			OTP:   &otpgo.TOTP{Key: "4SJHB4GSD43FZBAI7C2HLRJGPQ", Period: 30, Delay: 1, Algorithm: config.HmacSHA1, Length: config.Length6},
+		},
+		{
+			Label: authenticator.Label{AccountName: "James", Issuer: "Issuu"},
+			OTP:   &otpgo.HOTP{Key: "YOOMIXWS5GN6RTBPUFFWKTW5M4", Counter: 5, Leeway: 1, Algorithm: config.HmacSHA256, Length: config.Length6},
+		},
+	}
+
+	if !reflect.DeepEqual(expected, accounts) {
+		t.Errorf("unexpected accounts\nexpected: %+v\n  actual: %+v", expected, accounts)
+	}
+}
+
+func TestAndOTP_Import_Errors(t *testing.T) {
+	cases := []struct {
+		label    string
+		file     string
+		expected error
+	}{
+		{"Not JSON", "not json", ErrorInvalidFile{}},
+		{"Encrypted", "\x8a\x01\x02", ErrorInvalidFile{}},
+	}
+
+	for _, c := range cases {
+		_, err := (&AndOTP{}).Import(strings.NewReader(c.file))
+		if reflect.TypeOf(err) != reflect.TypeOf(c.expected) {
+			t.Errorf("case %s: unexpected error\nexpected: %T\n  actual: %v", c.label, c.expected, err)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package importers
+
+import (
+	"fmt"
+	"strings"
+)
+
+// The ErrorInvalidFile represents a backup that can not be read.
+type ErrorInvalidFile struct {
+	msg string
+}
+
+func (eif ErrorInvalidFile) Error() string {
+	return fmt.Sprintf("invalid backup file: %s", eif.msg)
+}
+
+// The ErrorUnsupported represents a backup feature, or an OTP type, that the
+// package can't handle.
+type ErrorUnsupported struct {
+	msg string
+}
+
+func (eu ErrorUnsupported) Error() string {
+	return fmt.Sprintf("unsupported backup content: %s", eu.msg)
+}
+
+// The ErrorSkipped is returned along with the imported accounts when some
+// entries of the backup were left out because they are not supported, e.g.:
+// Steam or mOTP entries. The other accounts are imported all the same.
+type ErrorSkipped struct {
+	Entries []ErrorUnsupported // Why each entry was skipped, in backup order
+}
+
+func (es ErrorSkipped) Error() string {
+	reasons := make([]string, 0, len(es.Entries))
+	for _, e := range es.Entries {
+		reasons = append(reasons, e.msg)
+	}
+
+	return fmt.Sprintf("skipped %d unsupported entries: %s", len(es.Entries), strings.Join(reasons, "; "))
+}
+
+// skip records the entry as skipped if err is an ErrorUnsupported, it reports
+// whether it was.
+func (es *ErrorSkipped) skip(err error) bool {
+	eu, ok := err.(ErrorUnsupported)
+	if ok {
+		es.Entries = append(es.Entries, eu)
+	}
+
+	return ok
+}
+
+// orNil returns the error only if an entry was skipped.
+func (es ErrorSkipped) orNil() error {
+	if len(es.Entries) == 0 {
+		return nil
+	}
+
+	return es
+}
+
+// The ErrorDecryption is returned when an encrypted backup can not be
+// decrypted, either because of a wrong password or because the file is
+// corrupted.
+type ErrorDecryption struct{}
+
+func (ed ErrorDecryption) Error() string {
+	return "unable to decrypt backup: wrong password or corrupted file"
+}
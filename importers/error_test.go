@@ -0,0 +1,28 @@
+package importers
+
+import (
+	"testing"
+)
+
+func TestErrors(t *testing.T) {
+	cases := []struct {
+		label    string
+		err      error
+		expected string
+	}{
+		{"Invalid File", ErrorInvalidFile{msg: "line 2: missing secret"}, "invalid backup file: line 2: missing secret"},
+		{"Unsupported", ErrorUnsupported{msg: "encrypted 2fas backup"}, "unsupported backup content: encrypted 2fas backup"},
+		{
+			"Skipped",
+			ErrorSkipped{Entries: []ErrorUnsupported{{msg: `type "steam" of entry "Valve"`}, {msg: "line 3: unsupported key uri type \"motp\""}}},
+			`skipped 2 unsupported entries: type "steam" of entry "Valve"; line 3: unsupported key uri type "motp"`,
+		},
+		{"Decryption", ErrorDecryption{}, "unable to decrypt backup: wrong password or corrupted file"},
+	}
+
+	for _, c := range cases {
+		if c.expected != c.err.Error() {
+			t.Errorf("case %s: unexpected error\nexpected: %s\n  actual: %s", c.label, c.expected, c.err.Error())
+		}
+	}
+}
@@ -0,0 +1,162 @@
+// Package importers reads and writes the backups of popular authenticator
+// apps, so that users can migrate their accounts from and to other apps.
+//
+// Supported formats are Aegis (plain or password encrypted), andOTP and 2FAS
+// (unencrypted) and plain lists of otpauth key URIs. Only HOTP and TOTP
+// accounts are supported, other kinds of entries are skipped and reported by
+// an ErrorSkipped returned along with the imported accounts.
+package importers
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/jltorresm/otpgo"
+	"github.com/jltorresm/otpgo/authenticator"
+	"github.com/jltorresm/otpgo/config"
+)
+
+// The Account type is an OTP credential found in a backup.
+type Account struct {
+	Label authenticator.Label
+	OTP   otpgo.OTP // Either an *otpgo.HOTP or an *otpgo.TOTP
+}
+
+// The Format interface is implemented by every backup format. Import returns
+// the accounts read even when it also returns an ErrorSkipped.
+type Format interface {
+	Import(r io.Reader) ([]Account, error)
+	Export(w io.Writer, accounts []Account) error
+}
+
+var (
+	_ Format = (*Aegis)(nil)
+	_ Format = (*AndOTP)(nil)
+	_ Format = (*TwoFAS)(nil)
+	_ Format = (*URIList)(nil)
+)
+
+var keyEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// The params type holds the OTP settings shared by every backup format, with
+// the names they have in most of them.
+type params struct {
+	kind      string // otpgo.TypeHOTP or otpgo.TypeTOTP
+	secret    string // Base32 encoded
+	algorithm string
+	digits    int
+	period    int
+	counter   uint64
+}
+
+// paramsOf returns the settings of the account OTP, with the defaults applied
+// to the empty ones.
+func paramsOf(a Account) (params, error) {
+	var (
+		p      params
+		alg    config.HmacAlgorithm
+		length config.Length
+	)
+
+	switch o := a.OTP.(type) {
+	case *otpgo.HOTP:
+		p = params{kind: otpgo.TypeHOTP, secret: o.Key, counter: o.Counter}
+		alg, length = o.Algorithm, o.Length
+	case *otpgo.TOTP:
+		p = params{kind: otpgo.TypeTOTP, secret: o.Key, period: o.Period}
+		alg, length = o.Algorithm, o.Length
+
+		if p.period == 0 {
+			p.period = otpgo.TOTPDefaultPeriod
+		}
+	default:
+		return params{}, ErrorUnsupported{msg: fmt.Sprintf("otp type of account %q", a.Label.AccountName)}
+	}
+
+	if alg == 0 {
+		alg = config.HmacSHA1
+	}
+
+	if length == 0 {
+		length = config.Length6
+	}
+
+	p.algorithm, p.digits = alg.String(), int(length)
+
+	return p, nil
+}
+
+// otp returns the HOTP or TOTP described by the settings. The name identifies
+// the entry in errors.
+func (p params) otp(name string) (otpgo.OTP, error) {
+	secret := strings.TrimRight(strings.ToUpper(strings.Replace(p.secret, " ", "", -1)), "=")
+	if _, err := keyEncoding.DecodeString(secret); err != nil || secret == "" {
+		return nil, ErrorInvalidFile{msg: fmt.Sprintf("invalid secret of entry %q", name)}
+	}
+
+	alg := config.HmacSHA1
+	if p.algorithm != "" {
+		parsed, err := config.ParseHmacAlgorithm(p.algorithm)
+		if err != nil {
+			return nil, ErrorUnsupported{msg: fmt.Sprintf("algorithm %q of entry %q", p.algorithm, name)}
+		}
+
+		alg = parsed
+	}
+
+	length := config.Length6
+	if p.digits != 0 {
+		if p.digits < int(config.Length1) || p.digits > int(config.Length8) {
+			return nil, ErrorUnsupported{msg: fmt.Sprintf("%d digits of entry %q", p.digits, name)}
+		}
+
+		length = config.Length(p.digits)
+	}
+
+	switch strings.ToLower(p.kind) {
+	case otpgo.TypeHOTP:
+		return &otpgo.HOTP{
+			Key:       secret,
+			Counter:   p.counter,
+			Leeway:    otpgo.HOTPDefaultLeeway,
+			Algorithm: alg,
+			Length:    length,
+		}, nil
+	case otpgo.TypeTOTP:
+		period := p.period
+		if period == 0 {
+			period = otpgo.TOTPDefaultPeriod
+		}
+
+		if period < 0 {
+			return nil, ErrorInvalidFile{msg: fmt.Sprintf("invalid period of entry %q", name)}
+		}
+
+		return &otpgo.TOTP{
+			Key:       secret,
+			Period:    period,
+			Delay:     otpgo.TOTPDefaultDelay,
+			Algorithm: alg,
+			Length:    length,
+		}, nil
+	}
+
+	return nil, ErrorUnsupported{msg: fmt.Sprintf("type %q of entry %q", p.kind, name)}
+}
+
+// newUUID returns a random (version 4) UUID, used by the formats identifying
+// their entries with one.
+func newUUID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
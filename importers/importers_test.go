@@ -0,0 +1,190 @@
+package importers
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jltorresm/otpgo"
+	"github.com/jltorresm/otpgo/authenticator"
+	"github.com/jltorresm/otpgo/config"
+)
+
+func testAccounts() []Account {
+	return []Account{
+		{
+			Label: authenticator.Label{AccountName: "john.doe@example.org", Issuer: "A Company"},
+			OTP: &otpgo.TOTP{
+				Key:       "4SJHB4GSD43FZBAI7C2HLRJGPQ",
+				Period:    60,
+				Delay:     otpgo.TOTPDefaultDelay,
+				Algorithm: config.HmacSHA256,
+				Length:    config.Length8,
+			},
+		},
+		{
+			Label: authenticator.Label{AccountName: "jane"},
+			OTP: &otpgo.HOTP{
+				Key:       "YOOMIXWS5GN6RTBPUFFWKTW5M4",
+				Counter:   42,
+				Leeway:    otpgo.HOTPDefaultLeeway,
+				Algorithm: config.HmacSHA1,
+				Length:    config.Length6,
+			},
+		},
+	}
+}
+
+func TestFormats_RoundTrip(t *testing.T) {
+	cases := []struct {
+		label  string
+		format Format
+	}{
+		{"Aegis", &Aegis{}},
+		{"Aegis Encrypted", &Aegis{Password: "test", N: 1 << 10}},
+		{"andOTP", &AndOTP{}},
+		{"2FAS", &TwoFAS{}},
+		{"URI List", &URIList{}},
+	}
+
+	for _, c := range cases {
+		var out bytes.Buffer
+		if err := c.format.Export(&out, testAccounts()); err != nil {
+			t.Errorf("case %s: unexpected error: %s", c.label, err)
+			continue
+		}
+
+		accounts, err := c.format.Import(&out)
+		if err != nil {
+			t.Errorf("case %s: unexpected error: %s", c.label, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(testAccounts(), accounts) {
+			t.Errorf("case %s: unexpected accounts\nexpected: %+v\n  actual: %+v", c.label, testAccounts(), accounts)
+		}
+	}
+}
+
+func TestFormats_Export_Defaults(t *testing.T) {
+	accounts := []Account{{Label: authenticator.Label{AccountName: "john"}, OTP: &otpgo.TOTP{Key: "4SJHB4GSD43FZBAI7C2HLRJGPQ"}}}
+	expected := &otpgo.TOTP{
+		Key:       "4SJHB4GSD43FZBAI7C2HLRJGPQ",
+		Period:    otpgo.TOTPDefaultPeriod,
+		Delay:     otpgo.TOTPDefaultDelay,
+		Algorithm: config.HmacSHA1,
+		Length:    config.Length6,
+	}
+
+	for _, f := range []Format{&Aegis{}, &AndOTP{}, &TwoFAS{}, &URIList{}} {
+		var out bytes.Buffer
+		if err := f.Export(&out, accounts); err != nil {
+			t.Errorf("%T: unexpected error: %s", f, err)
+			continue
+		}
+
+		imported, err := f.Import(&out)
+		if err != nil {
+			t.Errorf("%T: unexpected error: %s", f, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(expected, imported[0].OTP) {
+			t.Errorf("%T: unexpected otp\nexpected: %+v\n  actual: %+v", f, expected, imported[0].OTP)
+		}
+	}
+}
+
+func TestFormats_Export_Unsupported(t *testing.T) {
+	accounts := []Account{{Label: authenticator.Label{AccountName: "john"}, OTP: &otpgo.MOTP{Key: "1234567890abcdef"}}}
+
+	for _, f := range []Format{&Aegis{}, &AndOTP{}, &TwoFAS{}, &URIList{}} {
+		err := f.Export(&bytes.Buffer{}, accounts)
+		if _, ok := err.(ErrorUnsupported); !ok {
+			t.Errorf("%T: unexpected error\nexpected: %T\n  actual: %v", f, ErrorUnsupported{}, err)
+		}
+	}
+}
+
+func TestFormats_Import_Skipped(t *testing.T) {
+	cases := []struct {
+		label    string
+		format   Format
+		file     string
+		expected []string
+	}{
+		{"Aegis", &Aegis{}, strings.Replace(aegisPlain, `"type": "totp"`, `"type": "steam"`, 1), []string{"James"}},
+		{"andOTP", &AndOTP{}, strings.Replace(andOTPBackup, `"type": "HOTP"`, `"type": "STEAM"`, 1), []string{"Mason"}},
+		{"2FAS", &TwoFAS{}, strings.Replace(twoFASBackup, `"tokenType": "TOTP"`, `"tokenType": "STEAM"`, 1), []string{"James", "old"}},
+		{"URI List", &URIList{}, "otpauth://motp/Mason?secret=1234567890abcdef\n" + uriList, []string{"Mason", "James"}},
+	}
+
+	for _, c := range cases {
+		accounts, err := c.format.Import(strings.NewReader(c.file))
+
+		skipped, ok := err.(ErrorSkipped)
+		if !ok {
+			t.Errorf("case %s: unexpected error\nexpected: %T\n  actual: %v", c.label, ErrorSkipped{}, err)
+			continue
+		}
+
+		if len(skipped.Entries) != 1 {
+			t.Errorf("case %s: unexpected skipped entries: %v", c.label, skipped.Entries)
+		}
+
+		names := make([]string, 0, len(accounts))
+		for _, acc := range accounts {
+			names = append(names, acc.Label.AccountName)
+		}
+
+		if !reflect.DeepEqual(c.expected, names) {
+			t.Errorf("case %s: unexpected accounts\nexpected: %v\n  actual: %v", c.label, c.expected, names)
+		}
+	}
+}
+
+func TestParams_OTP(t *testing.T) {
+	cases := []struct {
+		label    string
+		params   params
+		expected otpgo.OTP
+		err      error
+	}{
+		{
+			"Lowercase Padded Secret",
+			params{kind: "TOTP", secret: "4sjh b4gs d43f zbai 7c2h lrjg pq======"},
+			&otpgo.TOTP{Key: "4SJHB4GSD43FZBAI7C2HLRJGPQ", Period: 30, Delay: 1, Algorithm: config.HmacSHA1, Length: config.Length6},
+			nil,
+		},
+		{"Empty Secret", params{kind: "totp"}, nil, ErrorInvalidFile{}},
+		{"Invalid Secret", params{kind: "totp", secret: "not base32!"}, nil, ErrorInvalidFile{}},
+		{"Algorithm", params{kind: "totp", secret: "GEZA", algorithm: "MD5"}, nil, ErrorUnsupported{}},
+		{"Digits", params{kind: "totp", secret: "GEZA", digits: 10}, nil, ErrorUnsupported{}},
+		{"Period", params{kind: "totp", secret: "GEZA", period: -30}, nil, ErrorInvalidFile{}},
+		{"Type", params{kind: "steam", secret: "GEZA"}, nil, ErrorUnsupported{}},
+	}
+
+	for _, c := range cases {
+		otp, err := c.params.otp(c.label)
+		if reflect.TypeOf(err) != reflect.TypeOf(c.err) {
+			t.Errorf("case %s: unexpected error\nexpected: %T\n  actual: %v", c.label, c.err, err)
+		}
+
+		if c.expected != nil && !reflect.DeepEqual(c.expected, otp) {
+			t.Errorf("case %s: unexpected otp\nexpected: %+v\n  actual: %+v", c.label, c.expected, otp)
+		}
+	}
+}
+
+func TestNewUUID(t *testing.T) {
+	id, err := newUUID()
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		t.FailNow()
+	}
+
+	if len(id) != 36 || id[14] != '4' || !bytes.ContainsAny([]byte{id[19]}, "89ab") {
+		t.Errorf("unexpected uuid %s", id)
+	}
+}
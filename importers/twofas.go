@@ -0,0 +1,151 @@
+package importers
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/jltorresm/otpgo"
+	"github.com/jltorresm/otpgo/authenticator"
+	"github.com/jltorresm/otpgo/internal/clock"
+)
+
+// TwoFASSchemaVersion is the version of the 2FAS backups written by Export.
+const TwoFASSchemaVersion = 4
+
+// The TwoFAS type reads and writes unencrypted 2FAS backups.
+type TwoFAS struct {
+	Now func() time.Time // Defaults to time.Now
+}
+
+type twoFASFile struct {
+	Services          []twoFASService `json:"services"`
+	ServicesEncrypted string          `json:"servicesEncrypted,omitempty"`
+	Groups            []interface{}   `json:"groups"`
+	UpdatedAt         int64           `json:"updatedAt"` // Unix time in milliseconds
+	SchemaVersion     int             `json:"schemaVersion"`
+}
+
+type twoFASService struct {
+	Name      string      `json:"name"`
+	Secret    string      `json:"secret"`
+	UpdatedAt int64       `json:"updatedAt"`
+	OTP       twoFASOTP   `json:"otp"`
+	Order     twoFASOrder `json:"order"`
+}
+
+type twoFASOTP struct {
+	Label     string `json:"label"`
+	Account   string `json:"account"`
+	Issuer    string `json:"issuer"`
+	Digits    int    `json:"digits"`
+	Period    int    `json:"period"`
+	Algorithm string `json:"algorithm"`
+	Counter   uint64 `json:"counter"`
+	TokenType string `json:"tokenType"`
+	Source    string `json:"source"`
+}
+
+type twoFASOrder struct {
+	Position int `json:"position"`
+}
+
+// Import reads the HOTP and TOTP accounts of a 2FAS backup. The issuer is
+// taken from the service name when the backup doesn't hold it, unless the
+// service is named after the account.
+func (*TwoFAS) Import(r io.Reader) ([]Account, error) {
+	var f twoFASFile
+	if err := json.NewDecoder(r).Decode(&f); err != nil {
+		return nil, ErrorInvalidFile{msg: err.Error()}
+	}
+
+	if f.ServicesEncrypted != "" {
+		return nil, ErrorUnsupported{msg: "encrypted 2fas backup"}
+	}
+
+	accounts := make([]Account, 0, len(f.Services))
+	var skipped ErrorSkipped
+	for _, s := range f.Services {
+		p := params{
+			kind:      s.OTP.TokenType,
+			secret:    s.Secret,
+			algorithm: s.OTP.Algorithm,
+			digits:    s.OTP.Digits,
+			period:    s.OTP.Period,
+			counter:   s.OTP.Counter,
+		}
+
+		// Services added before token types existed are all TOTP.
+		if p.kind == "" {
+			p.kind = otpgo.TypeTOTP
+		}
+
+		otp, err := p.otp(s.Name)
+		if skipped.skip(err) {
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		l := authenticator.Label{AccountName: s.OTP.Account, Issuer: s.OTP.Issuer}
+		if l.AccountName == "" {
+			l.AccountName = s.OTP.Label
+		}
+
+		if l.Issuer == "" && s.Name != l.AccountName {
+			l.Issuer = s.Name
+		}
+
+		accounts = append(accounts, Account{Label: l, OTP: otp})
+	}
+
+	return accounts, skipped.orNil()
+}
+
+// Export writes the accounts as a 2FAS backup. Services are named after the
+// issuer, or the account name when there is no issuer.
+func (tf *TwoFAS) Export(w io.Writer, accounts []Account) error {
+	updatedAt := clock.Now(tf.Now).UnixNano() / int64(time.Millisecond)
+
+	f := twoFASFile{
+		Services:      make([]twoFASService, 0, len(accounts)),
+		Groups:        []interface{}{},
+		UpdatedAt:     updatedAt,
+		SchemaVersion: TwoFASSchemaVersion,
+	}
+
+	for i, acc := range accounts {
+		p, err := paramsOf(acc)
+		if err != nil {
+			return err
+		}
+
+		name := acc.Label.Issuer
+		if name == "" {
+			name = acc.Label.AccountName
+		}
+
+		f.Services = append(f.Services, twoFASService{
+			Name:      name,
+			Secret:    p.secret,
+			UpdatedAt: updatedAt,
+			OTP: twoFASOTP{
+				Label:     acc.Label.AccountName,
+				Account:   acc.Label.AccountName,
+				Issuer:    acc.Label.Issuer,
+				Digits:    p.digits,
+				Period:    p.period,
+				Algorithm: p.algorithm,
+				Counter:   p.counter,
+				TokenType: strings.ToUpper(p.kind),
+				Source:    "Manual",
+			},
+			Order: twoFASOrder{Position: i},
+		})
+	}
+
+	return writeJSON(w, f)
+}
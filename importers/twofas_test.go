@@ -0,0 +1,136 @@
+package importers
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jltorresm/otpgo"
+	"github.com/jltorresm/otpgo/authenticator"
+	"github.com/jltorresm/otpgo/config"
+)
+
+const twoFASBackup = `{
+  "services": [
+    {
+      "name": "Deno",
+      "secret": "4SJHB4GSD43FZBAI7C2HLRJGPQ",
+      "updatedAt": 1660000000000,
+      "otp": {
+        "label": "Deno:Mason",
+        "account": "Mason",
+        "issuer": "Deno",
+        "digits": 6,
+        "period": 30,
+        "algorithm": "SHA1",
+        "tokenType": "TOTP",
+        "source": "Link"
+      },
+      "order": {"position": 0}
+    },
+    {
+      "name": "Issuu",
+      "secret": "YOOMIXWS5GN6RTBPUFFWKTW5M4",
+      "updatedAt": 1660000000000,
+      "otp": {
+        "label": "James",
+        "digits": 7,
+        "algorithm": "SHA1",
+        "counter": 2,
+        "tokenType": "HOTP",
+        "source": "Manual"
+      },
+      "order": {"position": 1}
+    },
+    {
+      "name": "Legacy",
+      "secret": "KUVJJOM753IHTNDSZVCNKL7GII",
+      "updatedAt": 1560000000000,
+      "otp": {"account": "old"},
+      "order": {"position": 2}
+    }
+  ],
+  "groups": [],
+  "updatedAt": 1660000000000,
+  "schemaVersion": 4,
+  "appVersionCode": 4000000,
+  "appVersionName": "4.0.0",
+  "appOrigin": "android"
+}`
+
+func TestTwoFAS_Import(t *testing.T) {
+	accounts, err := (&TwoFAS{}).Import(strings.NewReader(twoFASBackup))
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		t.FailNow()
+	}
+
+	expected := []Account{
+		{
+			Label: authenticator.Label{AccountName: "Mason", Issuer: "Deno"},
+			OTP:   &otpgo.TOTP{Key: "4SJHB4GSD43FZBAI7C2HLRJGPQ", Period: 30, Delay: 1, Algorithm: config.HmacSHA1, Length: config.Length6},
+		},
+		{
+			Label: authenticator.Label{AccountName: "James", Issuer: "Issuu"},
+			OTP:   &otpgo.HOTP{Key: "YOOMIXWS5GN6RTBPUFFWKTW5M4", Counter: 2, Leeway: 1, Algorithm: config.HmacSHA1, Length: config.Length7},
+		},
+		{
+			Label: authenticator.Label{AccountName: "old", Issuer: "Legacy"},
+			OTP:   &otpgo.TOTP{Key: "KUVJJOM753IHTNDSZVCNKL7GII", Period: 30, Delay: 1, Algorithm: config.HmacSHA1, Length: config.Length6},
+		},
+	}
+
+	if !reflect.DeepEqual(expected, accounts) {
+		t.Errorf("unexpected accounts\nexpected: %+v\n  actual: %+v", expected, accounts)
+	}
+}
+
+func TestTwoFAS_Import_Errors(t *testing.T) {
+	cases := []struct {
+		label    string
+		file     string
+		expected error
+	}{
+		{"Not JSON", "not json", ErrorInvalidFile{}},
+		{"Encrypted", `{"services": [], "servicesEncrypted": "c2VjcmV0:c2FsdA==:aXY=", "schemaVersion": 4}`, ErrorUnsupported{}},
+	}
+
+	for _, c := range cases {
+		_, err := (&TwoFAS{}).Import(strings.NewReader(c.file))
+		if reflect.TypeOf(err) != reflect.TypeOf(c.expected) {
+			t.Errorf("case %s: unexpected error\nexpected: %T\n  actual: %v", c.label, c.expected, err)
+		}
+	}
+}
+
+func TestTwoFAS_Export(t *testing.T) {
+	now := time.Unix(1660000000, 0)
+
+	var out bytes.Buffer
+	if err := (&TwoFAS{Now: func() time.Time { return now }}).Export(&out, testAccounts()); err != nil {
+		t.Errorf("unexpected error: %s", err)
+		t.FailNow()
+	}
+
+	var f twoFASFile
+	if err := json.Unmarshal(out.Bytes(), &f); err != nil {
+		t.Errorf("unexpected error: %s", err)
+		t.FailNow()
+	}
+
+	if f.UpdatedAt != 1660000000000 || f.SchemaVersion != TwoFASSchemaVersion {
+		t.Errorf("unexpected backup header\n%s", out.String())
+	}
+
+	names := []string{f.Services[0].Name, f.Services[1].Name}
+	if !reflect.DeepEqual([]string{"A Company", "jane"}, names) {
+		t.Errorf("unexpected service names\nexpected: [A Company jane]\n  actual: %v", names)
+	}
+
+	if f.Services[1].Order.Position != 1 || f.Services[1].OTP.TokenType != "HOTP" {
+		t.Errorf("unexpected service\n  actual: %+v", f.Services[1])
+	}
+}
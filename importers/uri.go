@@ -0,0 +1,96 @@
+package importers
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/jltorresm/otpgo"
+	"github.com/jltorresm/otpgo/authenticator"
+)
+
+// The URIList type reads and writes plain text lists of otpauth key URIs, one
+// per line, as exported by many apps. Blank lines are ignored.
+type URIList struct{}
+
+// Import reads the HOTP and TOTP accounts of a key URI list.
+func (*URIList) Import(r io.Reader) ([]Account, error) {
+	var accounts []Account
+	var skipped ErrorSkipped
+
+	s := bufio.NewScanner(r)
+	for line := 1; s.Scan(); line++ {
+		raw := strings.TrimSpace(s.Text())
+		if raw == "" {
+			continue
+		}
+
+		ku, err := authenticator.ParseKeyUri(raw)
+		if err != nil {
+			return nil, ErrorInvalidFile{msg: fmt.Sprintf("line %d: %s", line, err)}
+		}
+
+		// Only the OTP types the package can't handle are skipped, a malformed
+		// entry of a supported type means the file is corrupted.
+		if ku.Type != otpgo.TypeHOTP && ku.Type != otpgo.TypeTOTP {
+			skipped.Entries = append(skipped.Entries, ErrorUnsupported{msg: fmt.Sprintf("line %d: otp type %q", line, ku.Type)})
+			continue
+		}
+
+		o, err := otpgo.OTPFromKeyUri(ku)
+		if err != nil {
+			return nil, ErrorInvalidFile{msg: fmt.Sprintf("line %d: %s", line, err)}
+		}
+
+		// Go through the shared params, so that the defaults are applied the
+		// same way as for the other formats.
+		p, err := paramsOf(Account{Label: ku.Label, OTP: o})
+		if err != nil {
+			return nil, err
+		}
+
+		otp, err := p.otp(ku.Label.AccountName)
+		if skipped.skip(err) {
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		accounts = append(accounts, Account{Label: ku.Label, OTP: otp})
+	}
+
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	return accounts, skipped.orNil()
+}
+
+// Export writes the key URIs of the accounts, one per line.
+func (*URIList) Export(w io.Writer, accounts []Account) error {
+	for _, acc := range accounts {
+		p, err := paramsOf(acc)
+		if err != nil {
+			return err
+		}
+
+		otp, err := p.otp(acc.Label.AccountName)
+		if err != nil {
+			return err
+		}
+
+		uri, err := otp.KeyUri(acc.Label.AccountName, acc.Label.Issuer).Build()
+		if err != nil {
+			return err
+		}
+
+		if _, err := fmt.Fprintln(w, uri); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
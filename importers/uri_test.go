@@ -0,0 +1,78 @@
+package importers
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jltorresm/otpgo"
+	"github.com/jltorresm/otpgo/authenticator"
+	"github.com/jltorresm/otpgo/config"
+)
+
+const uriList = `otpauth://totp/Deno:Mason?secret=4SJHB4GSD43FZBAI7C2HLRJGPQ&issuer=Deno
+
+otpauth://hotp/James?secret=YOOMIXWS5GN6RTBPUFFWKTW5M4&counter=7&digits=8&algorithm=SHA256
+`
+
+func TestURIList_Import(t *testing.T) {
+	accounts, err := (&URIList{}).Import(strings.NewReader(uriList))
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		t.FailNow()
+	}
+
+	expected := []Account{
+		{
+			Label: authenticator.Label{AccountName: "Mason", Issuer: "Deno"},
+			OTP:   &otpgo.TOTP{Key: "4SJHB4GSD43FZBAI7C2HLRJGPQ", Period: 30, Delay: 1, Algorithm: config.HmacSHA1, Length: config.Length6},
+		},
+		{
+			Label: authenticator.Label{AccountName: "James"},
+			OTP:   &otpgo.HOTP{Key: "YOOMIXWS5GN6RTBPUFFWKTW5M4", Counter: 7, Leeway: 1, Algorithm: config.HmacSHA256, Length: config.Length8},
+		},
+	}
+
+	if !reflect.DeepEqual(expected, accounts) {
+		t.Errorf("unexpected accounts\nexpected: %+v\n  actual: %+v", expected, accounts)
+	}
+}
+
+func TestURIList_Import_Errors(t *testing.T) {
+	cases := []struct {
+		label    string
+		file     string
+		expected error
+	}{
+		{"Not A URI", "otpauth://totp/Deno:Mason?secret=4SJHB4GSD43FZBAI7C2HLRJGPQ\nhttps://example.org", ErrorInvalidFile{}},
+		{"Bad Secret", "otpauth://totp/Deno:Mason?secret=not-base-32", ErrorInvalidFile{}},
+		{"Bad Period", "otpauth://totp/Deno:Mason?secret=4SJHB4GSD43FZBAI7C2HLRJGPQ&period=0", ErrorInvalidFile{}},
+		{"Bad Algorithm", "otpauth://totp/Deno:Mason?secret=4SJHB4GSD43FZBAI7C2HLRJGPQ&algorithm=MD5", ErrorInvalidFile{}},
+	}
+
+	for _, c := range cases {
+		_, err := (&URIList{}).Import(strings.NewReader(c.file))
+		if reflect.TypeOf(err) != reflect.TypeOf(c.expected) {
+			t.Errorf("case %s: unexpected error\nexpected: %T\n  actual: %v", c.label, c.expected, err)
+		}
+	}
+
+	_, err := (&URIList{}).Import(strings.NewReader(cases[0].file))
+	if !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("unexpected error, expected the line number\n  actual: %s", err)
+	}
+}
+
+func TestURIList_Export(t *testing.T) {
+	var out bytes.Buffer
+	if err := (&URIList{}).Export(&out, testAccounts()); err != nil {
+		t.Errorf("unexpected error: %s", err)
+		t.FailNow()
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], "otpauth://totp/A%20Company:john.doe@example.org?") || !strings.HasPrefix(lines[1], "otpauth://hotp/jane?") {
+		t.Errorf("unexpected key uris\n%s", out.String())
+	}
+}
@@ -0,0 +1,16 @@
+// Package clock is the time source of the types with an optional Now field,
+// which lets callers, e.g.: tests, fix the time they see.
+package clock
+
+import (
+	"time"
+)
+
+// Now returns the time given by now, or the current time when it is nil.
+func Now(now func() time.Time) time.Time {
+	if now == nil {
+		return time.Now()
+	}
+
+	return now()
+}
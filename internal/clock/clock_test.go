@@ -0,0 +1,19 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNow(t *testing.T) {
+	fixed := time.Date(2020, 9, 10, 12, 0, 0, 0, time.UTC)
+
+	if actual := Now(func() time.Time { return fixed }); !fixed.Equal(actual) {
+		t.Errorf("unexpected time\nexpected: %s\n  actual: %s", fixed, actual)
+	}
+
+	before := time.Now()
+	if actual := Now(nil); actual.Before(before) || actual.After(time.Now()) {
+		t.Errorf("unexpected time\nexpected: around %s\n  actual: %s", before, actual)
+	}
+}
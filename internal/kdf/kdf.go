@@ -0,0 +1,17 @@
+// Package kdf bounds the cost of the key derivations whose parameters are read
+// from files, e.g.: encrypted vaults and backups, so that a crafted file can't
+// take unbounded memory or time before it is authenticated.
+package kdf
+
+// Limits of the scrypt cost. The memory needed is 128 * N * R bytes.
+const (
+	MaxScryptMemory = 256 << 20
+	MaxScryptR      = 32
+	MaxScryptP      = 16
+)
+
+// Affordable tells whether the scrypt cost parameters are within the limits.
+// It doesn't check they are valid, which is left to scrypt.
+func Affordable(n, r, p int) bool {
+	return r <= MaxScryptR && p <= MaxScryptP && (r <= 0 || n <= MaxScryptMemory/(128*r))
+}
@@ -0,0 +1,24 @@
+package kdf
+
+import "testing"
+
+func TestAffordable(t *testing.T) {
+	cases := []struct {
+		label    string
+		n, r, p  int
+		expected bool
+	}{
+		{"Default", 1 << 15, 8, 1, true},
+		{"Memory Limit", MaxScryptMemory / (128 * 8), 8, 1, true},
+		{"Too Much Memory", 1 << 30, 8, 1, false},
+		{"Too Large R", 1 << 10, MaxScryptR + 1, 1, false},
+		{"Too Large P", 1 << 10, 8, MaxScryptP + 1, false},
+		{"Invalid R", 1 << 10, 0, 1, true},
+	}
+
+	for _, c := range cases {
+		if actual := Affordable(c.n, c.r, c.p); c.expected != actual {
+			t.Errorf("case %s: unexpected result\nexpected: %v\n  actual: %v", c.label, c.expected, actual)
+		}
+	}
+}
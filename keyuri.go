@@ -0,0 +1,95 @@
+package otpgo
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"github.com/jltorresm/otpgo/authenticator"
+	"github.com/jltorresm/otpgo/config"
+)
+
+// HOTPFromKeyUri builds the HOTP described by a key URI, e.g.: one returned by
+// authenticator.ParseKeyUri. Missing parameters are left empty, so that the
+// defaults apply.
+func HOTPFromKeyUri(ku *authenticator.KeyUri) (*HOTP, error) {
+	params, err := keyUriParams(ku, TypeHOTP)
+	if err != nil {
+		return nil, err
+	}
+
+	h := &HOTP{Key: params.Get("secret")}
+
+	if h.Algorithm, h.Length, err = parseCommonParams(params); err != nil {
+		return nil, err
+	}
+
+	if raw := params.Get("counter"); raw != "" {
+		if h.Counter, err = strconv.ParseUint(raw, 10, 64); err != nil {
+			return nil, ErrorInvalidConfig{msg: fmt.Sprintf("invalid counter %q", raw)}
+		}
+	}
+
+	return h, nil
+}
+
+// TOTPFromKeyUri builds the TOTP described by a key URI, e.g.: one returned by
+// authenticator.ParseKeyUri. Missing parameters are left empty, so that the
+// defaults apply.
+func TOTPFromKeyUri(ku *authenticator.KeyUri) (*TOTP, error) {
+	params, err := keyUriParams(ku, TypeTOTP)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &TOTP{Key: params.Get("secret")}
+
+	if t.Algorithm, t.Length, err = parseCommonParams(params); err != nil {
+		return nil, err
+	}
+
+	if raw := params.Get("period"); raw != "" {
+		if t.Period, err = strconv.Atoi(raw); err != nil || t.Period <= 0 {
+			return nil, ErrorInvalidConfig{msg: fmt.Sprintf("invalid period %q", raw)}
+		}
+	}
+
+	return t, nil
+}
+
+// keyUriParams checks the KeyUri type and extracts its parameters, making
+// sure the secret is a valid key.
+func keyUriParams(ku *authenticator.KeyUri, otpType string) (url.Values, error) {
+	if ku.Type != otpType {
+		return nil, ErrorInvalidConfig{msg: fmt.Sprintf("expected %s key uri, got %q", otpType, ku.Type)}
+	}
+
+	params := ku.Parameters.AsUrlValues(ku.Label.Issuer)
+
+	if params.Get("secret") == "" {
+		return nil, ErrorInvalidKey{msg: "missing secret"}
+	}
+
+	if _, err := decodeKey(params.Get("secret")); err != nil {
+		return nil, err
+	}
+
+	return params, nil
+}
+
+// parseCommonParams reads the algorithm and digits parameters, if present.
+func parseCommonParams(params url.Values) (alg config.HmacAlgorithm, length config.Length, err error) {
+	if raw := params.Get("algorithm"); raw != "" {
+		if alg, err = config.ParseHmacAlgorithm(raw); err != nil {
+			return 0, 0, ErrorInvalidConfig{msg: err.Error()}
+		}
+	}
+
+	if raw := params.Get("digits"); raw != "" {
+		if length, err = config.ParseLength(raw); err != nil {
+			return 0, 0, ErrorInvalidConfig{msg: err.Error()}
+		}
+	}
+
+	return alg, length, nil
+}
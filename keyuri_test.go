@@ -0,0 +1,133 @@
+package otpgo
+
+import (
+	"testing"
+
+	"github.com/jltorresm/otpgo/authenticator"
+	"github.com/jltorresm/otpgo/config"
+)
+
+func TestHOTPFromKeyUri(t *testing.T) {
+	original := &HOTP{
+		Key:       "JOC773H4BTUR5U6M422M2AT7S4MTQ7BLR75Y252JK3A",
+		Counter:   759,
+		Algorithm: config.HmacSHA256,
+		Length:    config.Length8,
+	}
+
+	ku, err := authenticator.ParseKeyUri(original.KeyUri("john", "Acme").String())
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		t.FailNow()
+	}
+
+	h, err := HOTPFromKeyUri(ku)
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		t.FailNow()
+	}
+
+	if *original != *h {
+		t.Errorf("unexpected hotp\nexpected: %+v\n  actual: %+v", original, h)
+	}
+}
+
+func TestTOTPFromKeyUri(t *testing.T) {
+	original := &TOTP{
+		Key:       "JOC773H4BTUR5U6M422M2AT7S4MTQ7BLR75Y252JK3A",
+		Period:    60,
+		Algorithm: config.HmacSHA512,
+		Length:    config.Length7,
+	}
+
+	ku, err := authenticator.ParseKeyUri(original.KeyUri("john", "Acme").String())
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		t.FailNow()
+	}
+
+	totp, err := TOTPFromKeyUri(ku)
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		t.FailNow()
+	}
+
+	if *original != *totp {
+		t.Errorf("unexpected totp\nexpected: %+v\n  actual: %+v", original, totp)
+	}
+}
+
+func TestFromKeyUri_Defaults(t *testing.T) {
+	ku, err := authenticator.ParseKeyUri("otpauth://totp/alice?secret=JBSWY3DPEHPK3PXP")
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		t.FailNow()
+	}
+
+	totp, err := TOTPFromKeyUri(ku)
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		t.FailNow()
+	}
+
+	expected := TOTP{Key: "JBSWY3DPEHPK3PXP"}
+	if expected != *totp {
+		t.Errorf("unexpected totp\nexpected: %+v\n  actual: %+v", expected, totp)
+	}
+}
+
+func TestFromKeyUri_Errors(t *testing.T) {
+	cases := []struct {
+		label       string
+		uri         string
+		expectedErr error
+	}{
+		{
+			"Wrong Type",
+			"otpauth://hotp/alice?secret=JBSWY3DPEHPK3PXP",
+			ErrorInvalidConfig{msg: `expected totp key uri, got "hotp"`},
+		},
+		{
+			"Bad Secret",
+			"otpauth://totp/alice?secret=not-base-32",
+			ErrorInvalidKey{msg: "illegal base32 data at input byte 3"},
+		},
+		{
+			"Bad Algorithm",
+			"otpauth://totp/alice?secret=JBSWY3DPEHPK3PXP&algorithm=MD5",
+			ErrorInvalidConfig{msg: `unknown hash algorithm "MD5"`},
+		},
+		{
+			"Bad Digits",
+			"otpauth://totp/alice?secret=JBSWY3DPEHPK3PXP&digits=12",
+			ErrorInvalidConfig{msg: `unsupported length "12"`},
+		},
+		{
+			"Bad Period",
+			"otpauth://totp/alice?secret=JBSWY3DPEHPK3PXP&period=-30",
+			ErrorInvalidConfig{msg: `invalid period "-30"`},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.label, func(t *testing.T) {
+			ku, err := authenticator.ParseKeyUri(c.uri)
+			if err != nil {
+				t.Errorf("unexpected error: %s", err)
+				t.FailNow()
+			}
+
+			_, err = TOTPFromKeyUri(ku)
+			if c.expectedErr != err {
+				t.Errorf("unexpected error\nexpected: %v\n  actual: %v", c.expectedErr, err)
+			}
+		})
+	}
+
+	ku, _ := authenticator.ParseKeyUri("otpauth://hotp/alice?secret=JBSWY3DPEHPK3PXP&counter=-1")
+	_, err := HOTPFromKeyUri(ku)
+	expectedErr := ErrorInvalidConfig{msg: `invalid counter "-1"`}
+	if expectedErr != err {
+		t.Errorf("unexpected error\nexpected: %v\n  actual: %v", expectedErr, err)
+	}
+}
@@ -0,0 +1,168 @@
+package metrics
+
+import (
+	"encoding/json"
+	"expvar"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultBuckets are the upper bounds of the offset histogram buckets, in
+// steps.
+var DefaultBuckets = []float64{-10, -5, -2, -1, 0, 1, 2, 5, 10}
+
+// The Expvar type is a Recorder that keeps its measurements in expvar
+// variables. They can be published with Publish, to be served in JSON by the
+// expvar handler, and served in the Prometheus text format by ServeHTTP. The
+// zero value is ready to use.
+type Expvar struct {
+	Buckets []float64 // Offset histogram buckets, defaults to DefaultBuckets
+
+	once        sync.Once
+	mu          sync.Mutex // Serializes the creation of histograms
+	vars        *expvar.Map
+	validations *expvar.Map // Keyed by "type,result"
+	lockouts    *expvar.Map // Keyed by type
+	offsets     *expvar.Map // Histograms keyed by type
+}
+
+// Publish makes the measurements available to the expvar handler under the
+// given name. Like expvar.Publish, it panics if the name is already in use.
+func (e *Expvar) Publish(name string) {
+	e.init()
+	expvar.Publish(name, e.vars)
+}
+
+// Validation counts a validation of the given OTP type and result.
+func (e *Expvar) Validation(otpType, result string) {
+	e.init()
+	e.validations.Add(otpType+","+result, 1)
+}
+
+// Offset records the offset of a successful validation.
+func (e *Expvar) Offset(otpType string, steps int64) {
+	e.init()
+
+	e.mu.Lock()
+	h, ok := e.offsets.Get(otpType).(*histogram)
+	if !ok {
+		h = newHistogram(e.Buckets)
+		e.offsets.Set(otpType, h)
+	}
+	e.mu.Unlock()
+
+	h.observe(float64(steps))
+}
+
+// Lockout counts an attempt blocked by a rate limiter.
+func (e *Expvar) Lockout(otpType string) {
+	e.init()
+	e.lockouts.Add(otpType, 1)
+}
+
+func (e *Expvar) init() {
+	e.once.Do(func() {
+		e.validations = new(expvar.Map).Init()
+		e.lockouts = new(expvar.Map).Init()
+		e.offsets = new(expvar.Map).Init()
+
+		e.vars = new(expvar.Map).Init()
+		e.vars.Set("validations", e.validations)
+		e.vars.Set("lockouts", e.lockouts)
+		e.vars.Set("offsets", e.offsets)
+	})
+}
+
+// counters returns the values of an expvar map of counters, sorted by key.
+func counters(m *expvar.Map) (keys []string, values map[string]int64) {
+	values = map[string]int64{}
+	m.Do(func(kv expvar.KeyValue) {
+		keys = append(keys, kv.Key)
+		values[kv.Key] = kv.Value.(*expvar.Int).Value()
+	})
+	sort.Strings(keys)
+
+	return keys, values
+}
+
+// The histogram type is an expvar.Var that counts observations in cumulative
+// buckets, as Prometheus histograms do.
+type histogram struct {
+	mu     sync.Mutex
+	bounds []float64
+	counts []uint64 // Non cumulative, one per bound
+	count  uint64
+	sum    float64
+}
+
+func newHistogram(bounds []float64) *histogram {
+	if bounds == nil {
+		bounds = DefaultBuckets
+	}
+
+	bounds = append([]float64(nil), bounds...)
+	sort.Float64s(bounds)
+
+	return &histogram{bounds: bounds, counts: make([]uint64, len(bounds))}
+}
+
+func (h *histogram) observe(v float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for i, bound := range h.bounds {
+		if v <= bound {
+			h.counts[i]++
+			break
+		}
+	}
+
+	h.count++
+	h.sum += v
+}
+
+// snapshot returns the cumulative bucket counts, the total count and the sum.
+func (h *histogram) snapshot() (cumulative []uint64, count uint64, sum float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	cumulative = make([]uint64, len(h.counts))
+	var total uint64
+	for i, c := range h.counts {
+		total += c
+		cumulative[i] = total
+	}
+
+	return cumulative, h.count, h.sum
+}
+
+// String returns the JSON representation of the histogram, as expected by
+// expvar.
+func (h *histogram) String() string {
+	cumulative, count, sum := h.snapshot()
+
+	buckets := make([]string, len(h.bounds))
+	for i, bound := range h.bounds {
+		buckets[i] = formatFloat(bound)
+	}
+
+	raw, _ := json.Marshal(struct {
+		Buckets []string `json:"buckets"`
+		Counts  []uint64 `json:"counts"`
+		Count   uint64   `json:"count"`
+		Sum     float64  `json:"sum"`
+	}{buckets, cumulative, count, sum})
+
+	return string(raw)
+}
+
+// splitKey separates the type and result of a validations key.
+func splitKey(key string) (otpType, result string) {
+	parts := strings.SplitN(key, ",", 2)
+	if len(parts) == 1 {
+		return parts[0], ""
+	}
+
+	return parts[0], parts[1]
+}
@@ -0,0 +1,66 @@
+package metrics
+
+import (
+	"encoding/json"
+	"expvar"
+	"sync"
+	"testing"
+)
+
+func TestExpvar_Publish(t *testing.T) {
+	e := &Expvar{Buckets: []float64{1, -1, 0}}
+	e.Publish("otpgo_test")
+
+	e.Validation("totp", "success")
+	e.Validation("totp", "success")
+	e.Validation("hotp", "invalid_token")
+	e.Lockout("totp")
+	e.Offset("totp", -1)
+	e.Offset("totp", 3)
+
+	var published struct {
+		Validations map[string]int64 `json:"validations"`
+		Lockouts    map[string]int64 `json:"lockouts"`
+		Offsets     map[string]struct {
+			Buckets []string `json:"buckets"`
+			Counts  []uint64 `json:"counts"`
+			Count   uint64   `json:"count"`
+			Sum     float64  `json:"sum"`
+		} `json:"offsets"`
+	}
+
+	if err := json.Unmarshal([]byte(expvar.Get("otpgo_test").String()), &published); err != nil {
+		t.Errorf("unexpected error: %s", err)
+		t.FailNow()
+	}
+
+	if published.Validations["totp,success"] != 2 || published.Validations["hotp,invalid_token"] != 1 || published.Lockouts["totp"] != 1 {
+		t.Errorf("unexpected counters: %+v", published)
+	}
+
+	h := published.Offsets["totp"]
+	if len(h.Buckets) != 3 || h.Buckets[0] != "-1" || h.Counts[0] != 1 || h.Counts[2] != 1 || h.Count != 2 || h.Sum != 2 {
+		t.Errorf("unexpected histogram: %+v", h)
+	}
+}
+
+func TestExpvar_Concurrency(t *testing.T) {
+	e := &Expvar{}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			e.Validation("totp", "success")
+			e.Offset("totp", 0)
+		}()
+	}
+	wg.Wait()
+
+	_, values := counters(e.validations)
+	_, count, _ := e.offsets.Get("totp").(*histogram).snapshot()
+	if values["totp,success"] != 50 || count != 50 {
+		t.Errorf("unexpected measurements\nexpected: %d\n  actual: %d, %d", 50, values["totp,success"], count)
+	}
+}
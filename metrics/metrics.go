@@ -0,0 +1,53 @@
+// Package metrics measures OTP validations, so that operators can follow
+// success and failure rates, clock drift and lockouts.
+//
+// Validations are measured by an otpgo.Observer, Observer, which forwards them
+// to a Recorder. Expvar is a Recorder that publishes the measurements with the
+// expvar package and serves them in the Prometheus text exposition format.
+package metrics
+
+import (
+	"github.com/jltorresm/otpgo"
+)
+
+// ResultSuccess is the result recorded for successful validations, failures
+// are recorded with their otpgo.Event reason.
+const ResultSuccess = "success"
+
+// The Recorder interface receives the measurements of OTP validations.
+// Implementations must be safe for concurrent use.
+type Recorder interface {
+	// Validation counts a validation of the given OTP type and result.
+	Validation(otpType, result string)
+
+	// Offset records the steps between the expected code and the matching one
+	// of a successful validation.
+	Offset(otpType string, steps int64)
+
+	// Lockout counts an attempt blocked by a rate limiter.
+	Lockout(otpType string)
+}
+
+// The Observer type is an otpgo.Observer that forwards validation events to a
+// Recorder.
+type Observer struct {
+	Recorder Recorder
+	Next     otpgo.Observer // Optional, also notified of every event, e.g.: an audit.Writer
+}
+
+// Observe records the event.
+func (o Observer) Observe(e otpgo.Event) {
+	switch {
+	case e.Reason == otpgo.ReasonThrottled:
+		o.Recorder.Lockout(e.Type)
+	case e.Success:
+		o.Recorder.Validation(e.Type, ResultSuccess)
+		o.Recorder.Offset(e.Type, e.Offset)
+	default:
+		o.Recorder.Validation(e.Type, e.Reason)
+	}
+
+	if o.Next != nil {
+		o.Next.Observe(e)
+	}
+}
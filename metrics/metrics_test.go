@@ -0,0 +1,55 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jltorresm/otpgo"
+)
+
+// callRecorder keeps a description of every call it receives.
+type callRecorder struct {
+	calls []string
+}
+
+func (c *callRecorder) Validation(otpType, result string) {
+	c.calls = append(c.calls, "validation:"+otpType+":"+result)
+}
+
+func (c *callRecorder) Offset(otpType string, steps int64) {
+	c.calls = append(c.calls, "offset:"+otpType+":"+formatFloat(float64(steps)))
+}
+
+func (c *callRecorder) Lockout(otpType string) {
+	c.calls = append(c.calls, "lockout:"+otpType)
+}
+
+func TestObserver_Observe(t *testing.T) {
+	cases := []struct {
+		label    string
+		event    otpgo.Event
+		expected string
+	}{
+		{"Success", otpgo.Event{Type: otpgo.TypeTOTP, Success: true, Offset: -1}, "validation:totp:success offset:totp:-1"},
+		{"Failure", otpgo.Event{Type: otpgo.TypeHOTP, Reason: otpgo.ReasonReplay}, "validation:hotp:replay"},
+		{"Throttled", otpgo.Event{Type: otpgo.TypeTOTP, Reason: otpgo.ReasonThrottled}, "lockout:totp"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.label, func(t *testing.T) {
+			r := &callRecorder{}
+			next := &callRecorder{}
+			o := Observer{Recorder: r, Next: Observer{Recorder: next}}
+
+			o.Observe(c.event)
+
+			if actual := strings.Join(r.calls, " "); c.expected != actual {
+				t.Errorf("unexpected calls\nexpected: %s\n  actual: %s", c.expected, actual)
+			}
+
+			if actual := strings.Join(next.calls, " "); c.expected != actual {
+				t.Errorf("unexpected calls to next\nexpected: %s\n  actual: %s", c.expected, actual)
+			}
+		})
+	}
+}
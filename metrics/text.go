@@ -0,0 +1,84 @@
+package metrics
+
+import (
+	"bufio"
+	"expvar"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Names of the metrics in the Prometheus text exposition format.
+const (
+	NameValidations = "otpgo_validations_total"
+	NameLockouts    = "otpgo_lockouts_total"
+	NameOffset      = "otpgo_offset_steps"
+)
+
+// WriteText writes the measurements in the Prometheus text exposition format,
+// version 0.0.4.
+func (e *Expvar) WriteText(w io.Writer) error {
+	e.init()
+
+	b := bufio.NewWriter(w)
+
+	fmt.Fprintf(b, "# HELP %s OTP validations by type and result.\n", NameValidations)
+	fmt.Fprintf(b, "# TYPE %s counter\n", NameValidations)
+	keys, values := counters(e.validations)
+	for _, key := range keys {
+		otpType, result := splitKey(key)
+		fmt.Fprintf(b, "%s{type=%s,result=%s} %d\n", NameValidations, quote(otpType), quote(result), values[key])
+	}
+
+	fmt.Fprintf(b, "# HELP %s Validation attempts blocked by a rate limiter.\n", NameLockouts)
+	fmt.Fprintf(b, "# TYPE %s counter\n", NameLockouts)
+	keys, values = counters(e.lockouts)
+	for _, key := range keys {
+		fmt.Fprintf(b, "%s{type=%s} %d\n", NameLockouts, quote(key), values[key])
+	}
+
+	fmt.Fprintf(b, "# HELP %s Steps between the expected and the matching code of successful validations.\n", NameOffset)
+	fmt.Fprintf(b, "# TYPE %s histogram\n", NameOffset)
+	e.offsets.Do(func(kv expvar.KeyValue) {
+		h := kv.Value.(*histogram)
+		cumulative, count, sum := h.snapshot()
+		otpType := quote(kv.Key)
+
+		for i, bound := range h.bounds {
+			fmt.Fprintf(b, "%s_bucket{type=%s,le=%s} %d\n", NameOffset, otpType, quote(formatFloat(bound)), cumulative[i])
+		}
+		fmt.Fprintf(b, "%s_bucket{type=%s,le=\"+Inf\"} %d\n", NameOffset, otpType, count)
+		fmt.Fprintf(b, "%s_sum{type=%s} %s\n", NameOffset, otpType, formatFloat(sum))
+		fmt.Fprintf(b, "%s_count{type=%s} %d\n", NameOffset, otpType, count)
+	})
+
+	return b.Flush()
+}
+
+// ServeHTTP serves the measurements in the Prometheus text exposition format,
+// to be scraped from e.g.: /metrics.
+func (e *Expvar) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_ = e.WriteText(w)
+}
+
+// quote returns the label value quoted and escaped as required by the text
+// exposition format.
+func quote(value string) string {
+	value = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`).Replace(value)
+	return `"` + value + `"`
+}
+
+func formatFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "+Inf"
+	case math.IsInf(f, -1):
+		return "-Inf"
+	}
+
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
@@ -0,0 +1,72 @@
+package metrics
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jltorresm/otpgo"
+)
+
+func TestExpvar_WriteText(t *testing.T) {
+	e := &Expvar{Buckets: []float64{-1, 0, 1}}
+	e.Validation("totp", "success")
+	e.Validation("totp", "success")
+	e.Validation("totp", "invalid_format")
+	e.Lockout("hotp")
+	e.Offset("totp", 0)
+	e.Offset("totp", -2)
+
+	expected := `# HELP otpgo_validations_total OTP validations by type and result.
+# TYPE otpgo_validations_total counter
+otpgo_validations_total{type="totp",result="invalid_format"} 1
+otpgo_validations_total{type="totp",result="success"} 2
+# HELP otpgo_lockouts_total Validation attempts blocked by a rate limiter.
+# TYPE otpgo_lockouts_total counter
+otpgo_lockouts_total{type="hotp"} 1
+# HELP otpgo_offset_steps Steps between the expected and the matching code of successful validations.
+# TYPE otpgo_offset_steps histogram
+otpgo_offset_steps_bucket{type="totp",le="-1"} 1
+otpgo_offset_steps_bucket{type="totp",le="0"} 2
+otpgo_offset_steps_bucket{type="totp",le="1"} 2
+otpgo_offset_steps_bucket{type="totp",le="+Inf"} 2
+otpgo_offset_steps_sum{type="totp"} -2
+otpgo_offset_steps_count{type="totp"} 2
+`
+
+	buf := &bytes.Buffer{}
+	if err := e.WriteText(buf); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	if actual := buf.String(); expected != actual {
+		t.Errorf("unexpected text\nexpected: %s\n  actual: %s", expected, actual)
+	}
+}
+
+func TestExpvar_ServeHTTP(t *testing.T) {
+	e := &Expvar{}
+
+	// Validate a real TOTP through the Observer.
+	totp := &otpgo.TOTP{Key: "73QK7D3A3PIZ6NUQQBF4BNFYQBRVUHUQ", Observer: Observer{Recorder: e}}
+	code, _ := totp.Generate()
+	_, _ = totp.Validate(code)
+
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if w.Header().Get("Content-Type") != "text/plain; version=0.0.4; charset=utf-8" {
+		t.Errorf("unexpected content type: %s", w.Header().Get("Content-Type"))
+	}
+
+	if !bytes.Contains(w.Body.Bytes(), []byte(`otpgo_validations_total{type="totp",result="success"} 1`)) {
+		t.Errorf("unexpected body:\n%s", w.Body.String())
+	}
+}
+
+func TestQuote(t *testing.T) {
+	if actual := quote("a\"b\\c\nd"); actual != `"a\"b\\c\nd"` {
+		t.Errorf("unexpected quoted value: %s", actual)
+	}
+}
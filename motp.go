@@ -0,0 +1,181 @@
+package otpgo
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jltorresm/otpgo/authenticator"
+)
+
+const (
+	// MOTPPeriod is the number of seconds a Mobile-OTP is valid, fixed by the
+	// algorithm.
+	MOTPPeriod = 10
+	// MOTPDefaultSkew is the default acceptable window, in steps, around the
+	// current time. A value of 18 accepts devices running up to 3 minutes off,
+	// like the reference implementation.
+	MOTPDefaultSkew = 18
+	// MOTPLength is the number of hex characters of a Mobile-OTP.
+	MOTPLength = 6
+)
+
+// The MOTP type used to generate Mobile-OTP codes, as used by legacy clients.
+// A code is the first 6 hex characters of the MD5 hash of the current 10
+// seconds step, the secret and the user's PIN.
+//
+// Mobile-OTP relies on MD5 and short codes, prefer TOTP for new credentials.
+type MOTP struct {
+	Key      string   `json:"key"`  // Secret shared with the device, usually 16 hex characters
+	PIN      string   `json:"pin"`  // PIN typed by the user on the device
+	Skew     int      `json:"skew"` // Acceptable steps in both directions
+	ID       string   `json:"-"`    // Identifies the credential in validation events
+	Observer Observer `json:"-"`    // Optional, notified of every validation
+}
+
+// Generate a Mobile-OTP for the current time.
+func (m *MOTP) Generate() (string, error) {
+	if m.Key == "" {
+		return "", ErrorInvalidConfig{msg: "missing secret key"}
+	}
+
+	return generateMOTP(m.Key, m.PIN, m.getCounter(time.Now().Unix())), nil
+}
+
+// Validate will try to check if the provided token is a valid Mobile-OTP for
+// the current time, within Skew steps. Hex characters are accepted in any case.
+func (m *MOTP) Validate(token string) (bool, error) {
+	valid, reason, offset, err := m.validate(token)
+	notify(m.Observer, outcome(m.ID, TypeMOTP, valid, reason, int64(offset)))
+
+	return valid, err
+}
+
+// validate does the work of Validate without notifying the Observer, returning
+// the reason of a failure or the offset of the matching step.
+func (m *MOTP) validate(token string) (valid bool, reason string, offset int, err error) {
+	// This will be the base for all validations
+	now := time.Now().Unix()
+
+	if m.Key == "" {
+		return false, ReasonError, 0, errors.New("missing secret key for validation")
+	}
+
+	token = strings.ToLower(token)
+	if !wellFormedHex(token, MOTPLength) {
+		return false, ReasonInvalidFormat, 0, nil
+	}
+
+	offset, ok := m.match(now, token)
+	if !ok {
+		return false, ReasonInvalidToken, 0, nil
+	}
+
+	return true, "", offset, nil
+}
+
+// GenerateContext is like Generate, but fails with the context error if it is
+// already done.
+func (m *MOTP) GenerateContext(ctx context.Context) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	return m.Generate()
+}
+
+// ValidateContext is like Validate, but fails with the context error if it is
+// already done.
+func (m *MOTP) ValidateContext(ctx context.Context, token string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		notify(m.Observer, failure(m.ID, TypeMOTP, ReasonError))
+		return false, err
+	}
+
+	return m.Validate(token)
+}
+
+// match checks the token against the steps within the skew window, returning
+// the offset of the matching one.
+func (m *MOTP) match(timestamp int64, token string) (int, bool) {
+	skew := m.Skew
+	if skew <= 0 {
+		skew = MOTPDefaultSkew
+	}
+
+	counter := m.getCounter(timestamp)
+	for step := 0; step <= skew; step++ {
+		if tokensEqual(generateMOTP(m.Key, m.PIN, counter-int64(step)), token) {
+			return -step, true
+		}
+
+		if step != 0 && tokensEqual(generateMOTP(m.Key, m.PIN, counter+int64(step)), token) {
+			return step, true
+		}
+	}
+
+	return 0, false
+}
+
+// KeyUri return an authenticator.KeyUri configured with the current MOTP
+// params, the PIN is never included. Mobile-OTP key URIs are not supported by
+// the common authenticator apps.
+func (m *MOTP) KeyUri(accountName, issuer string) *authenticator.KeyUri {
+	return &authenticator.KeyUri{
+		Type: TypeMOTP,
+		Label: authenticator.Label{
+			AccountName: accountName,
+			Issuer:      issuer,
+		},
+		Parameters: m,
+	}
+}
+
+// Type returns the OTP type, TypeMOTP.
+func (m *MOTP) Type() string {
+	return TypeMOTP
+}
+
+// AsUrlValues returns the MOTP parameters represented as url.Values.
+func (m *MOTP) AsUrlValues(issuer string) url.Values {
+	params := url.Values{}
+	params.Add("secret", m.Key)
+	params.Add("period", strconv.Itoa(MOTPPeriod))
+	params.Add("digits", strconv.Itoa(MOTPLength))
+	params.Add("issuer", issuer)
+
+	return params
+}
+
+// getCounter returns the step of the given timestamp.
+func (m *MOTP) getCounter(timestamp int64) int64 {
+	return timestamp / MOTPPeriod
+}
+
+// generateMOTP returns the Mobile-OTP for the given step.
+func generateMOTP(key, pin string, counter int64) string {
+	sum := md5.Sum([]byte(strconv.FormatInt(counter, 10) + key + pin))
+
+	return hex.EncodeToString(sum[:])[:MOTPLength]
+}
+
+// wellFormedHex tells whether the token has the given number of lowercase hex
+// characters.
+func wellFormedHex(token string, length int) bool {
+	if len(token) != length {
+		return false
+	}
+
+	for _, c := range token {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,102 @@
+package otpgo
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGenerateMOTP(t *testing.T) {
+	cases := []struct {
+		key, pin  string
+		timestamp int64
+		expected  string
+	}{
+		{"1234567890abcdef", "1234", 1603180800, "02c21f"},
+		{"1234567890abcdef", "1234", 1603180809, "02c21f"},
+		{"1234567890abcdef", "1234", 1603180810, "da37f7"},
+		{"a1b2c3d4e5f60718", "0000", 0, "99ac4f"},
+		{"a1b2c3d4e5f60718", "9876", 1234567890, "af7a15"},
+	}
+
+	m := &MOTP{}
+	for _, c := range cases {
+		if actual := generateMOTP(c.key, c.pin, m.getCounter(c.timestamp)); c.expected != actual {
+			t.Errorf("unexpected motp for %d\nexpected: %s\n  actual: %s", c.timestamp, c.expected, actual)
+		}
+	}
+}
+
+func TestMOTP_Generate(t *testing.T) {
+	m := &MOTP{Key: "1234567890abcdef", PIN: "1234"}
+
+	expected := generateMOTP(m.Key, m.PIN, time.Now().Unix()/MOTPPeriod)
+	if actual, err := m.Generate(); err != nil || expected != actual {
+		t.Errorf("unexpected motp\nexpected: %s\n  actual: %s (%v)", expected, actual, err)
+	}
+
+	if _, err := (&MOTP{}).Generate(); err != (ErrorInvalidConfig{msg: "missing secret key"}) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestMOTP_Validate(t *testing.T) {
+	m := &MOTP{Key: "1234567890abcdef", PIN: "1234", Skew: 3}
+	counter := time.Now().Unix() / MOTPPeriod
+	codeAt := func(offset int64) string {
+		return generateMOTP(m.Key, m.PIN, counter+offset)
+	}
+
+	cases := []struct {
+		label         string
+		token         string
+		expectedValid bool
+	}{
+		{"Current", codeAt(0), true},
+		{"Upper Case", strings.ToUpper(codeAt(0)), true},
+		{"Past", codeAt(-2), true},
+		{"Future", codeAt(2), true},
+		{"Too Far Past", codeAt(-5), false},
+		{"Too Far Future", codeAt(5), false},
+		{"Other PIN", generateMOTP(m.Key, "4321", counter), false},
+		{"Not Hex", "zzzzzz", false},
+		{"Too Short", "abc", false},
+	}
+
+	for _, c := range cases {
+		valid, err := m.Validate(c.token)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", c.label, err)
+		}
+
+		if valid != c.expectedValid {
+			t.Errorf("%s: unexpected validation\nexpected: %v\n  actual: %v", c.label, c.expectedValid, valid)
+		}
+	}
+
+	if _, err := (&MOTP{}).Validate(codeAt(0)); err == nil {
+		t.Error("expected error for missing key")
+	}
+}
+
+func TestMOTP_DefaultSkew(t *testing.T) {
+	m := &MOTP{Key: "1234567890abcdef", PIN: "1234"}
+	counter := time.Now().Unix() / MOTPPeriod
+
+	if valid, _ := m.Validate(generateMOTP(m.Key, m.PIN, counter-MOTPDefaultSkew+1)); !valid {
+		t.Error("expected code within the default skew to be valid")
+	}
+
+	if valid, _ := m.Validate(generateMOTP(m.Key, m.PIN, counter+MOTPDefaultSkew+2)); valid {
+		t.Error("expected code outside the default skew to be invalid")
+	}
+}
+
+func TestMOTP_KeyUri(t *testing.T) {
+	m := &MOTP{Key: "1234567890abcdef", PIN: "1234"}
+
+	expected := "otpauth://motp/Acme:john?digits=6&issuer=Acme&period=10&secret=1234567890abcdef"
+	if actual := m.KeyUri("john", "Acme").String(); expected != actual {
+		t.Errorf("unexpected key uri\nexpected: %s\n  actual: %s", expected, actual)
+	}
+}
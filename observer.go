@@ -0,0 +1,93 @@
+package otpgo
+
+import (
+	"time"
+)
+
+// Reasons given in the Event of a failed validation.
+const (
+	// ReasonInvalidToken means the token doesn't match any accepted code.
+	ReasonInvalidToken = "invalid_token"
+	// ReasonInvalidFormat means the token doesn't have the expected number of
+	// digits, so it was rejected without being checked.
+	ReasonInvalidFormat = "invalid_format"
+	// ReasonReplay means the token matches a code that was already used.
+	ReasonReplay = "replay"
+	// ReasonThrottled means the attempt was blocked by a rate limiter.
+	ReasonThrottled = "throttled"
+	// ReasonError means the validation couldn't be completed, e.g.: because of
+	// an invalid key or a store failure.
+	ReasonError = "error"
+)
+
+// The Event type describes the outcome of a validation, for auditing. It never
+// holds the secret key nor the validated token.
+type Event struct {
+	Time         time.Time `json:"time"`
+	CredentialID string    `json:"credentialId,omitempty"`
+	Type         string    `json:"type"` // OTP type, e.g.: TypeTOTP
+	Success      bool      `json:"success"`
+	Offset       int64     `json:"offset"` // Steps between the expected code and the matching one
+	Reason       string    `json:"reason,omitempty"`
+	Generation   string    `json:"generation,omitempty"` // Secret of a Rotating credential that matched
+}
+
+// The Observer interface is notified of every validation by the OTP types that
+// hold one, e.g.: HOTP.Observer. Observe is called synchronously, so
+// implementations should return quickly and must be safe for concurrent use.
+type Observer interface {
+	Observe(e Event)
+}
+
+// notify sends the event to the observer, if any, stamped with the current
+// time.
+func notify(o Observer, e Event) {
+	if o == nil {
+		return
+	}
+
+	e.Time = time.Now().UTC()
+	o.Observe(e)
+}
+
+// Notify sends the event to the observer, if any, stamped with the current
+// time, like the OTP types of this package do. It is meant for the OTP types
+// implemented outside of it.
+func Notify(o Observer, e Event) {
+	notify(o, e)
+}
+
+// failure returns the event for a failed validation.
+func failure(id, otpType, reason string) Event {
+	return Event{CredentialID: id, Type: otpType, Reason: reason}
+}
+
+// success returns the event for a successful validation.
+func success(id, otpType string, offset int64) Event {
+	return Event{CredentialID: id, Type: otpType, Success: true, Offset: offset}
+}
+
+// outcome returns the event for a validation, the offset of a successful one
+// or the reason of a failure.
+func outcome(id, otpType string, valid bool, reason string, offset int64) Event {
+	if valid {
+		return success(id, otpType, offset)
+	}
+
+	return failure(id, otpType, reason)
+}
+
+// wellFormed tells whether the token has the given number of digits.
+func wellFormed(token string, digits int) bool {
+	if len(token) != digits {
+		return false
+	}
+
+	for _, c := range token {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+
+	return true
+}
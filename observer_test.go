@@ -0,0 +1,149 @@
+package otpgo
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jltorresm/otpgo/config"
+)
+
+// eventRecorder is an Observer that keeps every event.
+type eventRecorder struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+func (r *eventRecorder) Observe(e Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	e.Time = time.Time{}
+	r.events = append(r.events, e)
+}
+
+func (r *eventRecorder) last() Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.events) == 0 {
+		return Event{}
+	}
+
+	return r.events[len(r.events)-1]
+}
+
+func TestHOTP_Observer(t *testing.T) {
+	events := &eventRecorder{}
+	h := &HOTP{Key: "73QK7D3A3PIZ6NUQQBF4BNFYQBRVUHUP", Counter: 362, Algorithm: config.HmacSHA256, ID: "john", Observer: events}
+
+	cases := []struct {
+		label    string
+		token    string
+		expected Event
+	}{
+		{"Look Ahead", "363033", success("john", TypeHOTP, 1)},
+		{"Wrong", "000000", failure("john", TypeHOTP, ReasonInvalidToken)},
+		{"Short", "12345", failure("john", TypeHOTP, ReasonInvalidFormat)},
+		{"Letters", "12345a", failure("john", TypeHOTP, ReasonInvalidFormat)},
+	}
+
+	for _, c := range cases {
+		_, _ = h.Validate(c.token)
+		if actual := events.last(); c.expected != actual {
+			t.Errorf("%s: unexpected event\nexpected: %+v\n  actual: %+v", c.label, c.expected, actual)
+		}
+	}
+
+	h.Key = "not-base-32"
+	_, _ = h.Validate("123456")
+	if expected := failure("john", TypeHOTP, ReasonError); events.last() != expected {
+		t.Errorf("unexpected event\nexpected: %+v\n  actual: %+v", expected, events.last())
+	}
+}
+
+func TestTOTP_Observer(t *testing.T) {
+	events := &eventRecorder{}
+	totp := &TOTP{Key: "73QK7D3A3PIZ6NUQQBF4BNFYQBRVUHUQ", Observer: events}
+
+	code, _ := totp.Generate()
+	_, _ = totp.Validate(code)
+	if expected := success("", TypeTOTP, 0); events.last() != expected {
+		t.Errorf("unexpected event\nexpected: %+v\n  actual: %+v", expected, events.last())
+	}
+
+	totp.Key = ""
+	_, _ = totp.Validate(code)
+	if expected := failure("", TypeTOTP, ReasonError); events.last() != expected {
+		t.Errorf("unexpected event\nexpected: %+v\n  actual: %+v", expected, events.last())
+	}
+}
+
+func TestSyncHOTP_Observer(t *testing.T) {
+	events := &eventRecorder{}
+	s := &SyncHOTP{ID: "john", HOTP: HOTP{Key: "73QK7D3A3PIZ6NUQQBF4BNFYQBRVUHUP", Counter: 363, Algorithm: config.HmacSHA256}, Store: &MemoryCounterStore{}}
+	s.HOTP.Observer = events
+
+	cases := []struct {
+		label    string
+		token    string
+		expected Event
+	}{
+		{"Valid", "363033", success("john", TypeHOTP, 0)},
+		{"Replay", "363033", failure("john", TypeHOTP, ReasonReplay)},
+		{"Look Ahead", codeAt(t, s, 365), success("john", TypeHOTP, 1)},
+		{"Wrong", "000000", failure("john", TypeHOTP, ReasonInvalidToken)},
+		{"Format", "0", failure("john", TypeHOTP, ReasonInvalidFormat)},
+	}
+
+	for _, c := range cases {
+		_, _ = s.Validate(c.token)
+		if actual := events.last(); c.expected != actual {
+			t.Errorf("%s: unexpected event\nexpected: %+v\n  actual: %+v", c.label, c.expected, actual)
+		}
+	}
+}
+
+func TestDriftTOTP_Observer(t *testing.T) {
+	events := &eventRecorder{}
+	now := time.Date(2020, 10, 20, 8, 0, 0, 0, time.UTC)
+	d := &DriftTOTP{ID: "john", TOTP: TOTP{Key: "73QK7D3A3PIZ6NUQQBF4BNFYQBRVUHUQ", Algorithm: config.HmacSHA256}, Store: &MemoryDriftStore{}, MaxDrift: 3, Now: func() time.Time { return now }}
+	d.TOTP.Observer = events
+
+	cases := []struct {
+		label    string
+		token    string
+		expected Event
+	}{
+		{"Slow", driftCodeAt(t, d, -1), success("john", TypeTOTP, -1)},
+		{"Slower", driftCodeAt(t, d, -2), success("john", TypeTOTP, -2)},
+		{"Wrong", "000000", failure("john", TypeTOTP, ReasonInvalidToken)},
+	}
+
+	for _, c := range cases {
+		_, _ = d.Validate(c.token)
+		if actual := events.last(); c.expected != actual {
+			t.Errorf("%s: unexpected event\nexpected: %+v\n  actual: %+v", c.label, c.expected, actual)
+		}
+	}
+}
+
+func TestWellFormed(t *testing.T) {
+	cases := []struct {
+		token    string
+		digits   int
+		expected bool
+	}{
+		{"123456", 6, true},
+		{"12345678", 8, true},
+		{"1234567", 6, false},
+		{"12 456", 6, false},
+		{"", 6, false},
+	}
+
+	for _, c := range cases {
+		if actual := wellFormed(c.token, c.digits); c.expected != actual {
+			t.Errorf("unexpected result for %q\nexpected: %v\n  actual: %v", c.token, c.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package otpgo
+
+import (
+	"fmt"
+
+	"github.com/jltorresm/otpgo/config"
+)
+
+// The Option type customizes the OTPs built by NewHOTP and NewTOTP.
+type Option func(o *options) error
+
+// The options type collects the parameters given to a constructor, so that
+// they can be validated before building the OTP.
+type options struct {
+	key       string
+	counter   *uint64
+	period    *int
+	skew      *int
+	window    *[2]int
+	algorithm config.HmacAlgorithm
+	length    config.Length
+}
+
+// WithKey sets the secret, base32 encoded.
+func WithKey(key string) Option {
+	return func(o *options) error {
+		if key == "" {
+			return ErrorInvalidConfig{msg: "key must not be empty"}
+		}
+
+		if _, err := decodeKey(key); err != nil {
+			return err
+		}
+
+		o.key = key
+
+		return nil
+	}
+}
+
+// WithRandomKey sets a new random secret of RandomKeyLength bytes. It must be
+// asked for explicitly, so that a missing key is never replaced by accident.
+func WithRandomKey() Option {
+	return func(o *options) (err error) {
+		o.key, err = randomKey(RandomKeyLength)
+		return err
+	}
+}
+
+// WithCounter sets the HOTP counter. Not supported by TOTP.
+func WithCounter(counter uint64) Option {
+	return func(o *options) error {
+		o.counter = &counter
+		return nil
+	}
+}
+
+// WithPeriod sets the TOTP period in seconds. Not supported by HOTP.
+func WithPeriod(seconds int) Option {
+	return func(o *options) error {
+		if seconds < 1 {
+			return ErrorInvalidConfig{msg: fmt.Sprintf("period must be at least 1 second, got %d", seconds)}
+		}
+
+		o.period = &seconds
+
+		return nil
+	}
+}
+
+// WithSkew sets the number of steps before and after the expected one that
+// are accepted during validation, the HOTP Leeway or TOTP Delay. Unlike the
+// zero value of those fields, a skew of 0 only accepts the expected code.
+func WithSkew(steps int) Option {
+	return func(o *options) error {
+		if steps < 0 {
+			return ErrorInvalidConfig{msg: fmt.Sprintf("skew must not be negative, got %d", steps)}
+		}
+
+		o.skew = &steps
+
+		return nil
+	}
+}
+
+// WithSkewWindow sets the number of steps before and after the expected one
+// that are accepted during validation, the HOTP LookBehind and LookAhead or
+// TOTP PastSkew and FutureSkew. It takes precedence over WithSkew.
+func WithSkewWindow(past, future int) Option {
+	return func(o *options) error {
+		if past < 0 || future < 0 {
+			return ErrorInvalidConfig{msg: fmt.Sprintf("skew must not be negative, got %d and %d", past, future)}
+		}
+
+		o.window = &[2]int{past, future}
+
+		return nil
+	}
+}
+
+// WithAlgorithm sets the hash algorithm.
+func WithAlgorithm(algorithm config.HmacAlgorithm) Option {
+	return func(o *options) error {
+		if algorithm < config.HmacSHA1 || algorithm > config.HmacSHA512 {
+			return ErrorInvalidConfig{msg: fmt.Sprintf("unknown hash algorithm %d", algorithm)}
+		}
+
+		o.algorithm = algorithm
+
+		return nil
+	}
+}
+
+// WithDigits sets the length of the codes, between 1 and 8 digits.
+func WithDigits(digits int) Option {
+	return func(o *options) error {
+		if digits < int(config.Length1) || digits > int(config.Length8) {
+			return ErrorInvalidConfig{msg: fmt.Sprintf("digits must be between 1 and 8, got %d", digits)}
+		}
+
+		o.length = config.Length(digits)
+
+		return nil
+	}
+}
+
+// apply runs the options and checks that a key was given, the defaults are
+// used for any parameter that was not set.
+func (o *options) apply(opts []Option) error {
+	for _, opt := range opts {
+		if err := opt(o); err != nil {
+			return err
+		}
+	}
+
+	if o.key == "" {
+		return ErrorInvalidConfig{msg: "missing key, use WithKey or WithRandomKey"}
+	}
+
+	if o.algorithm == 0 {
+		o.algorithm = config.HmacSHA1
+	}
+
+	if o.length == 0 {
+		o.length = config.Length6
+	}
+
+	return nil
+}
+
+// NewHOTP returns an HOTP built from the given options, which are validated up
+// front. Its Generate and Validate never modify the configuration, only the
+// Counter is advanced by a successful validation.
+func NewHOTP(opts ...Option) (*HOTP, error) {
+	o := &options{}
+	if err := o.apply(opts); err != nil {
+		return nil, err
+	}
+
+	if o.period != nil {
+		return nil, ErrorInvalidConfig{msg: "period is not supported by hotp"}
+	}
+
+	h := &HOTP{Key: o.key, Leeway: HOTPDefaultLeeway, Algorithm: o.algorithm, Length: o.length, frozen: true}
+
+	if o.counter != nil {
+		h.Counter = *o.counter
+	}
+
+	if o.skew != nil {
+		h.Leeway = uint64(*o.skew)
+	}
+
+	// An empty window means no skew at all, and not the symmetric Leeway.
+	if w := o.window; w != nil && w[0] == 0 && w[1] == 0 {
+		h.Leeway = 0
+	} else if w != nil {
+		h.LookBehind, h.LookAhead = uint64(w[0]), uint64(w[1])
+	}
+
+	return h, nil
+}
+
+// NewTOTP returns a TOTP built from the given options, which are validated up
+// front. Its Generate and Validate never modify it, so it is safe for
+// concurrent use.
+func NewTOTP(opts ...Option) (*TOTP, error) {
+	o := &options{}
+	if err := o.apply(opts); err != nil {
+		return nil, err
+	}
+
+	if o.counter != nil {
+		return nil, ErrorInvalidConfig{msg: "counter is not supported by totp"}
+	}
+
+	t := &TOTP{Key: o.key, Period: TOTPDefaultPeriod, Delay: TOTPDefaultDelay, Algorithm: o.algorithm, Length: o.length, frozen: true}
+
+	if o.period != nil {
+		t.Period = *o.period
+	}
+
+	if o.skew != nil {
+		t.Delay = *o.skew
+	}
+
+	// An empty window means no skew at all, and not the symmetric Delay.
+	if w := o.window; w != nil && w[0] == 0 && w[1] == 0 {
+		t.Delay = 0
+	} else if w != nil {
+		t.PastSkew, t.FutureSkew = w[0], w[1]
+	}
+
+	return t, nil
+}
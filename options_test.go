@@ -0,0 +1,192 @@
+package otpgo
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/jltorresm/otpgo/config"
+)
+
+func TestNewHOTP(t *testing.T) {
+	cases := []struct {
+		label       string
+		opts        []Option
+		expected    HOTP
+		expectedErr error
+	}{
+		{
+			"Defaults",
+			[]Option{WithKey("73QK7D3A3PIZ6NUQQBF4BNFYQBRVUHUP")},
+			HOTP{Key: "73QK7D3A3PIZ6NUQQBF4BNFYQBRVUHUP", Leeway: 1, Algorithm: config.HmacSHA1, Length: config.Length6, frozen: true},
+			nil,
+		},
+		{
+			"Custom",
+			[]Option{WithKey("73QK7D3A3PIZ6NUQQBF4BNFYQBRVUHUP"), WithCounter(363), WithSkew(0), WithAlgorithm(config.HmacSHA256), WithDigits(8)},
+			HOTP{Key: "73QK7D3A3PIZ6NUQQBF4BNFYQBRVUHUP", Counter: 363, Leeway: 0, Algorithm: config.HmacSHA256, Length: config.Length8, frozen: true},
+			nil,
+		},
+		{
+			"Skew Window",
+			[]Option{WithKey("73QK7D3A3PIZ6NUQQBF4BNFYQBRVUHUP"), WithSkewWindow(0, 5)},
+			HOTP{Key: "73QK7D3A3PIZ6NUQQBF4BNFYQBRVUHUP", Leeway: 1, LookAhead: 5, Algorithm: config.HmacSHA1, Length: config.Length6, frozen: true},
+			nil,
+		},
+		{"Missing Key", nil, HOTP{}, ErrorInvalidConfig{msg: "missing key, use WithKey or WithRandomKey"}},
+		{"Empty Key", []Option{WithKey("")}, HOTP{}, ErrorInvalidConfig{msg: "key must not be empty"}},
+		{"Bad Key", []Option{WithKey("not-base-32")}, HOTP{}, ErrorInvalidKey{msg: "illegal base32 data at input byte 3"}},
+		{"Period", []Option{WithKey("ABCD"), WithPeriod(30)}, HOTP{}, ErrorInvalidConfig{msg: "period is not supported by hotp"}},
+		{"Negative Skew", []Option{WithSkew(-1)}, HOTP{}, ErrorInvalidConfig{msg: "skew must not be negative, got -1"}},
+		{"Bad Algorithm", []Option{WithAlgorithm(7)}, HOTP{}, ErrorInvalidConfig{msg: "unknown hash algorithm 7"}},
+		{"Bad Digits", []Option{WithDigits(9)}, HOTP{}, ErrorInvalidConfig{msg: "digits must be between 1 and 8, got 9"}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.label, func(t *testing.T) {
+			h, err := NewHOTP(c.opts...)
+			if err != c.expectedErr {
+				t.Errorf("unexpected error\nexpected: %v\n  actual: %v", c.expectedErr, err)
+			}
+
+			if err == nil && *h != c.expected {
+				t.Errorf("unexpected hotp\nexpected: %+v\n  actual: %+v", c.expected, *h)
+			}
+		})
+	}
+}
+
+func TestNewTOTP(t *testing.T) {
+	cases := []struct {
+		label       string
+		opts        []Option
+		expected    TOTP
+		expectedErr error
+	}{
+		{
+			"Defaults",
+			[]Option{WithKey("73QK7D3A3PIZ6NUQQBF4BNFYQBRVUHUP")},
+			TOTP{Key: "73QK7D3A3PIZ6NUQQBF4BNFYQBRVUHUP", Period: 30, Delay: 1, Algorithm: config.HmacSHA1, Length: config.Length6, frozen: true},
+			nil,
+		},
+		{
+			"Custom",
+			[]Option{WithKey("73QK7D3A3PIZ6NUQQBF4BNFYQBRVUHUP"), WithPeriod(60), WithSkew(2), WithAlgorithm(config.HmacSHA512), WithDigits(7)},
+			TOTP{Key: "73QK7D3A3PIZ6NUQQBF4BNFYQBRVUHUP", Period: 60, Delay: 2, Algorithm: config.HmacSHA512, Length: config.Length7, frozen: true},
+			nil,
+		},
+		{
+			"Skew Window",
+			[]Option{WithKey("73QK7D3A3PIZ6NUQQBF4BNFYQBRVUHUP"), WithSkewWindow(1, 0)},
+			TOTP{Key: "73QK7D3A3PIZ6NUQQBF4BNFYQBRVUHUP", Period: 30, Delay: 1, PastSkew: 1, Algorithm: config.HmacSHA1, Length: config.Length6, frozen: true},
+			nil,
+		},
+		{
+			"Empty Skew Window",
+			[]Option{WithKey("73QK7D3A3PIZ6NUQQBF4BNFYQBRVUHUP"), WithSkewWindow(0, 0)},
+			TOTP{Key: "73QK7D3A3PIZ6NUQQBF4BNFYQBRVUHUP", Period: 30, Algorithm: config.HmacSHA1, Length: config.Length6, frozen: true},
+			nil,
+		},
+		{"Negative Skew Window", []Option{WithSkewWindow(1, -1)}, TOTP{}, ErrorInvalidConfig{msg: "skew must not be negative, got 1 and -1"}},
+		{"Counter", []Option{WithKey("ABCD"), WithCounter(1)}, TOTP{}, ErrorInvalidConfig{msg: "counter is not supported by totp"}},
+		{"Bad Period", []Option{WithPeriod(0)}, TOTP{}, ErrorInvalidConfig{msg: "period must be at least 1 second, got 0"}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.label, func(t *testing.T) {
+			totp, err := NewTOTP(c.opts...)
+			if err != c.expectedErr {
+				t.Errorf("unexpected error\nexpected: %v\n  actual: %v", c.expectedErr, err)
+			}
+
+			if err == nil && *totp != c.expected {
+				t.Errorf("unexpected totp\nexpected: %+v\n  actual: %+v", c.expected, *totp)
+			}
+		})
+	}
+}
+
+func TestWithRandomKey(t *testing.T) {
+	a, errA := NewTOTP(WithRandomKey())
+	b, errB := NewTOTP(WithRandomKey())
+	if errA != nil || errB != nil {
+		t.Errorf("unexpected errors: %v, %v", errA, errB)
+		t.FailNow()
+	}
+
+	if a.Key == "" || a.Key == b.Key {
+		t.Errorf("expected distinct random keys, got %q and %q", a.Key, b.Key)
+	}
+}
+
+func TestConstructed_EmptyKey(t *testing.T) {
+	h, _ := NewHOTP(WithKey("73QK7D3A3PIZ6NUQQBF4BNFYQBRVUHUP"))
+	h.Key = ""
+
+	totp, _ := NewTOTP(WithKey("73QK7D3A3PIZ6NUQQBF4BNFYQBRVUHUP"))
+	totp.Key = ""
+
+	cases := []struct {
+		label    string
+		generate func() (string, error)
+	}{
+		{"HOTP", h.Generate},
+		{"TOTP", totp.Generate},
+		{"TOTP Transaction", func() (string, error) { return totp.GenerateTransaction(Transaction{"amount": "1"}) }},
+	}
+
+	for _, c := range cases {
+		code, err := c.generate()
+		if reflect.TypeOf(err) != reflect.TypeOf(ErrorInvalidConfig{}) {
+			t.Errorf("case %s: unexpected error\nexpected: %T\n  actual: %T", c.label, ErrorInvalidConfig{}, err)
+		}
+
+		if code != "" {
+			t.Errorf("case %s: unexpected code %q", c.label, code)
+		}
+	}
+
+	if h.Key != "" || totp.Key != "" {
+		t.Error("expected no key to be generated")
+	}
+}
+
+func TestConstructed_NoMutation(t *testing.T) {
+	h, _ := NewHOTP(WithKey("73QK7D3A3PIZ6NUQQBF4BNFYQBRVUHUP"), WithCounter(363), WithSkew(0), WithAlgorithm(config.HmacSHA256))
+	before := *h
+
+	if otp, err := h.Generate(); otp != "363033" || err != nil {
+		t.Errorf("unexpected result: %s, %v", otp, err)
+	}
+
+	if *h != before {
+		t.Errorf("unexpected change\nexpected: %+v\n  actual: %+v", before, *h)
+	}
+
+	// A skew of 0 is kept, only the exact counter is accepted.
+	h.Counter = 364
+	if ok, _ := h.Validate("363033"); ok {
+		t.Error("expected the previous counter to be rejected")
+	}
+
+	totp, _ := NewTOTP(WithKey("73QK7D3A3PIZ6NUQQBF4BNFYQBRVUHUP"), WithSkew(0))
+	expected := *totp
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			code, err := totp.Generate()
+			if err != nil {
+				t.Errorf("unexpected error: %s", err)
+			}
+			_, _ = totp.Validate(code)
+		}()
+	}
+	wg.Wait()
+
+	if *totp != expected {
+		t.Errorf("unexpected change\nexpected: %+v\n  actual: %+v", expected, *totp)
+	}
+}
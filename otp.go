@@ -3,6 +3,7 @@ package otpgo
 import (
 	"crypto/hmac"
 	"crypto/rand"
+	"crypto/subtle"
 	"encoding/base32"
 	"encoding/binary"
 	"strings"
@@ -19,41 +20,100 @@ var otpBase32Encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
 
 // Generates a new OTP using the specified parameters based on the rfc4226.
 func generateOTP(key string, counter uint64, length config.Length, algorithm config.HmacAlgorithm) (string, error) {
-	// Ensure key is uppercase
-	key = strings.ToUpper(key)
-
-	// Trim unnecessary paddings in case the key was generated externally.
-	key = strings.TrimRight(key, string(base32.StdPadding))
+	return generateOTPWithData(key, counter, nil, length, algorithm)
+}
 
+// Generates a new OTP like generateOTP, appending data to the counter in the
+// HMAC input so the OTP is bound to it.
+func generateOTPWithData(key string, counter uint64, data []byte, length config.Length, algorithm config.HmacAlgorithm) (string, error) {
 	// Decode secret key to bytes
-	k, err := otpBase32Encoding.DecodeString(key)
+	k, err := decodeKey(key)
 	if err != nil {
-		return "", ErrorInvalidKey{msg: err.Error()}
+		return "", err
+	}
+
+	sum, err := digest(k, counter, data, algorithm)
+	if err != nil {
+		return "", err
 	}
 
+	// Build the result integer
+	bin := int(DynamicTruncate(sum, 4))
+
+	rawOtp := length.Truncate(bin)
+	otp := length.LeftPad(rawOtp)
+
+	return otp, nil
+}
+
+// Digest returns the HMAC of the counter under the decoded key, the value
+// RFC 4226 truncates into a code, so that OTP variants can encode their codes
+// their own way.
+func Digest(key []byte, counter uint64, algorithm config.HmacAlgorithm) ([]byte, error) {
+	return digest(key, counter, nil, algorithm)
+}
+
+// Computes the HMAC of the counter followed by data.
+func digest(key []byte, counter uint64, data []byte, algorithm config.HmacAlgorithm) ([]byte, error) {
 	// Convert the counter to bytes
-	msg := make([]byte, 8)
+	msg := make([]byte, 8, 8+len(data))
 	binary.BigEndian.PutUint64(msg, counter)
+	msg = append(msg, data...)
 
 	// Start the hmac algorithm
-	hm := hmac.New(algorithm.Hash, k)
+	hm := hmac.New(algorithm.Hash, key)
 	if _, err := hm.Write(msg); err != nil {
-		return "", err
+		return nil, err
 	}
-	sum := hm.Sum([]byte{})
 
-	// Build the result integer
-	offset := sum[len(sum)-1] & 0xf
+	return hm.Sum([]byte{}), nil
+}
 
-	bin := ((int(sum[offset]) & 0x7f) << 24) |
-		((int(sum[offset+1]) & 0xff) << 16) |
-		((int(sum[offset+2]) & 0xff) << 8) |
-		(int(sum[offset+3]) & 0xff)
+// DynamicTruncate applies the RFC 4226 dynamic truncation to the digest: it
+// reads n bytes, at most 8, from the offset given by the last byte and clears
+// the first bit. RFC 4226 reads 4 bytes, reading 8 takes a digest of at least
+// 23 bytes, e.g.: SHA256.
+func DynamicTruncate(sum []byte, n int) uint64 {
+	offset := int(sum[len(sum)-1] & 0xf)
 
-	rawOtp := length.Truncate(bin)
-	otp := length.LeftPad(rawOtp)
+	var value uint64
+	for i := 0; i < n; i++ {
+		value = value<<8 | uint64(sum[offset+i])
+	}
 
-	return otp, nil
+	return value &^ (1 << (uint(n)*8 - 1))
+}
+
+// DecodeKey decodes a base32 secret key like the OTP types do, tolerating
+// lowercase letters and padding. An empty key is an ErrorInvalidKey.
+func DecodeKey(key string) ([]byte, error) {
+	if key == "" {
+		return nil, ErrorInvalidKey{msg: "missing secret"}
+	}
+
+	return decodeKey(key)
+}
+
+// Decodes a base32 key, tolerating lowercase letters and padding.
+func decodeKey(key string) ([]byte, error) {
+	// Ensure key is uppercase
+	key = strings.ToUpper(key)
+
+	// Trim unnecessary paddings in case the key was generated externally.
+	key = strings.TrimRight(key, string(base32.StdPadding))
+
+	k, err := otpBase32Encoding.DecodeString(key)
+	if err != nil {
+		return nil, ErrorInvalidKey{msg: err.Error()}
+	}
+
+	return k, nil
+}
+
+// RandomKey returns a new random secret of RandomKeyLength bytes, base32
+// encoded, e.g.: to create the key of a new credential explicitly.
+func RandomKey() (string, error) {
+	return randomKey(RandomKeyLength)
 }
 
 // Generates a random key of the specified length, usable for OTP generation.
@@ -65,3 +125,9 @@ func randomKey(length uint) (string, error) {
 
 	return otpBase32Encoding.EncodeToString(buff), nil
 }
+
+// Compares two tokens in constant time, to avoid leaking how many leading
+// digits of a guess are correct.
+func tokensEqual(expected, token string) bool {
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(token)) == 1
+}
@@ -33,6 +33,43 @@ func TestGenerateOTP(t *testing.T) {
 	}
 }
 
+func TestDynamicTruncate(t *testing.T) {
+	sum := []byte{0xff, 0xfe, 0xfd, 0xfc, 0xfb, 0xfa, 0xf9, 0xf8, 0xf7, 0x01}
+
+	if actual := DynamicTruncate(sum, 4); actual != 0x7efdfcfb {
+		t.Errorf("unexpected value\nexpected: %x\n  actual: %x", 0x7efdfcfb, actual)
+	}
+
+	if actual := DynamicTruncate(sum, 8); actual != 0x7efdfcfbfaf9f8f7 {
+		t.Errorf("unexpected value\nexpected: %x\n  actual: %x", uint64(0x7efdfcfbfaf9f8f7), actual)
+	}
+}
+
+func TestDecodeKey(t *testing.T) {
+	cases := []struct {
+		label       string
+		key         string
+		expected    []byte
+		expectedErr error
+	}{
+		{"Upper Case", "MFRGG", []byte("abc"), nil},
+		{"Lower Case Padded", "mfrgg===", []byte("abc"), nil},
+		{"Empty", "", nil, ErrorInvalidKey{msg: "missing secret"}},
+		{"Not Base32", "MFRG1", nil, ErrorInvalidKey{msg: "illegal base32 data at input byte 4"}},
+	}
+
+	for _, c := range cases {
+		actual, err := DecodeKey(c.key)
+		if err != c.expectedErr {
+			t.Errorf("case %s: unexpected error\nexpected: %v\n  actual: %v", c.label, c.expectedErr, err)
+		}
+
+		if string(c.expected) != string(actual) {
+			t.Errorf("case %s: unexpected key\nexpected: %x\n  actual: %x", c.label, c.expected, actual)
+		}
+	}
+}
+
 func TestRandomKey(t *testing.T) {
 	cases := []struct {
 		label          string
@@ -66,3 +103,43 @@ func TestRandomKey(t *testing.T) {
 		})
 	}
 }
+
+func TestRandomKey_Exported(t *testing.T) {
+	a, errA := RandomKey()
+	b, errB := RandomKey()
+	if errA != nil || errB != nil {
+		t.Errorf("unexpected errors: %v, %v", errA, errB)
+		t.FailNow()
+	}
+
+	raw, err := decodeKey(a)
+	if err != nil || len(raw) != RandomKeyLength {
+		t.Errorf("unexpected key %q: %d bytes, %v", a, len(raw), err)
+	}
+
+	if a == b {
+		t.Errorf("expected distinct random keys, got %q twice", a)
+	}
+}
+
+func TestTokensEqual(t *testing.T) {
+	cases := []struct {
+		label    string
+		expected string
+		token    string
+		equal    bool
+	}{
+		{"Equal", "123456", "123456", true},
+		{"Different", "123456", "123457", false},
+		{"Shorter", "123456", "12345", false},
+		{"Empty", "123456", "", false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.label, func(t *testing.T) {
+			if c.equal != tokensEqual(c.expected, c.token) {
+				t.Errorf("unexpected comparison of %s and %s, expected %v", c.expected, c.token, c.equal)
+			}
+		})
+	}
+}
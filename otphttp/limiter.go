@@ -0,0 +1,122 @@
+package otphttp
+
+import (
+	"sync"
+	"time"
+
+	"github.com/jltorresm/otpgo/internal/clock"
+)
+
+const (
+	// DefaultMaxFailures is the number of failed attempts tolerated by the
+	// MemoryLimiter before blocking a credential.
+	DefaultMaxFailures = 5
+	// DefaultLockout is how long the MemoryLimiter blocks a credential.
+	DefaultLockout = 5 * time.Minute
+)
+
+// The Limiter interface decides whether a credential may attempt a validation,
+// based on the outcome of the previous ones. Every attempt counts as a failure
+// from the moment it is allowed, so that concurrent attempts can't all get in
+// before the first failures are recorded.
+type Limiter interface {
+	// Allow reserves an attempt and reports whether it is permitted,
+	// otherwise how long to wait. The attempt is a failure unless Success is
+	// called with it.
+	Allow(id string) (attempt uint64, wait time.Duration, ok bool)
+	// Success releases the attempt, and forgets the failures recorded before
+	// it. The attempts allowed after it, e.g.: concurrent guesses, are still
+	// failures.
+	Success(id string, attempt uint64)
+}
+
+// The MemoryLimiter type blocks a credential for the Lockout duration after
+// MaxFailures consecutive attempts without success. It is safe for concurrent
+// use.
+type MemoryLimiter struct {
+	MaxFailures int              // Defaults to DefaultMaxFailures
+	Lockout     time.Duration    // Defaults to DefaultLockout
+	Now         func() time.Time // Defaults to time.Now
+
+	mu       sync.Mutex
+	attempts uint64 // Attempts allowed so far, numbering them
+	failures map[string]*failures
+}
+
+type failures struct {
+	count       int
+	last        uint64 // Last attempt allowed
+	lockedUntil time.Time
+}
+
+// Allow counts the attempt unless the credential is currently blocked, and
+// blocks it once the maximum is reached. The attempt reaching the maximum is
+// still allowed, a success lifts the block.
+func (ml *MemoryLimiter) Allow(id string) (uint64, time.Duration, bool) {
+	ml.mu.Lock()
+	defer ml.mu.Unlock()
+
+	if ml.failures == nil {
+		ml.failures = map[string]*failures{}
+	}
+
+	f, ok := ml.failures[id]
+	if !ok {
+		f = &failures{}
+		ml.failures[id] = f
+	}
+
+	now := clock.Now(ml.Now)
+	if wait := f.lockedUntil.Sub(now); wait > 0 {
+		return 0, wait, false
+	}
+
+	ml.attempts++
+	f.last = ml.attempts
+
+	f.count++
+	if f.count >= ml.maxFailures() {
+		f.count = 0
+		f.lockedUntil = now.Add(ml.lockout())
+	}
+
+	return f.last, 0, true
+}
+
+// Success forgets the failures of the credential when the attempt is the last
+// one allowed. Otherwise other attempts started meanwhile, so it only releases
+// its own and the credential stays blocked if they reached the maximum.
+func (ml *MemoryLimiter) Success(id string, attempt uint64) {
+	ml.mu.Lock()
+	defer ml.mu.Unlock()
+
+	f, ok := ml.failures[id]
+	if !ok {
+		return
+	}
+
+	if f.last == attempt {
+		delete(ml.failures, id)
+		return
+	}
+
+	if f.count > 0 {
+		f.count--
+	}
+}
+
+func (ml *MemoryLimiter) maxFailures() int {
+	if ml.MaxFailures <= 0 {
+		return DefaultMaxFailures
+	}
+
+	return ml.MaxFailures
+}
+
+func (ml *MemoryLimiter) lockout() time.Duration {
+	if ml.Lockout <= 0 {
+		return DefaultLockout
+	}
+
+	return ml.Lockout
+}
@@ -0,0 +1,89 @@
+package otphttp
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMemoryLimiter(t *testing.T) {
+	now := time.Date(2020, 9, 10, 12, 0, 0, 0, time.UTC)
+	ml := &MemoryLimiter{Now: func() time.Time { return now }}
+
+	for i := 0; i < DefaultMaxFailures; i++ {
+		if _, _, ok := ml.Allow("john"); !ok {
+			t.Errorf("expected attempt %d to be allowed before reaching the maximum", i+1)
+		}
+	}
+
+	_, wait, ok := ml.Allow("john")
+	if ok || wait != DefaultLockout {
+		t.Errorf("unexpected result\nexpected: %s, false\n  actual: %s, %v", DefaultLockout, wait, ok)
+	}
+
+	if _, _, ok := ml.Allow("jane"); !ok {
+		t.Error("expected other credentials not to be blocked")
+	}
+
+	now = now.Add(DefaultLockout)
+	attempt, _, ok := ml.Allow("john")
+	if !ok {
+		t.Error("expected attempts to be allowed after the lockout")
+	}
+
+	ml.Success("john", attempt)
+	for i := 0; i < DefaultMaxFailures; i++ {
+		if _, _, ok := ml.Allow("john"); !ok {
+			t.Error("expected a success to reset the failures")
+		}
+	}
+}
+
+func TestMemoryLimiter_SuccessAfterOtherAttempts(t *testing.T) {
+	ml := &MemoryLimiter{MaxFailures: 5}
+
+	_, _, _ = ml.Allow("john")
+	attempt, _, _ := ml.Allow("john")
+
+	// A guess made while the attempt is validated keeps the failures, the
+	// success only releases its own attempt.
+	_, _, _ = ml.Allow("john")
+	ml.Success("john", attempt)
+
+	// Two failures are kept, so three attempts are left until the block.
+	for i := 0; i < 3; i++ {
+		if _, _, ok := ml.Allow("john"); !ok {
+			t.Errorf("expected attempt %d to be allowed before reaching the maximum", i+1)
+		}
+	}
+
+	if _, _, ok := ml.Allow("john"); ok {
+		t.Error("expected the failures to be kept")
+	}
+}
+
+func TestMemoryLimiter_Concurrent(t *testing.T) {
+	ml := &MemoryLimiter{MaxFailures: 3}
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		allowed int
+	)
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, _, ok := ml.Allow("john"); ok {
+				mu.Lock()
+				allowed++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if allowed != 3 {
+		t.Errorf("unexpected allowed attempts\nexpected: %d\n  actual: %d", 3, allowed)
+	}
+}
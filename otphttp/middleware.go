@@ -0,0 +1,191 @@
+// Package otphttp provides net/http middleware that requires a valid OTP as a
+// second authentication factor.
+package otphttp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jltorresm/otpgo"
+)
+
+const (
+	// DefaultHeader is the request header checked for the token.
+	DefaultHeader = "X-OTP"
+	// DefaultField is the form field checked for the token when the header is
+	// missing.
+	DefaultField = "otp"
+)
+
+// ErrNoCredential must be returned by a Resolver when the user making the
+// request has no OTP configured.
+var ErrNoCredential = errors.New("no otp credential")
+
+// The Validator interface is satisfied by any otpgo.OTP.
+type Validator interface {
+	Validate(token string) (bool, error)
+}
+
+// The ContextValidator interface can be implemented by a Validator to receive
+// the request context, e.g.: otpgo.SyncHOTP, so the validation is abandoned
+// when the client goes away or the request times out.
+type ContextValidator interface {
+	ValidateContext(ctx context.Context, token string) (bool, error)
+}
+
+// The Resolver interface looks up the OTP configuration for the user making
+// the request, usually based on an already established session.
+type Resolver interface {
+	// Resolve returns an identifier for the credential, used for rate limiting,
+	// and its Validator.
+	Resolve(r *http.Request) (id string, v Validator, err error)
+}
+
+// The Committer interface can be implemented by a Resolver to persist the
+// Validator after a successful validation, e.g.: the new HOTP counter.
+type Committer interface {
+	Commit(r *http.Request, id string, v Validator) error
+}
+
+// The Middleware type checks the OTP sent with each request before calling the
+// wrapped handler. Failed attempts are answered with a JSON error and status
+// 401, or 429 once the Limiter blocks the credential.
+type Middleware struct {
+	Resolver Resolver
+	Limiter  Limiter        // Optional, no rate limiting when nil
+	Observer otpgo.Observer // Optional, notified of the attempts blocked by the Limiter
+	Header   string         // Defaults to DefaultHeader
+	Field    string         // Defaults to DefaultField
+}
+
+type contextKey struct{}
+
+// CredentialID returns the id of the credential validated by the Middleware
+// for the request context.
+func CredentialID(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(contextKey{}).(string)
+	return id, ok
+}
+
+// Wrap returns a handler that only calls next when the request holds a valid
+// OTP.
+func (m *Middleware) Wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := m.token(r)
+		if token == "" {
+			writeError(w, http.StatusUnauthorized, "missing_token", "an otp is required")
+			return
+		}
+
+		id, v, err := m.Resolver.Resolve(r)
+		if errors.Is(err, ErrNoCredential) {
+			// Answer as an invalid token to avoid disclosing which users have an
+			// otp configured.
+			writeError(w, http.StatusUnauthorized, "invalid_token", "the otp is not valid")
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "internal_error", "unable to validate the otp")
+			return
+		}
+
+		// The attempt is reserved before validating, so that parallel requests
+		// can't exceed the limit. It stays a failure unless the token is valid.
+		var attempt uint64
+		if m.Limiter != nil {
+			reserved, wait, ok := m.Limiter.Allow(id)
+			if !ok {
+				m.throttled(id, v)
+				w.Header().Set("Retry-After", strconv.Itoa(int((wait+time.Second-1)/time.Second)))
+				writeError(w, http.StatusTooManyRequests, "too_many_attempts", "too many failed attempts, try again later")
+				return
+			}
+			attempt = reserved
+		}
+
+		valid, err := validate(r.Context(), v, token)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "internal_error", "unable to validate the otp")
+			return
+		}
+
+		if !valid {
+			writeError(w, http.StatusUnauthorized, "invalid_token", "the otp is not valid")
+			return
+		}
+
+		if c, ok := m.Resolver.(Committer); ok {
+			if err := c.Commit(r, id, v); err != nil {
+				writeError(w, http.StatusInternalServerError, "internal_error", "unable to validate the otp")
+				return
+			}
+		}
+
+		if m.Limiter != nil {
+			m.Limiter.Success(id, attempt)
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), contextKey{}, id)))
+	})
+}
+
+// validate checks the token with the request context when the Validator
+// accepts one.
+func validate(ctx context.Context, v Validator, token string) (bool, error) {
+	if cv, ok := v.(ContextValidator); ok {
+		return cv.ValidateContext(ctx, token)
+	}
+
+	return v.Validate(token)
+}
+
+// throttled notifies the Observer of an attempt blocked by the Limiter.
+func (m *Middleware) throttled(id string, v Validator) {
+	if m.Observer == nil {
+		return
+	}
+
+	e := otpgo.Event{Time: time.Now().UTC(), CredentialID: id, Reason: otpgo.ReasonThrottled}
+	if o, ok := v.(otpgo.OTP); ok {
+		e.Type = o.Type()
+	}
+
+	m.Observer.Observe(e)
+}
+
+// token extracts the OTP from the configured header or form field. Query
+// parameters are ignored, since they tend to end up in access logs.
+func (m *Middleware) token(r *http.Request) string {
+	header := m.Header
+	if header == "" {
+		header = DefaultHeader
+	}
+
+	if token := r.Header.Get(header); token != "" {
+		return token
+	}
+
+	field := m.Field
+	if field == "" {
+		field = DefaultField
+	}
+
+	return r.PostFormValue(field)
+}
+
+// The errorResponse type is the JSON body sent on failed validations.
+type errorResponse struct {
+	Error   string `json:"error"`
+	Message string `json:"message"`
+}
+
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(errorResponse{Error: code, Message: message})
+}
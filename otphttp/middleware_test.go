@@ -0,0 +1,249 @@
+package otphttp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jltorresm/otpgo"
+)
+
+const testKey = "73QK7D3A3PIZ6NUQQBF4BNFYQBRVUHUQ"
+
+// mapResolver resolves credentials from the X-User header.
+type mapResolver struct {
+	validators map[string]Validator
+	committed  []string
+	err        error
+}
+
+func (mr *mapResolver) Resolve(r *http.Request) (string, Validator, error) {
+	if mr.err != nil {
+		return "", nil, mr.err
+	}
+
+	user := r.Header.Get("X-User")
+	v, ok := mr.validators[user]
+	if !ok {
+		return "", nil, ErrNoCredential
+	}
+
+	return user, v, nil
+}
+
+func (mr *mapResolver) Commit(r *http.Request, id string, v Validator) error {
+	mr.committed = append(mr.committed, id)
+	return nil
+}
+
+// recorder keeps the reasons of the events it observes.
+type recorder struct {
+	reasons []string
+}
+
+func (r *recorder) Observe(e otpgo.Event) {
+	reason := e.Reason
+	if e.Success {
+		reason = "success"
+	}
+	r.reasons = append(r.reasons, e.Type+":"+e.CredentialID+":"+reason)
+}
+
+// hello is the protected handler, it greets the verified credential.
+var hello = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	id, _ := CredentialID(r.Context())
+	_, _ = w.Write([]byte("hello " + id))
+})
+
+func TestMiddleware_Wrap(t *testing.T) {
+	totp := &otpgo.TOTP{Key: testKey}
+	valid, _ := totp.Generate()
+
+	hotp := &otpgo.HOTP{Key: testKey, Counter: 10}
+	validHOTP, _ := hotp.Generate()
+
+	resolver := &mapResolver{validators: map[string]Validator{"john": totp, "jane": hotp}}
+	handler := (&Middleware{Resolver: resolver}).Wrap(hello)
+
+	cases := []struct {
+		label          string
+		user           string
+		header         string
+		form           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"Header", "john", valid, "", http.StatusOK, "hello john"},
+		{"Form", "john", "", valid, http.StatusOK, "hello john"},
+		{"HOTP", "jane", validHOTP, "", http.StatusOK, "hello jane"},
+		{"Missing", "john", "", "", http.StatusUnauthorized, `{"error":"missing_token","message":"an otp is required"}`},
+		{"Invalid", "john", "12345x", "", http.StatusUnauthorized, `{"error":"invalid_token","message":"the otp is not valid"}`},
+		{"Unknown User", "nobody", valid, "", http.StatusUnauthorized, `{"error":"invalid_token","message":"the otp is not valid"}`},
+	}
+
+	for _, c := range cases {
+		t.Run(c.label, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(url.Values{"otp": {c.form}}.Encode()))
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			r.Header.Set("X-User", c.user)
+			if c.header != "" {
+				r.Header.Set("X-OTP", c.header)
+			}
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if c.expectedStatus != w.Code {
+				t.Errorf("unexpected status\nexpected: %d\n  actual: %d", c.expectedStatus, w.Code)
+			}
+
+			if body := strings.TrimSpace(w.Body.String()); c.expectedBody != body {
+				t.Errorf("unexpected body\nexpected: %s\n  actual: %s", c.expectedBody, body)
+			}
+		})
+	}
+
+	if len(resolver.committed) != 3 {
+		t.Errorf("unexpected commits: %v", resolver.committed)
+	}
+}
+
+func TestMiddleware_CustomToken(t *testing.T) {
+	totp := &otpgo.TOTP{Key: testKey}
+	valid, _ := totp.Generate()
+
+	m := &Middleware{
+		Resolver: &mapResolver{validators: map[string]Validator{"john": totp}},
+		Header:   "X-Second-Factor",
+		Field:    "code",
+	}
+	handler := m.Wrap(hello)
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("X-User", "john")
+	r.Header.Set("X-Second-Factor", valid)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("unexpected status\nexpected: %d\n  actual: %d", http.StatusOK, w.Code)
+	}
+
+	// Tokens in the query string are never accepted.
+	r = httptest.NewRequest(http.MethodGet, "/?code="+valid, nil)
+	r.Header.Set("X-User", "john")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("unexpected status\nexpected: %d\n  actual: %d", http.StatusUnauthorized, w.Code)
+	}
+}
+
+func TestMiddleware_RateLimit(t *testing.T) {
+	events := &recorder{}
+	totp := &otpgo.TOTP{Key: testKey, ID: "john", Observer: events}
+	valid, _ := totp.Generate()
+
+	now := time.Date(2020, 9, 10, 12, 0, 0, 0, time.UTC)
+	limiter := &MemoryLimiter{MaxFailures: 2, Lockout: 90 * time.Second, Now: func() time.Time { return now }}
+	m := &Middleware{
+		Resolver: &mapResolver{validators: map[string]Validator{"john": totp}},
+		Limiter:  limiter,
+		Observer: events,
+	}
+	handler := m.Wrap(hello)
+
+	send := func(token string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("X-User", "john")
+		r.Header.Set("X-OTP", token)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	for i := 0; i < 2; i++ {
+		if w := send("bad"); w.Code != http.StatusUnauthorized {
+			t.Errorf("unexpected status\nexpected: %d\n  actual: %d", http.StatusUnauthorized, w.Code)
+		}
+	}
+
+	w := send(valid)
+	if w.Code != http.StatusTooManyRequests {
+		t.Errorf("unexpected status\nexpected: %d\n  actual: %d", http.StatusTooManyRequests, w.Code)
+	}
+
+	if w.Header().Get("Retry-After") != "90" {
+		t.Errorf("unexpected retry after\nexpected: %s\n  actual: %s", "90", w.Header().Get("Retry-After"))
+	}
+
+	var body errorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body.Error != "too_many_attempts" {
+		t.Errorf("unexpected body: %s", w.Body.String())
+	}
+
+	now = now.Add(91 * time.Second)
+	if w := send(valid); w.Code != http.StatusOK {
+		t.Errorf("unexpected status\nexpected: %d\n  actual: %d", http.StatusOK, w.Code)
+	}
+
+	expected := "totp:john:invalid_format totp:john:invalid_format totp:john:throttled totp:john:success"
+	if actual := strings.Join(events.reasons, " "); expected != actual {
+		t.Errorf("unexpected events\nexpected: %s\n  actual: %s", expected, actual)
+	}
+}
+
+func TestMiddleware_ResolverError(t *testing.T) {
+	m := &Middleware{Resolver: &mapResolver{err: errors.New("database is down")}}
+	handler := m.Wrap(hello)
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("X-OTP", "123456")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("unexpected status\nexpected: %d\n  actual: %d", http.StatusInternalServerError, w.Code)
+	}
+
+	expected := `{"error":"internal_error","message":"unable to validate the otp"}`
+	if body := strings.TrimSpace(w.Body.String()); expected != body {
+		t.Errorf("unexpected body\nexpected: %s\n  actual: %s", expected, body)
+	}
+
+	if w.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("unexpected content type: %s", w.Header().Get("Content-Type"))
+	}
+}
+
+func TestMiddleware_RequestContext(t *testing.T) {
+	totp := &otpgo.TOTP{Key: testKey}
+	m := &Middleware{Resolver: &mapResolver{validators: map[string]Validator{"john": totp}}}
+	handler := m.Wrap(hello)
+
+	code, err := totp.Generate()
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		t.FailNow()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+	r.Header.Set("X-User", "john")
+	r.Header.Set("X-OTP", code)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("unexpected status\nexpected: %d\n  actual: %d", http.StatusInternalServerError, w.Code)
+	}
+}
@@ -0,0 +1,169 @@
+package pskc
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"hash"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// The cryptor type holds the keys protecting the values of a container.
+type cryptor struct {
+	key          []byte // AES-128 encryption key
+	macKey       []byte
+	macAlgorithm string
+}
+
+// decrypt returns the plain bytes of the encrypted value, after checking its
+// MAC.
+func (c *cryptor) decrypt(keyID string, v *value) ([]byte, error) {
+	if v.EncryptedValue.EncryptionMethod.Algorithm != algorithmAES128CBC {
+		return nil, ErrorUnsupported{msg: "encryption algorithm " + v.EncryptedValue.EncryptionMethod.Algorithm}
+	}
+
+	if c.macKey == nil {
+		return nil, ErrorInvalidFile{msg: "encrypted values require a mac method"}
+	}
+
+	raw, err := decodeBase64(v.EncryptedValue.CipherData.CipherValue)
+	if err != nil {
+		return nil, ErrorInvalidFile{msg: "malformed cipher value of key " + keyID}
+	}
+
+	expected, err := decodeBase64(v.ValueMAC)
+	if err != nil || v.ValueMAC == "" {
+		return nil, ErrorInvalidMAC{KeyID: keyID}
+	}
+
+	sum, err := mac(c.macAlgorithm, c.macKey, raw)
+	if err != nil {
+		return nil, err
+	}
+
+	if !hmac.Equal(expected, sum) {
+		return nil, ErrorInvalidMAC{KeyID: keyID}
+	}
+
+	return decryptCBC(c.key, raw)
+}
+
+// encrypt returns the encrypted value and its MAC.
+func (c *cryptor) encrypt(plaintext []byte) (*value, error) {
+	raw, err := encryptCBC(c.key, plaintext)
+	if err != nil {
+		return nil, err
+	}
+
+	sum, err := mac(c.macAlgorithm, c.macKey, raw)
+	if err != nil {
+		return nil, err
+	}
+
+	return &value{
+		EncryptedValue: &encryptedValue{
+			EncryptionMethod: algorithm{Algorithm: algorithmAES128CBC},
+			CipherData:       cipherData{CipherValue: base64.StdEncoding.EncodeToString(raw)},
+		},
+		ValueMAC: base64.StdEncoding.EncodeToString(sum),
+	}, nil
+}
+
+// deriveKey returns the key derived from the password with PBKDF2.
+func deriveKey(params *pbkdf2Params, password string) ([]byte, error) {
+	if params == nil {
+		return nil, ErrorInvalidFile{msg: "missing pbkdf2 parameters"}
+	}
+
+	if params.PRF != nil && params.PRF.Algorithm != "" && params.PRF.Algorithm != algorithmHMACSHA1 {
+		return nil, ErrorUnsupported{msg: "pbkdf2 prf " + params.PRF.Algorithm}
+	}
+
+	salt, err := decodeBase64(params.Salt)
+	if err != nil {
+		return nil, ErrorInvalidFile{msg: "malformed pbkdf2 salt"}
+	}
+
+	if params.IterationCount < 1 {
+		return nil, ErrorInvalidFile{msg: "invalid pbkdf2 iteration count"}
+	}
+
+	length := params.KeyLength
+	if length == 0 {
+		length = aes.BlockSize
+	}
+
+	if length != aes.BlockSize {
+		return nil, ErrorUnsupported{msg: "pbkdf2 key length other than 16 bytes"}
+	}
+
+	return pbkdf2.Key([]byte(password), salt, params.IterationCount, length, sha1.New), nil
+}
+
+// mac returns the MAC of the data with the given algorithm.
+func mac(algorithm string, key, data []byte) ([]byte, error) {
+	var h func() hash.Hash
+	switch algorithm {
+	case algorithmHMACSHA1:
+		h = sha1.New
+	case algorithmHMACSHA256:
+		h = sha256.New
+	default:
+		return nil, ErrorUnsupported{msg: "mac algorithm " + algorithm}
+	}
+
+	hm := hmac.New(h, key)
+	hm.Write(data)
+
+	return hm.Sum(nil), nil
+}
+
+// decryptCBC decrypts the IV prefixed ciphertext and removes its padding. As
+// specified by XML Encryption, only the last padding byte is checked.
+func decryptCBC(key, raw []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, ErrorDecryption{}
+	}
+
+	if len(raw) < 2*aes.BlockSize || len(raw)%aes.BlockSize != 0 {
+		return nil, ErrorDecryption{}
+	}
+
+	plaintext := make([]byte, len(raw)-aes.BlockSize)
+	cipher.NewCBCDecrypter(block, raw[:aes.BlockSize]).CryptBlocks(plaintext, raw[aes.BlockSize:])
+
+	padding := int(plaintext[len(plaintext)-1])
+	if padding < 1 || padding > aes.BlockSize {
+		return nil, ErrorDecryption{}
+	}
+
+	return plaintext[:len(plaintext)-padding], nil
+}
+
+// encryptCBC pads and encrypts the plaintext with a random IV, which prefixes
+// the returned ciphertext.
+func encryptCBC(key, plaintext []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	padding := aes.BlockSize - len(plaintext)%aes.BlockSize
+	padded := append(append([]byte{}, plaintext...), bytes.Repeat([]byte{byte(padding)}, padding)...)
+
+	raw := make([]byte, aes.BlockSize+len(padded))
+	if _, err := rand.Read(raw[:aes.BlockSize]); err != nil {
+		return nil, err
+	}
+
+	cipher.NewCBCEncrypter(block, raw[:aes.BlockSize]).CryptBlocks(raw[aes.BlockSize:], padded)
+
+	return raw, nil
+}
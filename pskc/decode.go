@@ -0,0 +1,266 @@
+package pskc
+
+import (
+	"encoding/binary"
+	"encoding/xml"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/jltorresm/otpgo"
+	"github.com/jltorresm/otpgo/config"
+)
+
+// The Decoder type reads the keys of a PSKC file. Only the secret matching
+// the protection of the file is needed: PreSharedKey when the file uses a
+// pre-shared key, Password when its key is derived with PBKDF2.
+type Decoder struct {
+	PreSharedKey []byte // AES-128 key the values are encrypted with
+	Password     string // Password the encryption key is derived from
+}
+
+// Decode reads a PSKC file and returns its HOTP and TOTP keys.
+func (d *Decoder) Decode(r io.Reader) ([]Key, error) {
+	var kc keyContainer
+	if err := xml.NewDecoder(r).Decode(&kc); err != nil {
+		return nil, ErrorInvalidFile{msg: err.Error()}
+	}
+
+	if kc.Version != "1.0" {
+		return nil, ErrorUnsupported{msg: "version " + strconv.Quote(kc.Version)}
+	}
+
+	c, err := d.cryptor(&kc)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]Key, 0, len(kc.KeyPackages))
+	for _, kp := range kc.KeyPackages {
+		k, err := c.readKey(&kp)
+		if err != nil {
+			return nil, err
+		}
+
+		keys = append(keys, k)
+	}
+
+	return keys, nil
+}
+
+// cryptor returns the keys protecting the values of the container, nil if it
+// has no encryption key.
+func (d *Decoder) cryptor(kc *keyContainer) (*cryptor, error) {
+	if kc.EncryptionKey == nil {
+		return nil, nil
+	}
+
+	c := &cryptor{key: d.PreSharedKey}
+
+	if dk := kc.EncryptionKey.DerivedKey; dk != nil {
+		if dk.KeyDerivationMethod.Algorithm != algorithmPBKDF2 {
+			return nil, ErrorUnsupported{msg: "key derivation " + dk.KeyDerivationMethod.Algorithm}
+		}
+
+		key, err := deriveKey(dk.KeyDerivationMethod.Params, d.Password)
+		if err != nil {
+			return nil, err
+		}
+
+		c.key = key
+	}
+
+	if kc.MACMethod == nil {
+		return c, nil
+	}
+
+	c.macAlgorithm = kc.MACMethod.Algorithm
+
+	if kc.MACMethod.MACKey == nil {
+		return nil, ErrorInvalidFile{msg: "missing mac key"}
+	}
+
+	raw, err := decodeBase64(kc.MACMethod.MACKey.CipherData.CipherValue)
+	if err != nil {
+		return nil, ErrorInvalidFile{msg: "malformed mac key"}
+	}
+
+	// The MAC key isn't authenticated itself, a wrong key shows up as a
+	// failed decryption or as invalid MACs on the values.
+	macKey, err := decryptCBC(c.key, raw)
+	if err != nil {
+		return nil, err
+	}
+
+	c.macKey = macKey
+
+	return c, nil
+}
+
+// readKey converts a key package into a Key.
+func (c *cryptor) readKey(kp *keyPackage) (Key, error) {
+	if kp.Key == nil {
+		return Key{}, ErrorInvalidFile{msg: "key package without key"}
+	}
+
+	k := Key{ID: kp.Key.ID, Issuer: kp.Key.Issuer}
+	if kp.DeviceInfo != nil {
+		k.Serial = kp.DeviceInfo.SerialNo
+		k.Manufacturer = kp.DeviceInfo.Manufacturer
+	}
+
+	if kp.Key.Data == nil || kp.Key.Data.Secret == nil {
+		return Key{}, ErrorInvalidFile{msg: "missing secret of key " + k.ID}
+	}
+
+	secret, err := c.readBytes(k.ID, kp.Key.Data.Secret)
+	if err != nil {
+		return Key{}, err
+	}
+
+	if len(secret) == 0 {
+		return Key{}, ErrorInvalidFile{msg: "empty secret of key " + k.ID}
+	}
+
+	alg, length, err := parameters(k.ID, kp.Key.AlgorithmParameters)
+	if err != nil {
+		return Key{}, err
+	}
+
+	switch kp.Key.Algorithm {
+	case algorithmHOTP:
+		counter, err := c.readInteger(k.ID, kp.Key.Data.Counter)
+		if err != nil {
+			return Key{}, err
+		}
+
+		k.OTP = &otpgo.HOTP{
+			Key:       keyEncoding.EncodeToString(secret),
+			Counter:   counter,
+			Leeway:    otpgo.HOTPDefaultLeeway,
+			Algorithm: alg,
+			Length:    length,
+			ID:        k.ID,
+		}
+	case algorithmTOTP:
+		period, err := c.readInteger(k.ID, kp.Key.Data.TimeInterval)
+		if err != nil {
+			return Key{}, err
+		}
+
+		if period == 0 {
+			period = otpgo.TOTPDefaultPeriod
+		}
+
+		// Larger values would overflow the TOTP step arithmetic.
+		if period > math.MaxInt32 {
+			return Key{}, ErrorInvalidFile{msg: "time interval out of range in key " + k.ID}
+		}
+
+		t0, err := c.readInteger(k.ID, kp.Key.Data.Time)
+		if err != nil {
+			return Key{}, err
+		}
+
+		if t0 > math.MaxInt32 {
+			return Key{}, ErrorInvalidFile{msg: "start time out of range in key " + k.ID}
+		}
+
+		k.OTP = &otpgo.TOTP{
+			Key:       keyEncoding.EncodeToString(secret),
+			Period:    int(period),
+			Delay:     otpgo.TOTPDefaultDelay,
+			T0:        int64(t0),
+			Algorithm: alg,
+			Length:    length,
+			ID:        k.ID,
+		}
+	default:
+		return Key{}, ErrorUnsupported{msg: "algorithm " + kp.Key.Algorithm + " of key " + k.ID}
+	}
+
+	return k, nil
+}
+
+// readBytes returns the binary content of the value. Plain values are base64
+// encoded.
+func (c *cryptor) readBytes(keyID string, v *value) ([]byte, error) {
+	if v.EncryptedValue != nil {
+		if c == nil {
+			return nil, ErrorInvalidFile{msg: "encrypted value without encryption key"}
+		}
+
+		return c.decrypt(keyID, v)
+	}
+
+	b, err := decodeBase64(v.PlainValue)
+	if err != nil {
+		return nil, ErrorInvalidFile{msg: "malformed secret of key " + keyID}
+	}
+
+	return b, nil
+}
+
+// readInteger returns the numeric content of the value, 0 if it is missing.
+// Plain integers are written in decimal, encrypted ones as big endian bytes.
+func (c *cryptor) readInteger(keyID string, v *value) (uint64, error) {
+	if v == nil {
+		return 0, nil
+	}
+
+	if v.EncryptedValue == nil {
+		n, err := strconv.ParseUint(strings.TrimSpace(v.PlainValue), 10, 64)
+		if err != nil {
+			return 0, ErrorInvalidFile{msg: "malformed integer of key " + keyID}
+		}
+
+		return n, nil
+	}
+
+	b, err := c.readBytes(keyID, v)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(b) > 8 {
+		return 0, ErrorInvalidFile{msg: "integer overflow in key " + keyID}
+	}
+
+	padded := make([]byte, 8)
+	copy(padded[8-len(b):], b)
+
+	return binary.BigEndian.Uint64(padded), nil
+}
+
+// parameters returns the hash algorithm and code length of the key, SHA1 and
+// 6 digits when missing.
+func parameters(keyID string, p *algorithmParameters) (config.HmacAlgorithm, config.Length, error) {
+	alg, length := config.HmacSHA1, config.Length6
+	if p == nil {
+		return alg, length, nil
+	}
+
+	if p.Suite != "" {
+		parsed, err := config.ParseHmacAlgorithm(strings.TrimPrefix(strings.ToUpper(p.Suite), "HMAC-"))
+		if err != nil {
+			return 0, 0, ErrorUnsupported{msg: "suite " + p.Suite + " of key " + keyID}
+		}
+
+		alg = parsed
+	}
+
+	if rf := p.ResponseFormat; rf != nil {
+		if rf.Encoding != "" && rf.Encoding != "DECIMAL" {
+			return 0, 0, ErrorUnsupported{msg: "encoding " + rf.Encoding + " of key " + keyID}
+		}
+
+		if rf.Length < int(config.Length1) || rf.Length > int(config.Length8) {
+			return 0, 0, ErrorUnsupported{msg: "length " + strconv.Itoa(rf.Length) + " of key " + keyID}
+		}
+
+		length = config.Length(rf.Length)
+	}
+
+	return alg, length, nil
+}
@@ -0,0 +1,328 @@
+package pskc
+
+import (
+	"encoding/hex"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jltorresm/otpgo"
+	"github.com/jltorresm/otpgo/config"
+)
+
+// The secret of every RFC 6030 example, "12345678901234567890".
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+// RFC 6030, figure 2.
+const rfcPlain = `<?xml version="1.0" encoding="UTF-8"?>
+<KeyContainer Version="1.0"
+  Id="exampleID1"
+  xmlns="urn:ietf:params:xml:ns:keyprov:pskc">
+  <KeyPackage>
+    <DeviceInfo>
+      <Manufacturer>Manufacturer</Manufacturer>
+      <SerialNo>987654321</SerialNo>
+      <UserId>DC=example-bank,DC=net</UserId>
+    </DeviceInfo>
+    <CryptoModuleInfo>
+      <Id>CM_ID_001</Id>
+    </CryptoModuleInfo>
+    <Key Id="12345678"
+      Algorithm="urn:ietf:params:xml:ns:keyprov:pskc:hotp">
+      <Issuer>Issuer</Issuer>
+      <AlgorithmParameters>
+        <ResponseFormat Length="8" Encoding="DECIMAL"/>
+      </AlgorithmParameters>
+      <Data>
+        <Secret>
+          <PlainValue>MTIzNDU2Nzg5MDEyMzQ1Njc4OTA=
+          </PlainValue>
+        </Secret>
+        <Counter>
+          <PlainValue>0</PlainValue>
+        </Counter>
+      </Data>
+      <UserId>UID=jsmith,DC=example-bank,DC=net</UserId>
+    </Key>
+  </KeyPackage>
+</KeyContainer>`
+
+// RFC 6030, figure 6.
+const rfcPreSharedKey = `<?xml version="1.0" encoding="UTF-8"?>
+<KeyContainer Version="1.0"
+    xmlns="urn:ietf:params:xml:ns:keyprov:pskc"
+    xmlns:ds="http://www.w3.org/2000/09/xmldsig#"
+    xmlns:xenc="http://www.w3.org/2001/04/xmlenc#">
+    <EncryptionKey>
+        <ds:KeyName>Pre-shared-key</ds:KeyName>
+    </EncryptionKey>
+    <MACMethod Algorithm="http://www.w3.org/2000/09/xmldsig#hmac-sha1">
+        <MACKey>
+            <xenc:EncryptionMethod
+            Algorithm="http://www.w3.org/2001/04/xmlenc#aes128-cbc"/>
+            <xenc:CipherData>
+                <xenc:CipherValue>
+ESIzRFVmd4iZABEiM0RVZgKn6WjLaTC1sbeBMSvIhRejN9vJa2BOlSaMrR7I5wSX
+                </xenc:CipherValue>
+            </xenc:CipherData>
+        </MACKey>
+    </MACMethod>
+    <KeyPackage>
+        <DeviceInfo>
+            <Manufacturer>Manufacturer</Manufacturer>
+            <SerialNo>987654321</SerialNo>
+        </DeviceInfo>
+        <CryptoModuleInfo>
+            <Id>CM_ID_001</Id>
+        </CryptoModuleInfo>
+        <Key Id="12345678"
+            Algorithm="urn:ietf:params:xml:ns:keyprov:pskc:hotp">
+            <Issuer>Issuer</Issuer>
+            <AlgorithmParameters>
+                <ResponseFormat Length="8" Encoding="DECIMAL"/>
+            </AlgorithmParameters>
+            <Data>
+                <Secret>
+                    <EncryptedValue>
+                        <xenc:EncryptionMethod
+            Algorithm="http://www.w3.org/2001/04/xmlenc#aes128-cbc"/>
+                        <xenc:CipherData>
+                            <xenc:CipherValue>
+AAECAwQFBgcICQoLDA0OD+cIHItlB3Wra1DUpxVvOx2lef1VmNPCMl8jwZqIUqGv
+                            </xenc:CipherValue>
+                        </xenc:CipherData>
+                    </EncryptedValue>
+                    <ValueMAC>Su+NvtQfmvfJzF6bmQiJqoLRExc=
+                    </ValueMAC>
+                </Secret>
+                <Counter>
+                    <PlainValue>0</PlainValue>
+                </Counter>
+            </Data>
+        </Key>
+    </KeyPackage>
+</KeyContainer>`
+
+// RFC 6030, figure 7.
+const rfcPassword = `<?xml version="1.0" encoding="UTF-8"?>
+<pskc:KeyContainer
+  xmlns:pskc="urn:ietf:params:xml:ns:keyprov:pskc"
+  xmlns:xenc11="http://www.w3.org/2009/xmlenc11#"
+  xmlns:pkcs5=
+  "http://www.rsasecurity.com/rsalabs/pkcs/schemas/pkcs-5v2-0#"
+  xmlns:xenc="http://www.w3.org/2001/04/xmlenc#" Version="1.0">
+    <pskc:EncryptionKey>
+        <xenc11:DerivedKey>
+            <xenc11:KeyDerivationMethod
+              Algorithm=
+ "http://www.rsasecurity.com/rsalabs/pkcs/schemas/pkcs-5v2-0#pbkdf2">
+                <pkcs5:PBKDF2-params>
+                    <Salt>
+                        <Specified>Ej7/PEpyEpw=</Specified>
+                    </Salt>
+                    <IterationCount>1000</IterationCount>
+                    <KeyLength>16</KeyLength>
+                    <PRF/>
+                </pkcs5:PBKDF2-params>
+            </xenc11:KeyDerivationMethod>
+            <xenc:ReferenceList>
+                <xenc:DataReference URI="#ED"/>
+            </xenc:ReferenceList>
+            <xenc11:MasterKeyName>My Password 1</xenc11:MasterKeyName>
+        </xenc11:DerivedKey>
+    </pskc:EncryptionKey>
+    <pskc:MACMethod
+        Algorithm="http://www.w3.org/2000/09/xmldsig#hmac-sha1">
+        <pskc:MACKey>
+            <xenc:EncryptionMethod
+            Algorithm="http://www.w3.org/2001/04/xmlenc#aes128-cbc"/>
+            <xenc:CipherData>
+                <xenc:CipherValue>
+2GTTnLwM3I4e5IO5FkufoOEiOhNj91fhKRQBtBJYluUDsPOLTfUvoU2dStyOwYZx
+                </xenc:CipherValue>
+            </xenc:CipherData>
+        </pskc:MACKey>
+    </pskc:MACMethod>
+    <pskc:KeyPackage>
+        <pskc:DeviceInfo>
+            <pskc:Manufacturer>TokenVendorAcme</pskc:Manufacturer>
+            <pskc:SerialNo>987654321</pskc:SerialNo>
+        </pskc:DeviceInfo>
+        <pskc:CryptoModuleInfo>
+            <pskc:Id>CM_ID_001</pskc:Id>
+        </pskc:CryptoModuleInfo>
+        <pskc:Key Algorithm=
+        "urn:ietf:params:xml:ns:keyprov:pskc:hotp" Id="123456">
+            <pskc:Issuer>Example-Issuer</pskc:Issuer>
+            <pskc:AlgorithmParameters>
+                <pskc:ResponseFormat Length="8" Encoding="DECIMAL"/>
+            </pskc:AlgorithmParameters>
+            <pskc:Data>
+                <pskc:Secret>
+                <pskc:EncryptedValue Id="ED">
+                    <xenc:EncryptionMethod
+                        Algorithm=
+"http://www.w3.org/2001/04/xmlenc#aes128-cbc"/>
+                        <xenc:CipherData>
+                            <xenc:CipherValue>
+      oTvo+S22nsmS2Z/RtcoF8Hfh+jzMe0RkiafpoDpnoZTjPYZu6V+A4aEn032yCr4f
+                        </xenc:CipherValue>
+                    </xenc:CipherData>
+                    </pskc:EncryptedValue>
+                    <pskc:ValueMAC>LP6xMvjtypbfT9PdkJhBZ+D6O4w=
+                    </pskc:ValueMAC>
+                </pskc:Secret>
+            </pskc:Data>
+        </pskc:Key>
+    </pskc:KeyPackage>
+</pskc:KeyContainer>`
+
+const totpPlain = `<?xml version="1.0" encoding="UTF-8"?>
+<KeyContainer Version="1.0" xmlns="urn:ietf:params:xml:ns:keyprov:pskc">
+  <KeyPackage>
+    <Key Id="totp-1" Algorithm="urn:ietf:params:xml:ns:keyprov:pskc:totp">
+      <AlgorithmParameters>
+        <Suite>HMAC-SHA256</Suite>
+        <ResponseFormat Length="8" Encoding="DECIMAL"/>
+      </AlgorithmParameters>
+      <Data>
+        <Secret>
+          <PlainValue>MTIzNDU2Nzg5MDEyMzQ1Njc4OTAxMjM0NTY3ODkwMTI=</PlainValue>
+        </Secret>
+        <Time>
+          <PlainValue>1000</PlainValue>
+        </Time>
+        <TimeInterval>
+          <PlainValue>60</PlainValue>
+        </TimeInterval>
+      </Data>
+    </Key>
+  </KeyPackage>
+</KeyContainer>`
+
+func rfcPreSharedKeyBytes(t *testing.T) []byte {
+	k, err := hex.DecodeString("12345678901234567890123456789012")
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		t.FailNow()
+	}
+
+	return k
+}
+
+func TestDecoder_Decode_RFC(t *testing.T) {
+	cases := []struct {
+		label        string
+		file         string
+		decoder      Decoder
+		id           string
+		issuer       string
+		manufacturer string
+	}{
+		{"Plain", rfcPlain, Decoder{}, "12345678", "Issuer", "Manufacturer"},
+		{"Pre-Shared Key", rfcPreSharedKey, Decoder{PreSharedKey: rfcPreSharedKeyBytes(t)}, "12345678", "Issuer", "Manufacturer"},
+		{"Password", rfcPassword, Decoder{Password: "qwerty"}, "123456", "Example-Issuer", "TokenVendorAcme"},
+	}
+
+	for _, c := range cases {
+		keys, err := c.decoder.Decode(strings.NewReader(c.file))
+		if err != nil {
+			t.Errorf("case %s: unexpected error: %s", c.label, err)
+			continue
+		}
+
+		if len(keys) != 1 {
+			t.Errorf("case %s: unexpected number of keys\nexpected: 1\n  actual: %d", c.label, len(keys))
+			continue
+		}
+
+		k := keys[0]
+		if k.ID != c.id || k.Issuer != c.issuer || k.Manufacturer != c.manufacturer || k.Serial != "987654321" {
+			t.Errorf("case %s: unexpected key\n  actual: %+v", c.label, k)
+		}
+
+		h, ok := k.OTP.(*otpgo.HOTP)
+		if !ok {
+			t.Errorf("case %s: unexpected otp type %T", c.label, k.OTP)
+			continue
+		}
+
+		if h.Key != rfcSecret || h.Counter != 0 || h.Length != config.Length8 || h.Algorithm != config.HmacSHA1 || h.ID != c.id {
+			t.Errorf("case %s: unexpected hotp\n  actual: %+v", c.label, h)
+		}
+
+		// RFC 4226, appendix D.
+		code, err := h.Generate()
+		if err != nil {
+			t.Errorf("case %s: unexpected error: %s", c.label, err)
+		}
+
+		if code != "84755224" {
+			t.Errorf("case %s: unexpected code\nexpected: 84755224\n  actual: %s", c.label, code)
+		}
+	}
+}
+
+func TestDecoder_Decode_TOTP(t *testing.T) {
+	keys, err := (&Decoder{}).Decode(strings.NewReader(totpPlain))
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		t.FailNow()
+	}
+
+	tt, ok := keys[0].OTP.(*otpgo.TOTP)
+	if !ok {
+		t.Errorf("unexpected otp type %T", keys[0].OTP)
+		t.FailNow()
+	}
+
+	expected := otpgo.TOTP{
+		Key:       "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQGEZA",
+		Period:    60,
+		Delay:     otpgo.TOTPDefaultDelay,
+		T0:        1000,
+		Algorithm: config.HmacSHA256,
+		Length:    config.Length8,
+		ID:        "totp-1",
+	}
+
+	if *tt != expected {
+		t.Errorf("unexpected totp\nexpected: %+v\n  actual: %+v", expected, *tt)
+	}
+}
+
+func TestDecoder_Decode_Errors(t *testing.T) {
+	tampered := strings.Replace(rfcPreSharedKey, "Su+NvtQfmvfJzF6bmQiJqoLRExc=", "Tu+NvtQfmvfJzF6bmQiJqoLRExc=", 1)
+	ocra := strings.Replace(rfcPlain, "pskc:hotp", "pskc:ocra", 1)
+	hexadecimal := strings.Replace(rfcPlain, `Encoding="DECIMAL"`, `Encoding="HEXADECIMAL"`, 1)
+	version := strings.Replace(rfcPlain, `Version="1.0"`, `Version="2.0"`, 1)
+	empty := strings.Replace(rfcPlain, "MTIzNDU2Nzg5MDEyMzQ1Njc4OTA=", "", 1)
+	interval := strings.Replace(totpPlain, "<PlainValue>60</PlainValue>", "<PlainValue>18446744073709551615</PlainValue>", 1)
+	startTime := strings.Replace(totpPlain, "<PlainValue>1000</PlainValue>", "<PlainValue>9223372036854775808</PlainValue>", 1)
+
+	cases := []struct {
+		label    string
+		file     string
+		decoder  Decoder
+		expected error
+	}{
+		{"Not XML", "not xml", Decoder{}, ErrorInvalidFile{}},
+		{"Version", version, Decoder{}, ErrorUnsupported{}},
+		{"Empty Secret", empty, Decoder{}, ErrorInvalidFile{}},
+		{"Time Interval", interval, Decoder{}, ErrorInvalidFile{}},
+		{"Start Time", startTime, Decoder{}, ErrorInvalidFile{}},
+		{"Algorithm", ocra, Decoder{}, ErrorUnsupported{}},
+		{"Encoding", hexadecimal, Decoder{}, ErrorUnsupported{}},
+		{"Missing Key", rfcPreSharedKey, Decoder{}, ErrorDecryption{}},
+		{"Wrong Password", rfcPassword, Decoder{Password: "azerty"}, ErrorDecryption{}},
+		{"Tampered MAC", tampered, Decoder{PreSharedKey: rfcPreSharedKeyBytes(t)}, ErrorInvalidMAC{}},
+	}
+
+	for _, c := range cases {
+		_, err := c.decoder.Decode(strings.NewReader(c.file))
+
+		if reflect.TypeOf(err) != reflect.TypeOf(c.expected) {
+			t.Errorf("case %s: unexpected error\nexpected: %T\n  actual: %v", c.label, c.expected, err)
+		}
+	}
+}
@@ -0,0 +1,231 @@
+package pskc
+
+import (
+	"crypto/aes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/xml"
+	"io"
+	"strconv"
+
+	"github.com/jltorresm/otpgo"
+	"github.com/jltorresm/otpgo/config"
+)
+
+// DefaultIterations is the PBKDF2 iteration count used when deriving the
+// encryption key from a password.
+const DefaultIterations = 100000
+
+// The Encoder type writes keys to a PSKC file. Secrets are encrypted with
+// a key derived from Password when it is set, otherwise with PreSharedKey when
+// it is set, and left in plain text when none is.
+type Encoder struct {
+	PreSharedKey []byte // AES-128 key to encrypt the values with
+	KeyName      string // Optional name of the pre-shared key, to help the recipient pick it
+	Password     string // Password to derive the encryption key from
+	Iterations   int    // PBKDF2 iterations, defaults to DefaultIterations
+}
+
+// Encode writes the HOTP and TOTP keys as a PSKC file.
+func (e *Encoder) Encode(w io.Writer, keys []Key) error {
+	kc := keyContainer{Version: "1.0"}
+
+	c, err := e.cryptor(&kc)
+	if err != nil {
+		return err
+	}
+
+	for _, k := range keys {
+		kp, err := c.writeKey(k)
+		if err != nil {
+			return err
+		}
+
+		kc.KeyPackages = append(kc.KeyPackages, kp)
+	}
+
+	out, err := xml.MarshalIndent(kc, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	_, err = w.Write(append(out, '\n'))
+
+	return err
+}
+
+// cryptor sets the encryption key and MAC method of the container, and
+// returns the keys to protect its values with. It returns nil for plain text
+// containers.
+func (e *Encoder) cryptor(kc *keyContainer) (*cryptor, error) {
+	c := &cryptor{macKey: make([]byte, 20), macAlgorithm: algorithmHMACSHA1}
+
+	switch {
+	case e.Password != "":
+		salt := make([]byte, 8)
+		if _, err := rand.Read(salt); err != nil {
+			return nil, err
+		}
+
+		params := &pbkdf2Params{
+			Salt:           base64.StdEncoding.EncodeToString(salt),
+			IterationCount: e.iterations(),
+			KeyLength:      aes.BlockSize,
+		}
+
+		key, err := deriveKey(params, e.Password)
+		if err != nil {
+			return nil, err
+		}
+
+		c.key = key
+		kc.EncryptionKey = &encryptionKey{
+			DerivedKey: &derivedKey{
+				KeyDerivationMethod: keyDerivationMethod{Algorithm: algorithmPBKDF2, Params: params},
+			},
+		}
+	case e.PreSharedKey != nil:
+		if len(e.PreSharedKey) != aes.BlockSize {
+			return nil, ErrorUnsupported{msg: "pre-shared key length other than 16 bytes"}
+		}
+
+		c.key = e.PreSharedKey
+		kc.EncryptionKey = &encryptionKey{KeyName: e.KeyName}
+	default:
+		return nil, nil
+	}
+
+	if _, err := rand.Read(c.macKey); err != nil {
+		return nil, err
+	}
+
+	raw, err := encryptCBC(c.key, c.macKey)
+	if err != nil {
+		return nil, err
+	}
+
+	kc.MACMethod = &macMethod{
+		Algorithm: c.macAlgorithm,
+		MACKey: &encryptedValue{
+			EncryptionMethod: algorithm{Algorithm: algorithmAES128CBC},
+			CipherData:       cipherData{CipherValue: base64.StdEncoding.EncodeToString(raw)},
+		},
+	}
+
+	return c, nil
+}
+
+// iterations returns the PBKDF2 iteration count, DefaultIterations if unset.
+func (e *Encoder) iterations() int {
+	if e.Iterations == 0 {
+		return DefaultIterations
+	}
+
+	return e.Iterations
+}
+
+// writeKey converts a Key into a key package.
+func (c *cryptor) writeKey(k Key) (keyPackage, error) {
+	kp := keyPackage{Key: &key{ID: k.ID, Issuer: k.Issuer, Data: &keyData{}}}
+	if k.Serial != "" || k.Manufacturer != "" {
+		kp.DeviceInfo = &deviceInfo{Manufacturer: k.Manufacturer, SerialNo: k.Serial}
+	}
+
+	var (
+		secret string
+		alg    config.HmacAlgorithm
+		length config.Length
+	)
+
+	switch otp := k.OTP.(type) {
+	case *otpgo.HOTP:
+		kp.Key.Algorithm = algorithmHOTP
+		secret, alg, length = otp.Key, otp.Algorithm, otp.Length
+
+		counter, err := c.writeInteger(otp.Counter)
+		if err != nil {
+			return keyPackage{}, err
+		}
+
+		kp.Key.Data.Counter = counter
+	case *otpgo.TOTP:
+		kp.Key.Algorithm = algorithmTOTP
+		secret, alg, length = otp.Key, otp.Algorithm, otp.Length
+
+		period := otp.Period
+		if period == 0 {
+			period = otpgo.TOTPDefaultPeriod
+		}
+
+		interval, err := c.writeInteger(uint64(period))
+		if err != nil {
+			return keyPackage{}, err
+		}
+
+		kp.Key.Data.TimeInterval = interval
+
+		if otp.T0 < 0 {
+			return keyPackage{}, ErrorUnsupported{msg: "negative t0 of key " + k.ID}
+		}
+
+		if otp.T0 != 0 {
+			t0, err := c.writeInteger(uint64(otp.T0))
+			if err != nil {
+				return keyPackage{}, err
+			}
+
+			kp.Key.Data.Time = t0
+		}
+	default:
+		return keyPackage{}, ErrorUnsupported{msg: "otp type of key " + k.ID}
+	}
+
+	if alg == 0 {
+		alg = config.HmacSHA1
+	}
+
+	if length == 0 {
+		length = config.Length6
+	}
+
+	kp.Key.AlgorithmParameters = &algorithmParameters{
+		Suite:          "HMAC-" + alg.String(),
+		ResponseFormat: &responseFormat{Length: int(length), Encoding: "DECIMAL"},
+	}
+
+	raw, err := decodeKey(secret)
+	if err != nil || len(raw) == 0 {
+		return keyPackage{}, ErrorUnsupported{msg: "malformed secret of key " + k.ID}
+	}
+
+	kp.Key.Data.Secret, err = c.writeValue(raw, base64.StdEncoding.EncodeToString(raw))
+	if err != nil {
+		return keyPackage{}, err
+	}
+
+	return kp, nil
+}
+
+// writeInteger returns the value of n, in decimal or encrypted as big endian
+// bytes.
+func (c *cryptor) writeInteger(n uint64) (*value, error) {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, n)
+
+	return c.writeValue(b, strconv.FormatUint(n, 10))
+}
+
+// writeValue returns the encrypted raw bytes, or the plain text when the
+// container isn't encrypted.
+func (c *cryptor) writeValue(raw []byte, plain string) (*value, error) {
+	if c == nil {
+		return &value{PlainValue: plain}, nil
+	}
+
+	return c.encrypt(raw)
+}
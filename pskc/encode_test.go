@@ -0,0 +1,131 @@
+package pskc
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/jltorresm/otpgo"
+	"github.com/jltorresm/otpgo/config"
+)
+
+func testKeys() []Key {
+	return []Key{
+		{
+			ID:           "hotp-1",
+			Serial:       "987654321",
+			Manufacturer: "Manufacturer",
+			Issuer:       "Issuer",
+			OTP: &otpgo.HOTP{
+				Key:       rfcSecret,
+				Counter:   42,
+				Leeway:    otpgo.HOTPDefaultLeeway,
+				Algorithm: config.HmacSHA1,
+				Length:    config.Length8,
+				ID:        "hotp-1",
+			},
+		},
+		{
+			ID: "totp-1",
+			OTP: &otpgo.TOTP{
+				Key:       rfcSecret,
+				Period:    60,
+				Delay:     otpgo.TOTPDefaultDelay,
+				T0:        1000,
+				Algorithm: config.HmacSHA512,
+				Length:    config.Length6,
+				ID:        "totp-1",
+			},
+		},
+	}
+}
+
+func TestEncoder_Encode(t *testing.T) {
+	cases := []struct {
+		label   string
+		encoder Encoder
+		decoder Decoder
+	}{
+		{"Plain", Encoder{}, Decoder{}},
+		{"Pre-Shared Key", Encoder{PreSharedKey: rfcPreSharedKeyBytes(t), KeyName: "Pre-shared-key"}, Decoder{PreSharedKey: rfcPreSharedKeyBytes(t)}},
+		{"Password", Encoder{Password: "qwerty", Iterations: 1000}, Decoder{Password: "qwerty"}},
+	}
+
+	for _, c := range cases {
+		var out bytes.Buffer
+		if err := c.encoder.Encode(&out, testKeys()); err != nil {
+			t.Errorf("case %s: unexpected error: %s", c.label, err)
+			continue
+		}
+
+		if plain := strings.Contains(out.String(), "<PlainValue>MTIzNDU2Nzg5MDEyMzQ1Njc4OTA="); plain != (c.label == "Plain") {
+			t.Errorf("case %s: unexpected secret protection\n%s", c.label, out.String())
+		}
+
+		keys, err := c.decoder.Decode(&out)
+		if err != nil {
+			t.Errorf("case %s: unexpected error: %s", c.label, err)
+			continue
+		}
+
+		expected := testKeys()
+		if len(keys) != len(expected) {
+			t.Errorf("case %s: unexpected number of keys\nexpected: %d\n  actual: %d", c.label, len(expected), len(keys))
+			continue
+		}
+
+		if *keys[0].OTP.(*otpgo.HOTP) != *expected[0].OTP.(*otpgo.HOTP) {
+			t.Errorf("case %s: unexpected hotp\nexpected: %+v\n  actual: %+v", c.label, expected[0].OTP, keys[0].OTP)
+		}
+
+		if *keys[1].OTP.(*otpgo.TOTP) != *expected[1].OTP.(*otpgo.TOTP) {
+			t.Errorf("case %s: unexpected totp\nexpected: %+v\n  actual: %+v", c.label, expected[1].OTP, keys[1].OTP)
+		}
+
+		keys[0].OTP, keys[1].OTP = nil, nil
+		expected[0].OTP, expected[1].OTP = nil, nil
+		if keys[0] != expected[0] || keys[1] != expected[1] {
+			t.Errorf("case %s: unexpected keys\nexpected: %+v\n  actual: %+v", c.label, expected, keys)
+		}
+	}
+}
+
+func TestEncoder_Encode_Defaults(t *testing.T) {
+	var out bytes.Buffer
+	err := (&Encoder{}).Encode(&out, []Key{{ID: "totp-1", OTP: &otpgo.TOTP{Key: rfcSecret}}})
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		t.FailNow()
+	}
+
+	for _, expected := range []string{"<Suite>HMAC-SHA1</Suite>", `Length="6"`, "<PlainValue>30</PlainValue>"} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("missing %s in\n%s", expected, out.String())
+		}
+	}
+
+	if strings.Contains(out.String(), "<Time>") {
+		t.Errorf("unexpected time in\n%s", out.String())
+	}
+}
+
+func TestEncoder_Encode_Errors(t *testing.T) {
+	cases := []struct {
+		label   string
+		encoder Encoder
+		key     Key
+	}{
+		{"OTP Type", Encoder{}, Key{ID: "motp-1", OTP: &otpgo.MOTP{Key: "1234567890abcdef"}}},
+		{"Malformed Secret", Encoder{}, Key{ID: "hotp-1", OTP: &otpgo.HOTP{Key: "not base32!"}}},
+		{"Empty Secret", Encoder{}, Key{ID: "hotp-1", OTP: &otpgo.HOTP{}}},
+		{"Negative T0", Encoder{}, Key{ID: "totp-1", OTP: &otpgo.TOTP{Key: rfcSecret, T0: -30}}},
+		{"Pre-Shared Key Length", Encoder{PreSharedKey: []byte("short")}, Key{ID: "hotp-1", OTP: &otpgo.HOTP{Key: rfcSecret}}},
+	}
+
+	for _, c := range cases {
+		err := c.encoder.Encode(&bytes.Buffer{}, []Key{c.key})
+		if _, ok := err.(ErrorUnsupported); !ok {
+			t.Errorf("case %s: unexpected error\nexpected: %T\n  actual: %v", c.label, ErrorUnsupported{}, err)
+		}
+	}
+}
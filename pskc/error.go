@@ -0,0 +1,42 @@
+package pskc
+
+import (
+	"fmt"
+)
+
+// The ErrorInvalidFile represents a PSKC file that can not be read.
+type ErrorInvalidFile struct {
+	msg string
+}
+
+func (eif ErrorInvalidFile) Error() string {
+	return fmt.Sprintf("invalid pskc file: %s", eif.msg)
+}
+
+// The ErrorUnsupported represents a PSKC feature, or an OTP type, that the
+// package can't handle.
+type ErrorUnsupported struct {
+	msg string
+}
+
+func (eu ErrorUnsupported) Error() string {
+	return fmt.Sprintf("unsupported pskc content: %s", eu.msg)
+}
+
+// The ErrorDecryption is returned when an encrypted value can not be
+// decrypted, either because of a wrong key or because the file is corrupted.
+type ErrorDecryption struct{}
+
+func (ed ErrorDecryption) Error() string {
+	return "unable to decrypt pskc value: wrong key or corrupted file"
+}
+
+// The ErrorInvalidMAC represents an encrypted value whose MAC doesn't match,
+// meaning the file was tampered with.
+type ErrorInvalidMAC struct {
+	KeyID string
+}
+
+func (eim ErrorInvalidMAC) Error() string {
+	return fmt.Sprintf("invalid mac for key %s", eim.KeyID)
+}
@@ -0,0 +1,24 @@
+package pskc
+
+import (
+	"testing"
+)
+
+func TestErrors(t *testing.T) {
+	cases := []struct {
+		label    string
+		err      error
+		expected string
+	}{
+		{"Invalid File", ErrorInvalidFile{msg: "missing mac key"}, "invalid pskc file: missing mac key"},
+		{"Unsupported", ErrorUnsupported{msg: "version \"2.0\""}, "unsupported pskc content: version \"2.0\""},
+		{"Decryption", ErrorDecryption{}, "unable to decrypt pskc value: wrong key or corrupted file"},
+		{"Invalid MAC", ErrorInvalidMAC{KeyID: "12345678"}, "invalid mac for key 12345678"},
+	}
+
+	for _, c := range cases {
+		if c.expected != c.err.Error() {
+			t.Errorf("case %s: unexpected error\nexpected: %s\n  actual: %s", c.label, c.expected, c.err.Error())
+		}
+	}
+}
@@ -0,0 +1,159 @@
+// Package pskc reads and writes the Portable Symmetric Key Container format
+// (RFC 6030), used by vendors to ship the seeds of hardware OTP tokens.
+//
+// HOTP and TOTP keys are supported, with their algorithm, digits, counter,
+// time step and T0. Secrets may be in plain text, or encrypted with AES-128-CBC
+// using a pre-shared key or a key derived from a password with PBKDF2, in which
+// case their MAC is always verified.
+package pskc
+
+import (
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/xml"
+	"strings"
+
+	"github.com/jltorresm/otpgo"
+)
+
+// Namespace and algorithm identifiers used by PSKC files.
+const (
+	namespacePBE = "http://www.rsasecurity.com/rsalabs/pkcs/schemas/pkcs-5v2-0#"
+
+	algorithmHOTP       = "urn:ietf:params:xml:ns:keyprov:pskc:hotp"
+	algorithmTOTP       = "urn:ietf:params:xml:ns:keyprov:pskc:totp"
+	algorithmAES128CBC  = "http://www.w3.org/2001/04/xmlenc#aes128-cbc"
+	algorithmHMACSHA1   = "http://www.w3.org/2000/09/xmldsig#hmac-sha1"
+	algorithmHMACSHA256 = "http://www.w3.org/2001/04/xmldsig-more#hmac-sha256"
+	algorithmPBKDF2     = "http://www.rsasecurity.com/rsalabs/pkcs/schemas/pkcs-5v2-0#pbkdf2"
+)
+
+// The Key type is an OTP credential held in a PSKC file.
+type Key struct {
+	ID           string    // Identifier of the key within the file
+	Serial       string    // Serial number of the device holding the key
+	Manufacturer string    // Manufacturer of the device
+	Issuer       string    // Issuer of the key, e.g.: the service it was made for
+	OTP          otpgo.OTP // Either an *otpgo.HOTP or an *otpgo.TOTP
+}
+
+// The keyContainer type is the root element of a PSKC file.
+type keyContainer struct {
+	XMLName       xml.Name       `xml:"urn:ietf:params:xml:ns:keyprov:pskc KeyContainer"`
+	Version       string         `xml:"Version,attr"`
+	EncryptionKey *encryptionKey `xml:"EncryptionKey"`
+	MACMethod     *macMethod     `xml:"MACMethod"`
+	KeyPackages   []keyPackage   `xml:"KeyPackage"`
+}
+
+type encryptionKey struct {
+	KeyName    string      `xml:"http://www.w3.org/2000/09/xmldsig# KeyName,omitempty"`
+	DerivedKey *derivedKey `xml:"http://www.w3.org/2009/xmlenc11# DerivedKey"`
+}
+
+type derivedKey struct {
+	KeyDerivationMethod keyDerivationMethod `xml:"http://www.w3.org/2009/xmlenc11# KeyDerivationMethod"`
+	MasterKeyName       string              `xml:"http://www.w3.org/2009/xmlenc11# MasterKeyName,omitempty"`
+}
+
+type keyDerivationMethod struct {
+	Algorithm string        `xml:"Algorithm,attr"`
+	Params    *pbkdf2Params `xml:"http://www.rsasecurity.com/rsalabs/pkcs/schemas/pkcs-5v2-0# PBKDF2-params"`
+}
+
+type pbkdf2Params struct {
+	Salt           string     `xml:"Salt>Specified"` // Base64 encoded
+	IterationCount int        `xml:"IterationCount"`
+	KeyLength      int        `xml:"KeyLength"`
+	PRF            *algorithm `xml:"PRF"`
+}
+
+// MarshalXML writes the parameters with a prefixed name, as their children are
+// unqualified and must not inherit the namespace of the element.
+func (p *pbkdf2Params) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	start = xml.StartElement{
+		Name: xml.Name{Local: "pkcs5:PBKDF2-params"},
+		Attr: []xml.Attr{
+			{Name: xml.Name{Local: "xmlns:pkcs5"}, Value: namespacePBE},
+			{Name: xml.Name{Local: "xmlns"}, Value: ""},
+		},
+	}
+
+	type params pbkdf2Params // Drops the method, to avoid recursing
+
+	return e.EncodeElement((*params)(p), start)
+}
+
+type algorithm struct {
+	Algorithm string `xml:"Algorithm,attr,omitempty"`
+}
+
+type macMethod struct {
+	Algorithm string          `xml:"Algorithm,attr"`
+	MACKey    *encryptedValue `xml:"MACKey"`
+}
+
+type encryptedValue struct {
+	EncryptionMethod algorithm  `xml:"http://www.w3.org/2001/04/xmlenc# EncryptionMethod"`
+	CipherData       cipherData `xml:"http://www.w3.org/2001/04/xmlenc# CipherData"`
+}
+
+type cipherData struct {
+	CipherValue string `xml:"http://www.w3.org/2001/04/xmlenc# CipherValue"` // Base64 encoded IV and ciphertext
+}
+
+type keyPackage struct {
+	DeviceInfo *deviceInfo `xml:"DeviceInfo"`
+	Key        *key        `xml:"Key"`
+}
+
+type deviceInfo struct {
+	Manufacturer string `xml:"Manufacturer,omitempty"`
+	SerialNo     string `xml:"SerialNo,omitempty"`
+}
+
+type key struct {
+	ID                  string               `xml:"Id,attr"`
+	Algorithm           string               `xml:"Algorithm,attr"`
+	Issuer              string               `xml:"Issuer,omitempty"`
+	AlgorithmParameters *algorithmParameters `xml:"AlgorithmParameters"`
+	Data                *keyData             `xml:"Data"`
+}
+
+type algorithmParameters struct {
+	Suite          string          `xml:"Suite,omitempty"`
+	ResponseFormat *responseFormat `xml:"ResponseFormat"`
+}
+
+type responseFormat struct {
+	Length   int    `xml:"Length,attr"`
+	Encoding string `xml:"Encoding,attr"`
+}
+
+type keyData struct {
+	Secret       *value `xml:"Secret"`
+	Counter      *value `xml:"Counter"`
+	Time         *value `xml:"Time"`
+	TimeInterval *value `xml:"TimeInterval"`
+}
+
+// The value type holds any key data, in plain text or encrypted.
+type value struct {
+	PlainValue     string          `xml:"PlainValue,omitempty"`
+	EncryptedValue *encryptedValue `xml:"EncryptedValue"`
+	ValueMAC       string          `xml:"ValueMAC,omitempty"` // Base64 encoded
+}
+
+var keyEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// decodeKey returns the bytes of a base32 key, tolerating lowercase letters
+// and padding.
+func decodeKey(k string) ([]byte, error) {
+	return keyEncoding.DecodeString(strings.TrimRight(strings.ToUpper(k), "="))
+}
+
+// decodeBase64 returns the bytes of the base64 content of an element, ignoring
+// the whitespace around and within it.
+func decodeBase64(s string) ([]byte, error) {
+	return base64.StdEncoding.DecodeString(strings.Join(strings.Fields(s), ""))
+}
@@ -0,0 +1,201 @@
+package qr
+
+import (
+	"strings"
+)
+
+// Segment modes of the data bit stream.
+const (
+	modeTerminator       = 0x0
+	modeNumeric          = 0x1
+	modeAlphanumeric     = 0x2
+	modeStructuredAppend = 0x3
+	modeByte             = 0x4
+	modeFNC1First        = 0x5
+	modeECI              = 0x7
+	modeFNC1Second       = 0x9
+)
+
+const alphanumeric = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ $%*+-./:"
+
+// The bitReader type reads big endian bit fields from the data codewords.
+type bitReader struct {
+	data   []byte
+	offset int // In bits
+}
+
+func (r *bitReader) available() int {
+	return len(r.data)*8 - r.offset
+}
+
+func (r *bitReader) read(n int) (int, bool) {
+	if n > r.available() {
+		return 0, false
+	}
+
+	v := 0
+	for i := 0; i < n; i++ {
+		bit := r.data[(r.offset+i)/8] >> (7 - uint((r.offset+i)%8)) & 1
+		v = v<<1 | int(bit)
+	}
+	r.offset += n
+
+	return v, true
+}
+
+// countBits returns the length of the character count of a mode, which
+// depends on the version.
+func countBits(mode, version int) int {
+	sizes := map[int][3]int{
+		modeNumeric:      {10, 12, 14},
+		modeAlphanumeric: {9, 11, 13},
+		modeByte:         {8, 16, 16},
+	}[mode]
+
+	switch {
+	case version <= 9:
+		return sizes[0]
+	case version <= 26:
+		return sizes[1]
+	}
+
+	return sizes[2]
+}
+
+// decodeSegments returns the text held by the data codewords. Byte segments
+// are expected to be UTF-8, as is the case for otpauth URIs.
+func decodeSegments(data []byte, version int) (string, error) {
+	r := &bitReader{data: data}
+	var text strings.Builder
+
+	for r.available() >= 4 {
+		mode, _ := r.read(4)
+
+		switch mode {
+		case modeTerminator:
+			return text.String(), nil
+		case modeFNC1First:
+		case modeFNC1Second:
+			if _, ok := r.read(8); !ok {
+				return "", ErrorInvalidCode{msg: "truncated fnc1 segment"}
+			}
+		case modeStructuredAppend:
+			if _, ok := r.read(16); !ok {
+				return "", ErrorInvalidCode{msg: "truncated structured append segment"}
+			}
+		case modeECI:
+			if err := skipECI(r); err != nil {
+				return "", err
+			}
+		case modeNumeric, modeAlphanumeric, modeByte:
+			count, ok := r.read(countBits(mode, version))
+			if !ok {
+				return "", ErrorInvalidCode{msg: "truncated character count"}
+			}
+
+			if err := decodeSegment(r, mode, count, &text); err != nil {
+				return "", err
+			}
+		default:
+			return "", ErrorInvalidCode{msg: "unsupported segment mode"}
+		}
+	}
+
+	return text.String(), nil
+}
+
+// skipECI reads the designator of an ECI segment, which is ignored.
+func skipECI(r *bitReader) error {
+	first, ok := r.read(8)
+	switch {
+	case !ok:
+	case first&0x80 == 0:
+		return nil
+	case first&0xc0 == 0x80:
+		_, ok = r.read(8)
+	case first&0xe0 == 0xc0:
+		_, ok = r.read(16)
+	default:
+		ok = false
+	}
+
+	if !ok {
+		return ErrorInvalidCode{msg: "invalid eci designator"}
+	}
+
+	return nil
+}
+
+// decodeSegment appends the count characters of a numeric, alphanumeric or
+// byte segment to the text.
+func decodeSegment(r *bitReader, mode, count int, text *strings.Builder) error {
+	truncated := ErrorInvalidCode{msg: "truncated segment"}
+
+	switch mode {
+	case modeNumeric:
+		for ; count > 0; count -= 3 {
+			digits, size := 3, 10
+			if count == 2 {
+				digits, size = 2, 7
+			} else if count == 1 {
+				digits, size = 1, 4
+			}
+
+			v, ok := r.read(size)
+			if !ok {
+				return truncated
+			}
+
+			s := make([]byte, digits)
+			for i := digits - 1; i >= 0; i-- {
+				s[i] = byte('0' + v%10)
+				v /= 10
+			}
+
+			if v != 0 {
+				return ErrorInvalidCode{msg: "invalid numeric segment"}
+			}
+
+			text.Write(s)
+		}
+	case modeAlphanumeric:
+		for ; count > 0; count -= 2 {
+			if count == 1 {
+				v, ok := r.read(6)
+				if !ok {
+					return truncated
+				}
+
+				if v >= len(alphanumeric) {
+					return ErrorInvalidCode{msg: "invalid alphanumeric segment"}
+				}
+
+				text.WriteByte(alphanumeric[v])
+				break
+			}
+
+			v, ok := r.read(11)
+			if !ok {
+				return truncated
+			}
+
+			if v/45 >= len(alphanumeric) {
+				return ErrorInvalidCode{msg: "invalid alphanumeric segment"}
+			}
+
+			text.WriteByte(alphanumeric[v/45])
+			text.WriteByte(alphanumeric[v%45])
+		}
+	case modeByte:
+		for ; count > 0; count-- {
+			v, ok := r.read(8)
+			if !ok {
+				return truncated
+			}
+
+			text.WriteByte(byte(v))
+		}
+	}
+
+	return nil
+}
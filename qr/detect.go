@@ -0,0 +1,367 @@
+package qr
+
+import (
+	"image"
+	"math"
+	"sort"
+)
+
+// The binaryImage type holds the dark pixels of an image.
+type binaryImage struct {
+	width, height int
+	dark          []bool
+}
+
+// binarize converts the image to dark and light pixels, using Otsu's method
+// to pick the threshold. Transparent pixels are considered light.
+func binarize(img image.Image) *binaryImage {
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+
+	luminance := make([]uint8, w*h)
+	var histogram [256]int
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			r, g, b, a := img.At(bounds.Min.X+x, bounds.Min.Y+y).RGBA()
+
+			// Blend the premultiplied color over a white background.
+			r, g, b = r+0xffff-a, g+0xffff-a, b+0xffff-a
+			l := uint8((19595*r + 38470*g + 7471*b + 1<<15) >> 24)
+
+			luminance[y*w+x] = l
+			histogram[l]++
+		}
+	}
+
+	threshold := otsu(histogram, w*h)
+
+	bi := &binaryImage{width: w, height: h, dark: make([]bool, w*h)}
+	for i, l := range luminance {
+		bi.dark[i] = int(l) <= threshold
+	}
+
+	return bi
+}
+
+// otsu returns the threshold that best separates the two classes of the
+// luminance histogram.
+func otsu(histogram [256]int, total int) int {
+	sum := 0.0
+	for i, count := range histogram {
+		sum += float64(i * count)
+	}
+
+	best, bestVariance := 0, -1.0
+	sumBackground, weightBackground := 0.0, 0
+	for i, count := range histogram {
+		weightBackground += count
+		if weightBackground == 0 {
+			continue
+		}
+
+		weightForeground := total - weightBackground
+		if weightForeground == 0 {
+			break
+		}
+
+		sumBackground += float64(i * count)
+		meanBackground := sumBackground / float64(weightBackground)
+		meanForeground := (sum - sumBackground) / float64(weightForeground)
+
+		variance := float64(weightBackground) * float64(weightForeground) * (meanBackground - meanForeground) * (meanBackground - meanForeground)
+		if variance > bestVariance {
+			best, bestVariance = i, variance
+		}
+	}
+
+	return best
+}
+
+func (bi *binaryImage) at(x, y int) bool {
+	if x < 0 || y < 0 || x >= bi.width || y >= bi.height {
+		return false
+	}
+
+	return bi.dark[y*bi.width+x]
+}
+
+// The finderPattern type is a candidate position of one of the three squares
+// in the corners of a QR code.
+type finderPattern struct {
+	x, y       float64
+	moduleSize float64
+	count      int // Number of times the pattern was found
+}
+
+// finderRatio reports whether the runs of pixels are in the 1:1:3:1:1 ratio
+// of a finder pattern.
+func finderRatio(counts [5]int) bool {
+	total := 0
+	for _, c := range counts {
+		if c == 0 {
+			return false
+		}
+
+		total += c
+	}
+
+	if total < 7 {
+		return false
+	}
+
+	module := float64(total) / 7
+	variance := module / 2
+
+	return math.Abs(module-float64(counts[0])) < variance &&
+		math.Abs(module-float64(counts[1])) < variance &&
+		math.Abs(3*module-float64(counts[2])) < 3*variance &&
+		math.Abs(module-float64(counts[3])) < variance &&
+		math.Abs(module-float64(counts[4])) < variance
+}
+
+// findFinderPatterns scans the rows of the image for finder patterns, and
+// checks every candidate across its column and row.
+func findFinderPatterns(bi *binaryImage) []finderPattern {
+	var found []finderPattern
+
+	for y := 0; y < bi.height; y++ {
+		var counts [5]int
+		state := 0
+
+		for x := 0; x <= bi.width; x++ {
+			dark := x < bi.width && bi.at(x, y)
+			if x < bi.width && dark == (state%2 == 0) {
+				counts[state]++
+				continue
+			}
+
+			if state == 0 && counts[0] == 0 {
+				continue
+			}
+
+			if state < 4 {
+				state++
+				counts[state] = 1
+				continue
+			}
+
+			// The pattern ends with the dark run that just finished.
+			if p, ok := bi.checkFinder(counts, x, y); ok {
+				found = addFinder(found, p)
+			}
+
+			counts = [5]int{counts[2], counts[3], counts[4], 1, 0}
+			state = 3
+		}
+	}
+
+	return found
+}
+
+// checkFinder cross checks the horizontal runs ending before x, and returns
+// the refined center of the pattern.
+func (bi *binaryImage) checkFinder(counts [5]int, x, y int) (finderPattern, bool) {
+	if !finderRatio(counts) {
+		return finderPattern{}, false
+	}
+
+	total := 0
+	for _, c := range counts {
+		total += c
+	}
+
+	centerX := float64(x-counts[4]-counts[3]) - float64(counts[2])/2
+
+	column := func(i int) bool { return bi.at(int(centerX), i) }
+	centerY, vertical, ok := crossCheck(column, bi.height, y, counts[2], total)
+	if !ok {
+		return finderPattern{}, false
+	}
+
+	row := func(i int) bool { return bi.at(i, int(centerY)) }
+	centerX, horizontal, ok := crossCheck(row, bi.width, int(centerX), counts[2], total)
+	if !ok {
+		return finderPattern{}, false
+	}
+
+	return finderPattern{x: centerX, y: centerY, moduleSize: float64(vertical+horizontal) / 14, count: 1}, true
+}
+
+// crossCheck measures the runs of a finder pattern along a line of n pixels,
+// starting from its center. It returns the center along the line and the total
+// length of the pattern, which must be close to the expected one.
+func crossCheck(line func(int) bool, n, start, maxCount, expected int) (float64, int, bool) {
+	var counts [5]int
+
+	i := start
+	for ; i >= 0 && line(i); i-- {
+		counts[2]++
+	}
+	for ; i >= 0 && !line(i) && counts[1] <= maxCount; i-- {
+		counts[1]++
+	}
+	for ; i >= 0 && line(i) && counts[0] <= maxCount; i-- {
+		counts[0]++
+	}
+
+	i = start + 1
+	for ; i < n && line(i); i++ {
+		counts[2]++
+	}
+	for ; i < n && !line(i) && counts[3] <= maxCount; i++ {
+		counts[3]++
+	}
+	for ; i < n && line(i) && counts[4] <= maxCount; i++ {
+		counts[4]++
+	}
+
+	total := 0
+	for _, c := range counts {
+		total += c
+	}
+
+	if !finderRatio(counts) || 5*abs(total-expected) >= 2*expected {
+		return 0, 0, false
+	}
+
+	return float64(i-counts[4]-counts[3]) - float64(counts[2])/2, total, true
+}
+
+// addFinder adds the pattern to the candidates, merging it with the one at the
+// same position if any.
+func addFinder(found []finderPattern, p finderPattern) []finderPattern {
+	for i, f := range found {
+		if math.Abs(f.x-p.x) <= f.moduleSize && math.Abs(f.y-p.y) <= f.moduleSize && math.Abs(f.moduleSize-p.moduleSize) <= math.Max(1, f.moduleSize/2) {
+			n := float64(f.count)
+			found[i] = finderPattern{
+				x:          (f.x*n + p.x) / (n + 1),
+				y:          (f.y*n + p.y) / (n + 1),
+				moduleSize: (f.moduleSize*n + p.moduleSize) / (n + 1),
+				count:      f.count + 1,
+			}
+
+			return found
+		}
+	}
+
+	return append(found, p)
+}
+
+// selectFinders returns the top left, top right and bottom left patterns of
+// the QR code: the three candidates of similar sizes forming the closest to
+// an isosceles right triangle.
+func selectFinders(found []finderPattern) (tl, tr, bl finderPattern, ok bool) {
+	sort.Slice(found, func(i, j int) bool { return found[i].count > found[j].count })
+	if len(found) > 10 {
+		found = found[:10]
+	}
+
+	bestScore := math.Inf(1)
+	for i := 0; i < len(found); i++ {
+		for j := i + 1; j < len(found); j++ {
+			for k := j + 1; k < len(found); k++ {
+				a, b, c := found[i], found[j], found[k]
+				score := triangleScore(a, b, c)
+				if score < bestScore {
+					bestScore = score
+					tl, tr, bl = orient(a, b, c)
+				}
+			}
+		}
+	}
+
+	return tl, tr, bl, bestScore < 0.5
+}
+
+// triangleScore returns how far the patterns are from the corners of a QR
+// code, 0 being a perfect match.
+func triangleScore(a, b, c finderPattern) float64 {
+	sides := []float64{distance2(a, b), distance2(b, c), distance2(a, c)}
+	sort.Float64s(sides)
+
+	sizes := []float64{a.moduleSize, b.moduleSize, c.moduleSize}
+	sort.Float64s(sizes)
+
+	if sides[0] == 0 {
+		return math.Inf(1)
+	}
+
+	return math.Abs(sides[2]-sides[0]-sides[1])/sides[2] +
+		math.Abs(sides[1]-sides[0])/sides[1] +
+		(sizes[2]-sizes[0])/sizes[2]
+}
+
+// orient returns the patterns as top left, top right and bottom left. The top
+// left one is opposite to the longest side.
+func orient(a, b, c finderPattern) (tl, tr, bl finderPattern) {
+	ab, bc, ac := distance2(a, b), distance2(b, c), distance2(a, c)
+
+	switch {
+	case bc >= ab && bc >= ac:
+		tl, tr, bl = a, b, c
+	case ac >= ab && ac >= bc:
+		tl, tr, bl = b, a, c
+	default:
+		tl, tr, bl = c, a, b
+	}
+
+	// With the y axis pointing down, the top right pattern is clockwise from
+	// the bottom left one.
+	if (tr.x-tl.x)*(bl.y-tl.y)-(tr.y-tl.y)*(bl.x-tl.x) < 0 {
+		tr, bl = bl, tr
+	}
+
+	return tl, tr, bl
+}
+
+// estimateDimension returns the number of modules on each side of the code,
+// from the distance between the finder patterns.
+func estimateDimension(tl, tr, bl finderPattern) int {
+	module := (tl.moduleSize + tr.moduleSize + bl.moduleSize) / 3
+	modules := (math.Sqrt(distance2(tl, tr))+math.Sqrt(distance2(tl, bl)))/(2*module) + 7
+
+	// Dimensions are 4 * version + 17.
+	version := int(math.Round((modules - 17) / 4))
+	if version < 1 {
+		version = 1
+	}
+
+	if version > 40 {
+		version = 40
+	}
+
+	return dimension(version)
+}
+
+// sample reads the modules of the code, mapping the grid on the image with the
+// affine transform given by the centers of the finder patterns.
+func sample(bi *binaryImage, tl, tr, bl finderPattern, dim int) *bitMatrix {
+	m := newBitMatrix(dim)
+	span := float64(dim - 7)
+
+	for row := 0; row < dim; row++ {
+		for col := 0; col < dim; col++ {
+			u := (float64(col) - 3) / span
+			v := (float64(row) - 3) / span
+
+			x := tl.x + u*(tr.x-tl.x) + v*(bl.x-tl.x)
+			y := tl.y + u*(tr.y-tl.y) + v*(bl.y-tl.y)
+
+			m.set(row, col, bi.at(int(math.Floor(x)), int(math.Floor(y))))
+		}
+	}
+
+	return m
+}
+
+func distance2(a, b finderPattern) float64 {
+	return (a.x-b.x)*(a.x-b.x) + (a.y-b.y)*(a.y-b.y)
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+
+	return n
+}
@@ -0,0 +1,33 @@
+package qr
+
+import (
+	"fmt"
+)
+
+// The ErrorInvalidImage is returned when the image data is neither a PNG nor
+// a JPEG, or is corrupted.
+type ErrorInvalidImage struct {
+	msg string
+}
+
+func (eii ErrorInvalidImage) Error() string {
+	return fmt.Sprintf("invalid image: %s", eii.msg)
+}
+
+// The ErrorNotFound is returned when no QR code could be located in the
+// image.
+type ErrorNotFound struct{}
+
+func (enf ErrorNotFound) Error() string {
+	return "no qr code found in image"
+}
+
+// The ErrorInvalidCode represents a QR code that was located, but can't be
+// decoded, e.g.: it is damaged beyond what its error correction can fix.
+type ErrorInvalidCode struct {
+	msg string
+}
+
+func (eic ErrorInvalidCode) Error() string {
+	return fmt.Sprintf("invalid qr code: %s", eic.msg)
+}
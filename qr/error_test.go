@@ -0,0 +1,23 @@
+package qr
+
+import (
+	"testing"
+)
+
+func TestErrors(t *testing.T) {
+	cases := []struct {
+		label    string
+		err      error
+		expected string
+	}{
+		{"Invalid Image", ErrorInvalidImage{msg: "unknown format"}, "invalid image: unknown format"},
+		{"Not Found", ErrorNotFound{}, "no qr code found in image"},
+		{"Invalid Code", ErrorInvalidCode{msg: "too many errors"}, "invalid qr code: too many errors"},
+	}
+
+	for _, c := range cases {
+		if c.expected != c.err.Error() {
+			t.Errorf("case %s: unexpected error\nexpected: %s\n  actual: %s", c.label, c.expected, c.err.Error())
+		}
+	}
+}
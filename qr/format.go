@@ -0,0 +1,112 @@
+package qr
+
+import (
+	"math/bits"
+)
+
+const (
+	formatGenerator  = 0x537  // BCH(15, 5) generator polynomial
+	formatMask       = 0x5412 // XORed with the format information
+	versionGenerator = 0x1f25 // BCH(18, 6) generator polynomial
+
+	// maxInfoErrors is the number of bit errors tolerated when reading the
+	// format and version information, both codes correct 3 errors.
+	maxInfoErrors = 3
+)
+
+// bch returns the data followed by its BCH error correction bits.
+func bch(data uint32, generator uint32, dataBits uint) uint32 {
+	degree := uint(bits.Len32(generator) - 1)
+	value := data << degree
+	for i := dataBits + degree - 1; i >= degree; i-- {
+		if value&(1<<i) != 0 {
+			value ^= generator << (i - degree)
+		}
+	}
+
+	return data<<degree | value
+}
+
+// decodeFormat returns the error correction level and the data mask of the
+// symbol, read from either copy of the format information.
+func decodeFormat(m *bitMatrix) (level, int, bool) {
+	dim := m.size
+	var first, second uint32
+
+	read := func(v *uint32, row, col int) {
+		*v <<= 1
+		if m.get(row, col) {
+			*v |= 1
+		}
+	}
+
+	for col := 0; col <= 5; col++ {
+		read(&first, 8, col)
+	}
+	read(&first, 8, 7)
+	read(&first, 8, 8)
+	read(&first, 7, 8)
+	for row := 5; row >= 0; row-- {
+		read(&first, row, 8)
+	}
+
+	for row := dim - 1; row >= dim-7; row-- {
+		read(&second, row, 8)
+	}
+	for col := dim - 8; col < dim; col++ {
+		read(&second, 8, col)
+	}
+
+	best, bestDistance := uint32(0), maxInfoErrors+1
+	for data := uint32(0); data < 32; data++ {
+		code := bch(data, formatGenerator, 5) ^ formatMask
+		for _, v := range []uint32{first, second} {
+			if d := bits.OnesCount32(code ^ v); d < bestDistance {
+				best, bestDistance = data, d
+			}
+		}
+	}
+
+	if bestDistance > maxInfoErrors {
+		return 0, 0, false
+	}
+
+	return level(best >> 3), int(best & 7), true
+}
+
+// decodeVersion returns the version of the symbol, read from either copy of
+// the version information. Only symbols from version 7 have one.
+func decodeVersion(m *bitMatrix) (int, bool) {
+	dim := m.size
+	var first, second uint32
+
+	for row := 5; row >= 0; row-- {
+		for col := dim - 9; col >= dim-11; col-- {
+			first <<= 1
+			if m.get(row, col) {
+				first |= 1
+			}
+		}
+	}
+
+	for col := 5; col >= 0; col-- {
+		for row := dim - 9; row >= dim-11; row-- {
+			second <<= 1
+			if m.get(row, col) {
+				second |= 1
+			}
+		}
+	}
+
+	best, bestDistance := 0, maxInfoErrors+1
+	for version := 7; version <= 40; version++ {
+		code := bch(uint32(version), versionGenerator, 6)
+		for _, v := range []uint32{first, second} {
+			if d := bits.OnesCount32(code ^ v); d < bestDistance {
+				best, bestDistance = version, d
+			}
+		}
+	}
+
+	return best, bestDistance <= maxInfoErrors
+}
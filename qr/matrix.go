@@ -0,0 +1,28 @@
+package qr
+
+// The bitMatrix type is a square grid of modules, true being dark.
+type bitMatrix struct {
+	size int
+	bits []bool
+}
+
+func newBitMatrix(size int) *bitMatrix {
+	return &bitMatrix{size: size, bits: make([]bool, size*size)}
+}
+
+func (m *bitMatrix) get(row, col int) bool {
+	return m.bits[row*m.size+col]
+}
+
+func (m *bitMatrix) set(row, col int, dark bool) {
+	m.bits[row*m.size+col] = dark
+}
+
+// setRegion marks the modules of a rectangle as dark.
+func (m *bitMatrix) setRegion(row, col, height, width int) {
+	for r := row; r < row+height; r++ {
+		for c := col; c < col+width; c++ {
+			m.set(r, c, true)
+		}
+	}
+}
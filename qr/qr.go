@@ -0,0 +1,84 @@
+// Package qr decodes QR codes from images, in pure Go, so that the key URIs
+// of authenticator.KeyUri.QRCode can be read back.
+//
+// The decoder looks for a single QR code, upright or rotated, as found in
+// screenshots, scans and generated images. Photos taken at a strong angle are
+// not supported. Numeric, alphanumeric and byte segments are decoded, the
+// latter as UTF-8.
+package qr
+
+import (
+	"image"
+	"io"
+
+	// Register the supported image formats.
+	_ "image/jpeg"
+	_ "image/png"
+)
+
+// Decode returns the text held by the QR code of the image.
+func Decode(img image.Image) (string, error) {
+	bi := binarize(img)
+
+	tl, tr, bl, ok := selectFinders(findFinderPatterns(bi))
+	if !ok {
+		return "", ErrorNotFound{}
+	}
+
+	// The estimated dimension may be off by a version, try the neighbouring
+	// ones when it doesn't decode.
+	estimate := estimateDimension(tl, tr, bl)
+
+	var err error
+	for _, dim := range []int{estimate, estimate + 4, estimate - 4} {
+		if dim < dimension(1) || dim > dimension(40) {
+			continue
+		}
+
+		var text string
+		if text, err = decodeMatrix(sample(bi, tl, tr, bl, dim)); err == nil {
+			return text, nil
+		}
+	}
+
+	return "", err
+}
+
+// DecodeReader decodes a PNG or JPEG image, and returns the text held by its
+// QR code.
+func DecodeReader(r io.Reader) (string, error) {
+	img, _, err := image.Decode(r)
+	if err != nil {
+		return "", ErrorInvalidImage{msg: err.Error()}
+	}
+
+	return Decode(img)
+}
+
+// decodeMatrix returns the text held by the modules of a QR code.
+func decodeMatrix(m *bitMatrix) (string, error) {
+	l, mask, ok := decodeFormat(m)
+	if !ok {
+		return "", ErrorInvalidCode{msg: "unreadable format information"}
+	}
+
+	version := (m.size - 17) / 4
+	if version >= 7 {
+		if v, ok := decodeVersion(m); !ok || v != version {
+			return "", ErrorInvalidCode{msg: "unreadable version information"}
+		}
+	}
+
+	b := blocksOf(version, l)
+
+	var data []byte
+	for _, block := range deinterleave(readCodewords(m, version, mask), b) {
+		if !correct(block, b.ecCodewords) {
+			return "", ErrorInvalidCode{msg: "too many errors"}
+		}
+
+		data = append(data, block[:len(block)-b.ecCodewords]...)
+	}
+
+	return decodeSegments(data, version)
+}
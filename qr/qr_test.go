@@ -0,0 +1,231 @@
+package qr
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/skip2/go-qrcode"
+)
+
+const testURI = "otpauth://totp/A%20Company:john.doe@example.org?algorithm=SHA1&digits=6&issuer=A+Company&period=30&secret=73QK7D3A3PIZ6NUQQBF4BNFYQBRVUHUQ"
+
+// encode returns the QR code of the content, with the smallest version when
+// version is 0.
+func encode(t *testing.T, content string, version int, level qrcode.RecoveryLevel) *qrcode.QRCode {
+	q, err := qrcode.New(content, level)
+	if version != 0 {
+		q, err = qrcode.NewWithForcedVersion(content, version, level)
+	}
+
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		t.FailNow()
+	}
+
+	return q
+}
+
+func TestDecode_AllVersionsAndLevels(t *testing.T) {
+	levels := []qrcode.RecoveryLevel{qrcode.Low, qrcode.Medium, qrcode.High, qrcode.Highest}
+
+	for version := 1; version <= 40; version++ {
+		// Encoding the large versions is slow, past version 10 each one is
+		// tested with a single level, taken in turn.
+		tested := levels
+		if version > 10 {
+			tested = levels[version%len(levels) : version%len(levels)+1]
+		}
+
+		for _, level := range tested {
+			content := fmt.Sprintf("v%d/%d", version, level)
+			q := encode(t, content, version, level)
+
+			text, err := Decode(q.Image(0))
+			if err != nil {
+				t.Errorf("version %d level %d: unexpected error: %s", version, level, err)
+				continue
+			}
+
+			if text != content {
+				t.Errorf("version %d level %d: unexpected text\nexpected: %s\n  actual: %s", version, level, content, text)
+			}
+		}
+	}
+}
+
+// render draws the modules of the code, scale pixels per module and rotated
+// by angle degrees around the center of the image.
+func render(q *qrcode.QRCode, scale int, angle float64, damage func(bitmap [][]bool)) image.Image {
+	bitmap := q.Bitmap()
+	if damage != nil {
+		damage(bitmap)
+	}
+
+	size := len(bitmap) * scale
+	canvas := size * 3 / 2
+	img := image.NewGray(image.Rect(0, 0, canvas, canvas))
+
+	sin, cos := math.Sincos(angle * math.Pi / 180)
+	center := float64(canvas) / 2
+	for y := 0; y < canvas; y++ {
+		for x := 0; x < canvas; x++ {
+			// Rotate the pixel back into the unrotated code.
+			dx, dy := float64(x)+0.5-center, float64(y)+0.5-center
+			sx := cos*dx + sin*dy + float64(size)/2
+			sy := -sin*dx + cos*dy + float64(size)/2
+
+			row, col := int(math.Floor(sy))/scale, int(math.Floor(sx))/scale
+			dark := sx >= 0 && sy >= 0 && row < len(bitmap) && col < len(bitmap) && bitmap[row][col]
+
+			img.SetGray(x, y, color.Gray{Y: 255})
+			if dark {
+				img.SetGray(x, y, color.Gray{Y: 0})
+			}
+		}
+	}
+
+	return img
+}
+
+func TestDecode_Images(t *testing.T) {
+	q := encode(t, testURI, 0, qrcode.Medium)
+
+	flip := func(modules ...[2]int) func([][]bool) {
+		return func(bitmap [][]bool) {
+			for _, m := range modules {
+				// The bitmap includes a 4 modules quiet zone.
+				bitmap[m[0]+4][m[1]+4] = !bitmap[m[0]+4][m[1]+4]
+			}
+		}
+	}
+
+	cases := []struct {
+		label string
+		img   image.Image
+	}{
+		{"Generated PNG Size", q.Image(256)},
+		{"Large", q.Image(1200)},
+		{"Rotated 90", render(q, 4, 90, nil)},
+		{"Rotated 180", render(q, 4, 180, nil)},
+		{"Rotated 270", render(q, 5, 270, nil)},
+		{"Rotated 10", render(q, 6, 10, nil)},
+		{"Rotated -25", render(q, 6, -25, nil)},
+		{"Damaged", render(q, 3, 0, flip([2]int{20, 20}, [2]int{21, 22}, [2]int{30, 12}, [2]int{12, 30}, [2]int{40, 40}, [2]int{10, 45}))},
+	}
+
+	for _, c := range cases {
+		text, err := Decode(c.img)
+		if err != nil {
+			t.Errorf("case %s: unexpected error: %s", c.label, err)
+			continue
+		}
+
+		if text != testURI {
+			t.Errorf("case %s: unexpected text\nexpected: %s\n  actual: %s", c.label, testURI, text)
+		}
+	}
+}
+
+func TestDecodeReader(t *testing.T) {
+	q := encode(t, testURI, 0, qrcode.Medium)
+
+	png, err := q.PNG(256)
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		t.FailNow()
+	}
+
+	var jpg bytes.Buffer
+	if err := jpeg.Encode(&jpg, render(q, 5, 3, nil), &jpeg.Options{Quality: 60}); err != nil {
+		t.Errorf("unexpected error: %s", err)
+		t.FailNow()
+	}
+
+	for label, data := range map[string][]byte{"PNG": png, "JPEG": jpg.Bytes()} {
+		text, err := DecodeReader(bytes.NewReader(data))
+		if err != nil {
+			t.Errorf("case %s: unexpected error: %s", label, err)
+			continue
+		}
+
+		if text != testURI {
+			t.Errorf("case %s: unexpected text\nexpected: %s\n  actual: %s", label, testURI, text)
+		}
+	}
+
+	_, err = DecodeReader(bytes.NewReader([]byte("not an image")))
+	if reflect.TypeOf(err) != reflect.TypeOf(ErrorInvalidImage{}) {
+		t.Errorf("unexpected error\nexpected: %T\n  actual: %T", ErrorInvalidImage{}, err)
+	}
+}
+
+func TestDecode_Contents(t *testing.T) {
+	cases := []struct {
+		label   string
+		content string
+	}{
+		{"Numeric", "01234567890123456789"},
+		{"Alphanumeric", "OTPAUTH://TOTP/A:B?SECRET=GEZDGNBV $%*+-./"},
+		{"Mixed", "otpauth://hotp/1234567890123456?SECRET=GEZDGNBVGY3TQOJQ&counter=12345678"},
+		{"UTF-8", "otpauth://totp/Caf%C3%A9:jos%C3%A9?issuer=Café&secret=GEZDGNBV"},
+		{"Long", "otpauth://totp/x?secret=" + strings.Repeat("GEZDGNBVGY3TQOJQ", 80)},
+	}
+
+	for _, c := range cases {
+		q := encode(t, c.content, 0, qrcode.Highest)
+
+		text, err := Decode(q.Image(0))
+		if err != nil {
+			t.Errorf("case %s: unexpected error: %s", c.label, err)
+			continue
+		}
+
+		if text != c.content {
+			t.Errorf("case %s: unexpected text\nexpected: %s\n  actual: %s", c.label, c.content, text)
+		}
+	}
+}
+
+func TestDecode_Errors(t *testing.T) {
+	blank := image.NewGray(image.Rect(0, 0, 200, 200))
+	for i := range blank.Pix {
+		blank.Pix[i] = 255
+	}
+
+	q := encode(t, testURI, 0, qrcode.Low)
+	var modules [][2]int
+	for i := 9; i < 30; i++ {
+		for j := 9; j < 30; j++ {
+			modules = append(modules, [2]int{i, j})
+		}
+	}
+
+	destroyed := render(q, 3, 0, func(bitmap [][]bool) {
+		for _, m := range modules {
+			bitmap[m[0]+4][m[1]+4] = !bitmap[m[0]+4][m[1]+4]
+		}
+	})
+
+	cases := []struct {
+		label    string
+		img      image.Image
+		expected error
+	}{
+		{"Blank", blank, ErrorNotFound{}},
+		{"Too Damaged", destroyed, ErrorInvalidCode{}},
+	}
+
+	for _, c := range cases {
+		_, err := Decode(c.img)
+		if reflect.TypeOf(err) != reflect.TypeOf(c.expected) {
+			t.Errorf("case %s: unexpected error\nexpected: %T\n  actual: %v", c.label, c.expected, err)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package qr
+
+// GF(256) arithmetic with the QR code primitive polynomial
+// x^8 + x^4 + x^3 + x^2 + 1.
+var (
+	gfExp [512]byte
+	gfLog [256]int
+)
+
+func init() {
+	x := 1
+	for i := 0; i < 255; i++ {
+		gfExp[i] = byte(x)
+		gfLog[x] = i
+
+		x <<= 1
+		if x&0x100 != 0 {
+			x ^= 0x11d
+		}
+	}
+
+	for i := 255; i < len(gfExp); i++ {
+		gfExp[i] = gfExp[i-255]
+	}
+}
+
+func gfMul(a, b byte) byte {
+	if a == 0 || b == 0 {
+		return 0
+	}
+
+	return gfExp[gfLog[a]+gfLog[b]]
+}
+
+func gfDiv(a, b byte) byte {
+	if a == 0 {
+		return 0
+	}
+
+	return gfExp[gfLog[a]+255-gfLog[b]]
+}
+
+// gfPow returns alpha^n.
+func gfPow(n int) byte {
+	n %= 255
+	if n < 0 {
+		n += 255
+	}
+
+	return gfExp[n]
+}
+
+// evaluate returns the value of the polynomial at x. The coefficients are in
+// ascending order of degree.
+func evaluate(poly []byte, x byte) byte {
+	var y byte
+	for i := len(poly) - 1; i >= 0; i-- {
+		y = gfMul(y, x) ^ poly[i]
+	}
+
+	return y
+}
+
+// correct fixes the errors of a Reed-Solomon block in place, the last
+// ecCodewords bytes being the error correction ones. It reports false when
+// there are more errors than the block can correct.
+func correct(block []byte, ecCodewords int) bool {
+	n := len(block)
+
+	// The codeword at index i is the coefficient of degree n-1-i.
+	syndromes := make([]byte, ecCodewords)
+	clean := true
+	for i := range syndromes {
+		var s byte
+		x := gfPow(i)
+		for _, c := range block {
+			s = gfMul(s, x) ^ c
+		}
+
+		syndromes[i] = s
+		clean = clean && s == 0
+	}
+
+	if clean {
+		return true
+	}
+
+	locator := berlekampMassey(syndromes)
+	errors := len(locator) - 1
+	if errors*2 > ecCodewords {
+		return false
+	}
+
+	// The error evaluator is S(x) * L(x) mod x^ecCodewords.
+	evaluator := make([]byte, ecCodewords)
+	for i := range evaluator {
+		for j := 0; j <= i && j < len(locator); j++ {
+			evaluator[i] ^= gfMul(locator[j], syndromes[i-j])
+		}
+	}
+
+	// The formal derivative only keeps the odd terms in characteristic 2.
+	derivative := make([]byte, len(locator)-1)
+	for i := 1; i < len(locator); i += 2 {
+		derivative[i-1] = locator[i]
+	}
+
+	found := 0
+	for degree := 0; degree < n; degree++ {
+		inverse := gfPow(-degree)
+		if evaluate(locator, inverse) != 0 {
+			continue
+		}
+
+		// Forney algorithm, the first root of the generator being alpha^0.
+		magnitude := gfMul(gfPow(degree), gfDiv(evaluate(evaluator, inverse), evaluate(derivative, inverse)))
+		block[n-1-degree] ^= magnitude
+		found++
+	}
+
+	return found == errors
+}
+
+// berlekampMassey returns the error locator polynomial of the syndromes, in
+// ascending order of degree.
+func berlekampMassey(syndromes []byte) []byte {
+	current, previous := []byte{1}, []byte{1}
+	length, shift := 0, 1
+	var lastDiscrepancy byte = 1
+
+	for n := range syndromes {
+		discrepancy := syndromes[n]
+		for i := 1; i <= length && i < len(current); i++ {
+			discrepancy ^= gfMul(current[i], syndromes[n-i])
+		}
+
+		if discrepancy == 0 {
+			shift++
+			continue
+		}
+
+		factor := gfDiv(discrepancy, lastDiscrepancy)
+		next := make([]byte, max(len(current), len(previous)+shift))
+		copy(next, current)
+		for i, c := range previous {
+			next[i+shift] ^= gfMul(factor, c)
+		}
+
+		if 2*length <= n {
+			previous, current = current, next
+			length, lastDiscrepancy, shift = n+1-length, discrepancy, 1
+		} else {
+			current = next
+			shift++
+		}
+	}
+
+	// Trailing zero coefficients don't change the degree of the polynomial.
+	for len(current) > 1 && current[len(current)-1] == 0 {
+		current = current[:len(current)-1]
+	}
+
+	return current
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+
+	return b
+}
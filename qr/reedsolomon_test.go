@@ -0,0 +1,41 @@
+package qr
+
+import (
+	"bytes"
+	"testing"
+)
+
+// The codewords of "HELLO WORLD" in a version 1-M symbol, 16 data codewords
+// followed by 10 error correction ones.
+var helloWorld = []byte{
+	32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17,
+	196, 35, 39, 119, 235, 215, 231, 226, 93, 23,
+}
+
+func TestCorrect(t *testing.T) {
+	cases := []struct {
+		label  string
+		errors map[int]byte
+	}{
+		{"No Errors", nil},
+		{"One Error", map[int]byte{0: 0xff}},
+		{"Error Correction Codeword", map[int]byte{25: 1}},
+		{"Max Errors", map[int]byte{1: 1, 5: 0x80, 10: 0x33, 17: 0xaa, 24: 7}},
+	}
+
+	for _, c := range cases {
+		block := append([]byte{}, helloWorld...)
+		for i, e := range c.errors {
+			block[i] ^= e
+		}
+
+		if !correct(block, 10) {
+			t.Errorf("case %s: unexpected uncorrectable block", c.label)
+			continue
+		}
+
+		if !bytes.Equal(helloWorld, block) {
+			t.Errorf("case %s: unexpected block\nexpected: %v\n  actual: %v", c.label, helloWorld, block)
+		}
+	}
+}
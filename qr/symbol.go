@@ -0,0 +1,252 @@
+package qr
+
+// The level type is an error correction level, in the order of the format
+// information bits: M, L, H, Q.
+type level int
+
+const (
+	levelM level = iota
+	levelL
+	levelH
+	levelQ
+)
+
+// The blocks type describes how the codewords of a symbol are split into
+// Reed-Solomon blocks: every block has ecCodewords error correction codewords,
+// the first group of blocks has dataCodewords data codewords and the second
+// one has a data codeword more.
+type blocks struct {
+	ecCodewords   int
+	group1        int // Number of blocks of the first group
+	dataCodewords int // Data codewords of the blocks of the first group
+	group2        int // Number of blocks of the second group
+}
+
+// blockTable holds the blocks of every version, for the levels L, M, Q and H.
+var blockTable = [40][4]blocks{
+	{{7, 1, 19, 0}, {10, 1, 16, 0}, {13, 1, 13, 0}, {17, 1, 9, 0}},           // 1
+	{{10, 1, 34, 0}, {16, 1, 28, 0}, {22, 1, 22, 0}, {28, 1, 16, 0}},         // 2
+	{{15, 1, 55, 0}, {26, 1, 44, 0}, {18, 2, 17, 0}, {22, 2, 13, 0}},         // 3
+	{{20, 1, 80, 0}, {18, 2, 32, 0}, {26, 2, 24, 0}, {16, 4, 9, 0}},          // 4
+	{{26, 1, 108, 0}, {24, 2, 43, 0}, {18, 2, 15, 2}, {22, 2, 11, 2}},        // 5
+	{{18, 2, 68, 0}, {16, 4, 27, 0}, {24, 4, 19, 0}, {28, 4, 15, 0}},         // 6
+	{{20, 2, 78, 0}, {18, 4, 31, 0}, {18, 2, 14, 4}, {26, 4, 13, 1}},         // 7
+	{{24, 2, 97, 0}, {22, 2, 38, 2}, {22, 4, 18, 2}, {26, 4, 14, 2}},         // 8
+	{{30, 2, 116, 0}, {22, 3, 36, 2}, {20, 4, 16, 4}, {24, 4, 12, 4}},        // 9
+	{{18, 2, 68, 2}, {26, 4, 43, 1}, {24, 6, 19, 2}, {28, 6, 15, 2}},         // 10
+	{{20, 4, 81, 0}, {30, 1, 50, 4}, {28, 4, 22, 4}, {24, 3, 12, 8}},         // 11
+	{{24, 2, 92, 2}, {22, 6, 36, 2}, {26, 4, 20, 6}, {28, 7, 14, 4}},         // 12
+	{{26, 4, 107, 0}, {22, 8, 37, 1}, {24, 8, 20, 4}, {22, 12, 11, 4}},       // 13
+	{{30, 3, 115, 1}, {24, 4, 40, 5}, {20, 11, 16, 5}, {24, 11, 12, 5}},      // 14
+	{{22, 5, 87, 1}, {24, 5, 41, 5}, {30, 5, 24, 7}, {24, 11, 12, 7}},        // 15
+	{{24, 5, 98, 1}, {28, 7, 45, 3}, {24, 15, 19, 2}, {30, 3, 15, 13}},       // 16
+	{{28, 1, 107, 5}, {28, 10, 46, 1}, {28, 1, 22, 15}, {28, 2, 14, 17}},     // 17
+	{{30, 5, 120, 1}, {26, 9, 43, 4}, {28, 17, 22, 1}, {28, 2, 14, 19}},      // 18
+	{{28, 3, 113, 4}, {26, 3, 44, 11}, {26, 17, 21, 4}, {26, 9, 13, 16}},     // 19
+	{{28, 3, 107, 5}, {26, 3, 41, 13}, {30, 15, 24, 5}, {28, 15, 15, 10}},    // 20
+	{{28, 4, 116, 4}, {26, 17, 42, 0}, {28, 17, 22, 6}, {30, 19, 16, 6}},     // 21
+	{{28, 2, 111, 7}, {28, 17, 46, 0}, {30, 7, 24, 16}, {24, 34, 13, 0}},     // 22
+	{{30, 4, 121, 5}, {28, 4, 47, 14}, {30, 11, 24, 14}, {30, 16, 15, 14}},   // 23
+	{{30, 6, 117, 4}, {28, 6, 45, 14}, {30, 11, 24, 16}, {30, 30, 16, 2}},    // 24
+	{{26, 8, 106, 4}, {28, 8, 47, 13}, {30, 7, 24, 22}, {30, 22, 15, 13}},    // 25
+	{{28, 10, 114, 2}, {28, 19, 46, 4}, {28, 28, 22, 6}, {30, 33, 16, 4}},    // 26
+	{{30, 8, 122, 4}, {28, 22, 45, 3}, {30, 8, 23, 26}, {30, 12, 15, 28}},    // 27
+	{{30, 3, 117, 10}, {28, 3, 45, 23}, {30, 4, 24, 31}, {30, 11, 15, 31}},   // 28
+	{{30, 7, 116, 7}, {28, 21, 45, 7}, {30, 1, 23, 37}, {30, 19, 15, 26}},    // 29
+	{{30, 5, 115, 10}, {28, 19, 47, 10}, {30, 15, 24, 25}, {30, 23, 15, 25}}, // 30
+	{{30, 13, 115, 3}, {28, 2, 46, 29}, {30, 42, 24, 1}, {30, 23, 15, 28}},   // 31
+	{{30, 17, 115, 0}, {28, 10, 46, 23}, {30, 10, 24, 35}, {30, 19, 15, 35}}, // 32
+	{{30, 17, 115, 1}, {28, 14, 46, 21}, {30, 29, 24, 19}, {30, 11, 15, 46}}, // 33
+	{{30, 13, 115, 6}, {28, 14, 46, 23}, {30, 44, 24, 7}, {30, 59, 16, 1}},   // 34
+	{{30, 12, 121, 7}, {28, 12, 47, 26}, {30, 39, 24, 14}, {30, 22, 15, 41}}, // 35
+	{{30, 6, 121, 14}, {28, 6, 47, 34}, {30, 46, 24, 10}, {30, 2, 15, 64}},   // 36
+	{{30, 17, 122, 4}, {28, 29, 46, 14}, {30, 49, 24, 10}, {30, 24, 15, 46}}, // 37
+	{{30, 4, 122, 18}, {28, 13, 46, 32}, {30, 48, 24, 14}, {30, 42, 15, 32}}, // 38
+	{{30, 20, 117, 4}, {28, 40, 47, 7}, {30, 43, 24, 22}, {30, 10, 15, 67}},  // 39
+	{{30, 19, 118, 6}, {28, 18, 47, 31}, {30, 34, 24, 34}, {30, 20, 15, 61}}, // 40
+}
+
+// blocksOf returns the blocks of a version and error correction level.
+func blocksOf(version int, l level) blocks {
+	index := [...]int{levelL: 0, levelM: 1, levelQ: 2, levelH: 3}[l]
+
+	return blockTable[version-1][index]
+}
+
+// dimension returns the number of modules on each side of a version.
+func dimension(version int) int {
+	return 17 + 4*version
+}
+
+// alignmentPositions returns the row and column coordinates of the alignment
+// patterns of a version.
+func alignmentPositions(version int) []int {
+	if version == 1 {
+		return nil
+	}
+
+	count := version/7 + 2
+	step := 26
+	if version != 32 {
+		step = (version*4 + count*2 + 1) / (count*2 - 2) * 2
+	}
+
+	positions := make([]int, count)
+	positions[0] = 6
+	for i, pos := count-1, dimension(version)-7; i >= 1; i, pos = i-1, pos-step {
+		positions[i] = pos
+	}
+
+	return positions
+}
+
+// functionPatterns returns the modules that don't hold data: finder patterns
+// and their separators, format and version information, timing and alignment
+// patterns.
+func functionPatterns(version int) *bitMatrix {
+	dim := dimension(version)
+	m := newBitMatrix(dim)
+
+	m.setRegion(0, 0, 9, 9)
+	m.setRegion(0, dim-8, 9, 8)
+	m.setRegion(dim-8, 0, 8, 9)
+
+	positions := alignmentPositions(version)
+	last := len(positions) - 1
+	for i, row := range positions {
+		for j, col := range positions {
+			if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+				continue
+			}
+
+			m.setRegion(row-2, col-2, 5, 5)
+		}
+	}
+
+	m.setRegion(9, 6, dim-17, 1)
+	m.setRegion(6, 9, 1, dim-17)
+
+	if version >= 7 {
+		m.setRegion(0, dim-11, 6, 3)
+		m.setRegion(dim-11, 0, 3, 6)
+	}
+
+	return m
+}
+
+// masked reports whether the data mask pattern flips the module.
+func masked(mask, row, col int) bool {
+	switch mask {
+	case 0:
+		return (row+col)%2 == 0
+	case 1:
+		return row%2 == 0
+	case 2:
+		return col%3 == 0
+	case 3:
+		return (row+col)%3 == 0
+	case 4:
+		return (row/2+col/3)%2 == 0
+	case 5:
+		return row*col%2+row*col%3 == 0
+	case 6:
+		return (row*col%2+row*col%3)%2 == 0
+	default:
+		return ((row+col)%2+row*col%3)%2 == 0
+	}
+}
+
+// readCodewords unmasks the data modules and reads them as codewords, in the
+// zigzag order of the symbol.
+func readCodewords(m *bitMatrix, version, mask int) []byte {
+	dim := m.size
+	function := functionPatterns(version)
+	b := blocksOf(version, levelL)
+	total := (b.group1+b.group2)*(b.ecCodewords+b.dataCodewords) + b.group2
+
+	codewords := make([]byte, 0, total)
+	var current byte
+	bits := 0
+	up := true
+
+	for col := dim - 1; col > 0; col -= 2 {
+		if col == 6 {
+			// The vertical timing pattern is skipped as a whole.
+			col--
+		}
+
+		for count := 0; count < dim; count++ {
+			row := count
+			if up {
+				row = dim - 1 - count
+			}
+
+			for c := col; c > col-2; c-- {
+				if function.get(row, c) {
+					continue
+				}
+
+				current <<= 1
+				if m.get(row, c) != masked(mask, row, c) {
+					current |= 1
+				}
+
+				if bits++; bits == 8 {
+					codewords = append(codewords, current)
+					current, bits = 0, 0
+				}
+			}
+		}
+
+		up = !up
+	}
+
+	if len(codewords) > total {
+		codewords = codewords[:total]
+	}
+
+	return codewords
+}
+
+// deinterleave splits the codewords of the symbol into its blocks. Data
+// codewords come first, one from each block in turn, then the error
+// correction ones.
+func deinterleave(codewords []byte, b blocks) [][]byte {
+	count := b.group1 + b.group2
+	result := make([][]byte, count)
+	for i := range result {
+		size := b.dataCodewords + b.ecCodewords
+		if i >= b.group1 {
+			size++
+		}
+
+		result[i] = make([]byte, size)
+	}
+
+	offset := 0
+	for i := 0; i < b.dataCodewords; i++ {
+		for j := range result {
+			result[j][i] = codewords[offset]
+			offset++
+		}
+	}
+
+	for j := b.group1; j < count; j++ {
+		result[j][b.dataCodewords] = codewords[offset]
+		offset++
+	}
+
+	for i := b.dataCodewords; i < b.dataCodewords+b.ecCodewords; i++ {
+		for j := range result {
+			index := i
+			if j >= b.group1 {
+				index++
+			}
+
+			result[j][index] = codewords[offset]
+			offset++
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,237 @@
+package otpgo
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/jltorresm/otpgo/authenticator"
+	"github.com/jltorresm/otpgo/internal/clock"
+)
+
+// DefaultRotationGrace is how long the previous secret of a Rotating
+// credential is accepted after a rotation, unless the new one is used first.
+const DefaultRotationGrace = 7 * 24 * time.Hour
+
+// Secrets of a Rotating credential, as given in the Event of a successful
+// validation.
+const (
+	// GenerationCurrent means the token matched the newest secret.
+	GenerationCurrent = "current"
+	// GenerationPrevious means the token matched the secret being retired.
+	GenerationPrevious = "previous"
+)
+
+// The Rotating type is a credential whose secret is being replaced, e.g.:
+// after a suspected leak or when the user changes device. Tokens are validated
+// against both the current and the previous OTP until the current one is used
+// for the first time or the grace period ends, whichever happens first. Then
+// the previous OTP is retired for good.
+//
+// The OTPs are HOTP or TOTP configs, and are modified by validations, e.g.: an
+// HOTP counter advances, so they must not be used elsewhere while wrapped. It
+// is safe for concurrent use.
+type Rotating struct {
+	Current  OTP
+	Previous OTP       // Accepted during the grace period, nil once retired
+	Deadline time.Time // When Previous is retired, zero to retire it only on the first use of Current
+
+	ID       string           // Identifies the credential in validation events
+	Grace    time.Duration    // Grace period of new rotations, defaults to DefaultRotationGrace
+	Observer Observer         // Optional, notified of every validation
+	Now      func() time.Time // Defaults to time.Now
+
+	mu sync.Mutex
+}
+
+var _ OTP = (*Rotating)(nil)
+
+// The rotatingJSON type is the JSON representation of a Rotating credential.
+// The OTPs are stored with their type, see Envelope.
+type rotatingJSON struct {
+	Current  Envelope   `json:"current"`
+	Previous Envelope   `json:"previous"`
+	Deadline *time.Time `json:"deadline,omitempty"`
+}
+
+// Rotate makes next the current OTP. The current one becomes the previous and
+// is accepted for the grace period, any older secret is retired immediately.
+func (r *Rotating) Rotate(next OTP) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.Previous, r.Current = r.Current, next
+	r.Deadline = clock.Now(r.Now).Add(r.grace())
+}
+
+// Retire drops the previous OTP before the end of the grace period.
+func (r *Rotating) Retire() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.retire()
+}
+
+// Generate returns the code of the current OTP.
+func (r *Rotating) Generate() (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.Current == nil {
+		return "", ErrorInvalidConfig{msg: "missing current otp"}
+	}
+
+	return r.Current.Generate()
+}
+
+// Validate checks the token against the current OTP and, during the grace
+// period, against the previous one. A token matching the current OTP retires
+// the previous one.
+//
+// The Observer, if any, is notified of the outcome with ID as the credential
+// id, and with the Generation of the matching secret on success. The
+// observers of the wrapped OTPs are notified of their own checks as usual.
+func (r *Rotating) Validate(token string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.Current == nil {
+		notify(r.Observer, failure(r.ID, "", ReasonError))
+		return false, ErrorInvalidConfig{msg: "missing current otp"}
+	}
+
+	if !r.Deadline.IsZero() && !clock.Now(r.Now).Before(r.Deadline) {
+		r.retire()
+	}
+
+	valid, e, err := validateEvent(r.Current, token)
+	if valid {
+		r.retire()
+		e.Generation = GenerationCurrent
+	} else if err == nil && r.Previous != nil {
+		var previous Event
+		if valid, previous, err = validateEvent(r.Previous, token); valid {
+			e = previous
+			e.Generation = GenerationPrevious
+		} else if err != nil {
+			e = previous
+		}
+	}
+
+	if r.ID != "" {
+		e.CredentialID = r.ID
+	}
+	notify(r.Observer, e)
+
+	return valid, err
+}
+
+// KeyUri returns the key URI of the current OTP, to register it in the user's
+// authenticator app. It is nil when there is no current OTP.
+func (r *Rotating) KeyUri(accountName, issuer string) *authenticator.KeyUri {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.Current == nil {
+		return nil
+	}
+
+	return r.Current.KeyUri(accountName, issuer)
+}
+
+// Type returns the type of the current OTP.
+func (r *Rotating) Type() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.Current == nil {
+		return ""
+	}
+
+	return r.Current.Type()
+}
+
+// MarshalJSON encodes the OTPs along with their type, and the deadline of the
+// grace period. The settings of the credential, e.g.: Grace, are not encoded.
+func (r *Rotating) MarshalJSON() ([]byte, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	raw := rotatingJSON{Current: Envelope{OTP: r.Current}, Previous: Envelope{OTP: r.Previous}}
+	if !r.Deadline.IsZero() {
+		raw.Deadline = &r.Deadline
+	}
+
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON decodes a credential encoded by MarshalJSON, keeping its
+// settings.
+func (r *Rotating) UnmarshalJSON(data []byte) error {
+	raw := rotatingJSON{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.Current, r.Previous, r.Deadline = raw.Current.OTP, raw.Previous.OTP, time.Time{}
+	if raw.Deadline != nil {
+		r.Deadline = *raw.Deadline
+	}
+
+	return nil
+}
+
+func (r *Rotating) retire() {
+	r.Previous, r.Deadline = nil, time.Time{}
+}
+
+func (r *Rotating) grace() time.Duration {
+	if r.Grace <= 0 {
+		return DefaultRotationGrace
+	}
+
+	return r.Grace
+}
+
+// validateEvent validates the token and returns the event describing the
+// outcome, notifying the observer of the OTP as its Validate method does. OTPs
+// of other types than HOTP, TOTP and MOTP are described by a plain event,
+// without offset.
+func validateEvent(o OTP, token string) (bool, Event, error) {
+	var (
+		valid    bool
+		reason   string
+		offset   int64
+		err      error
+		id       string
+		observer Observer
+	)
+
+	switch v := o.(type) {
+	case *HOTP:
+		valid, reason, offset, err = v.validate(token)
+		id, observer = v.ID, v.Observer
+	case *TOTP:
+		var steps int
+		valid, reason, steps, err = v.validate(token, nil)
+		offset, id, observer = int64(steps), v.ID, v.Observer
+	case *MOTP:
+		var steps int
+		valid, reason, steps, err = v.validate(token)
+		offset, id, observer = int64(steps), v.ID, v.Observer
+	default:
+		valid, err = o.Validate(token)
+		reason = ReasonInvalidToken
+		if err != nil {
+			reason = ReasonError
+		}
+	}
+
+	e := outcome(id, o.Type(), valid, reason, offset)
+	notify(observer, e)
+
+	return valid, e, err
+}
@@ -0,0 +1,321 @@
+package otpgo
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jltorresm/otpgo/config"
+)
+
+const (
+	rotationOldKey = "73QK7D3A3PIZ6NUQQBF4BNFYQBRVUHUP"
+	rotationNewKey = "JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP"
+)
+
+// hotpCode returns the HOTP code of the key for the given counter.
+func hotpCode(t *testing.T, key string, counter uint64) string {
+	h := HOTP{Key: key, Counter: counter, Algorithm: config.HmacSHA1}
+
+	code, err := h.Generate()
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		t.FailNow()
+	}
+
+	return code
+}
+
+func TestRotating_Rotate(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	r := &Rotating{ID: "john", Current: &HOTP{Key: rotationOldKey, Counter: 10, Algorithm: config.HmacSHA1}, Now: func() time.Time { return now }}
+	r.Rotate(&HOTP{Key: rotationNewKey, Algorithm: config.HmacSHA1})
+
+	if r.Current.(*HOTP).Key != rotationNewKey || r.Previous.(*HOTP).Key != rotationOldKey {
+		t.Errorf("unexpected secrets\ncurrent: %+v\nprevious: %+v", r.Current, r.Previous)
+	}
+
+	if expected := now.Add(DefaultRotationGrace); !expected.Equal(r.Deadline) {
+		t.Errorf("unexpected deadline\nexpected: %s\n  actual: %s", expected, r.Deadline)
+	}
+
+	// Rotating again retires the oldest secret right away.
+	r.Grace = time.Hour
+	r.Rotate(&HOTP{Key: rotationOldKey, Algorithm: config.HmacSHA1})
+
+	if r.Previous.(*HOTP).Key != rotationNewKey {
+		t.Errorf("unexpected previous secret: %+v", r.Previous)
+	}
+
+	if expected := now.Add(time.Hour); !expected.Equal(r.Deadline) {
+		t.Errorf("unexpected deadline\nexpected: %s\n  actual: %s", expected, r.Deadline)
+	}
+
+	r.Retire()
+
+	if r.Previous != nil || !r.Deadline.IsZero() {
+		t.Errorf("expected the previous secret to be retired: %+v", r.Previous)
+	}
+}
+
+func TestRotating_Validate(t *testing.T) {
+	events := &eventRecorder{}
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	r := &Rotating{ID: "john", Current: &HOTP{Key: rotationOldKey, Counter: 10, Algorithm: config.HmacSHA1}, Observer: events, Now: func() time.Time { return now }}
+	r.Rotate(&HOTP{Key: rotationNewKey, Algorithm: config.HmacSHA1})
+
+	cases := []struct {
+		label            string
+		token            string
+		expectedValid    bool
+		expectedEvent    Event
+		expectedPrevious bool
+	}{
+		{
+			"Previous Secret",
+			hotpCode(t, rotationOldKey, 11),
+			true,
+			Event{CredentialID: "john", Type: TypeHOTP, Success: true, Offset: 1, Generation: GenerationPrevious},
+			true,
+		},
+		{"Wrong", "000000", false, failure("john", TypeHOTP, ReasonInvalidToken), true},
+		{"Short", "123", false, failure("john", TypeHOTP, ReasonInvalidFormat), true},
+		{
+			"Current Secret",
+			hotpCode(t, rotationNewKey, 0),
+			true,
+			Event{CredentialID: "john", Type: TypeHOTP, Success: true, Generation: GenerationCurrent},
+			false,
+		},
+		{"Retired Secret", hotpCode(t, rotationOldKey, 12), false, failure("john", TypeHOTP, ReasonInvalidToken), false},
+		{
+			"Current After Retirement",
+			hotpCode(t, rotationNewKey, 1),
+			true,
+			Event{CredentialID: "john", Type: TypeHOTP, Success: true, Generation: GenerationCurrent},
+			false,
+		},
+	}
+
+	for _, c := range cases {
+		valid, err := r.Validate(c.token)
+		if err != nil {
+			t.Errorf("case %s: unexpected error: %s", c.label, err)
+			continue
+		}
+
+		if c.expectedValid != valid {
+			t.Errorf("case %s: unexpected result\nexpected: %t\n  actual: %t", c.label, c.expectedValid, valid)
+		}
+
+		if actual := events.last(); c.expectedEvent != actual {
+			t.Errorf("case %s: unexpected event\nexpected: %+v\n  actual: %+v", c.label, c.expectedEvent, actual)
+		}
+
+		if c.expectedPrevious != (r.Previous != nil) {
+			t.Errorf("case %s: unexpected previous secret: %+v", c.label, r.Previous)
+		}
+	}
+}
+
+func TestRotating_Deadline(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	r := &Rotating{ID: "john", Current: &HOTP{Key: rotationOldKey, Counter: 10, Algorithm: config.HmacSHA1}, Now: func() time.Time { return now }}
+	r.Rotate(&HOTP{Key: rotationNewKey, Algorithm: config.HmacSHA1})
+	r.Current = &TOTP{Key: rotationNewKey, Algorithm: config.HmacSHA1}
+	r.Previous = &TOTP{Key: rotationOldKey, Algorithm: config.HmacSHA1}
+	r.Deadline = now.Add(time.Hour)
+
+	old := &TOTP{Key: rotationOldKey, Algorithm: config.HmacSHA1}
+	token, err := old.Generate()
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		t.FailNow()
+	}
+
+	if valid, err := r.Validate(token); !valid || err != nil {
+		t.Errorf("expected the previous secret to be valid before the deadline, got %t, %v", valid, err)
+	}
+
+	now = now.Add(time.Hour)
+
+	if valid, err := r.Validate(token); valid || err != nil {
+		t.Errorf("expected the previous secret to be invalid after the deadline, got %t, %v", valid, err)
+	}
+
+	if r.Previous != nil {
+		t.Errorf("expected the previous secret to be retired: %+v", r.Previous)
+	}
+}
+
+func TestRotating_NoDeadline(t *testing.T) {
+	r := &Rotating{
+		Current:  &HOTP{Key: rotationNewKey, Algorithm: config.HmacSHA1},
+		Previous: &HOTP{Key: rotationOldKey, Counter: 10, Algorithm: config.HmacSHA1},
+		Now:      func() time.Time { return time.Now().Add(10 * DefaultRotationGrace) },
+	}
+
+	if valid, err := r.Validate(hotpCode(t, rotationOldKey, 10)); !valid || err != nil {
+		t.Errorf("expected the previous secret to be valid without deadline, got %t, %v", valid, err)
+	}
+}
+
+func TestRotating_WrappedObservers(t *testing.T) {
+	outer, inner := &eventRecorder{}, &eventRecorder{}
+	previous := &HOTP{Key: rotationOldKey, Counter: 10, Algorithm: config.HmacSHA1, ID: "old", Observer: inner}
+	r := &Rotating{Current: &HOTP{Key: rotationNewKey, Algorithm: config.HmacSHA1}, Previous: previous, Observer: outer}
+
+	if _, err := r.Validate(hotpCode(t, rotationOldKey, 10)); err != nil {
+		t.Errorf("unexpected error: %s", err)
+		t.FailNow()
+	}
+
+	if expected := success("old", TypeHOTP, 0); expected != inner.last() {
+		t.Errorf("unexpected inner event\nexpected: %+v\n  actual: %+v", expected, inner.last())
+	}
+
+	// Without ID, the event keeps the one of the matching OTP.
+	expected := Event{CredentialID: "old", Type: TypeHOTP, Success: true, Generation: GenerationPrevious}
+	if expected != outer.last() {
+		t.Errorf("unexpected event\nexpected: %+v\n  actual: %+v", expected, outer.last())
+	}
+
+	if previous.Observer != inner {
+		t.Error("expected the observer of the wrapped otp to be untouched")
+	}
+
+	if previous.Counter != 11 {
+		t.Errorf("unexpected counter\nexpected: %d\n  actual: %d", 11, previous.Counter)
+	}
+}
+
+func TestRotating_ConcurrentObserverRead(t *testing.T) {
+	inner := &eventRecorder{}
+	current := &HOTP{Key: rotationNewKey, Algorithm: config.HmacSHA1, Length: config.Length6, Observer: inner}
+	r := &Rotating{Current: current}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			_, _ = r.Validate("000000")
+		}
+	}()
+
+	// The caller keeps owning the wrapped otp, reading its observer while it is
+	// validated must not race.
+	for i := 0; i < 100; i++ {
+		if current.Observer != inner {
+			t.Error("expected the observer of the wrapped otp to be untouched")
+			break
+		}
+	}
+
+	<-done
+}
+
+func TestRotating_JSON(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	r := &Rotating{ID: "john", Current: &HOTP{Key: rotationOldKey, Counter: 10, Algorithm: config.HmacSHA1}, Now: func() time.Time { return now }}
+	r.Rotate(&HOTP{Key: rotationNewKey, Algorithm: config.HmacSHA1})
+
+	raw, err := json.Marshal(r)
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		t.FailNow()
+	}
+
+	decoded := &Rotating{ID: "john"}
+	if err := json.Unmarshal(raw, decoded); err != nil {
+		t.Errorf("unexpected error: %s", err)
+		t.FailNow()
+	}
+
+	current, previous := Normalize(r.Current), Normalize(r.Previous)
+	if !reflect.DeepEqual(current, decoded.Current) || !reflect.DeepEqual(previous, decoded.Previous) {
+		t.Errorf("unexpected otps\nexpected: %+v %+v\n  actual: %+v %+v", current, previous, decoded.Current, decoded.Previous)
+	}
+
+	if !r.Deadline.Equal(decoded.Deadline) || decoded.ID != "john" {
+		t.Errorf("unexpected credential\nexpected: %s\n  actual: %s %q", r.Deadline, decoded.Deadline, decoded.ID)
+	}
+
+	r.Retire()
+
+	if raw, err = json.Marshal(r); err != nil {
+		t.Errorf("unexpected error: %s", err)
+		t.FailNow()
+	}
+
+	if err := json.Unmarshal(raw, decoded); err != nil {
+		t.Errorf("unexpected error: %s", err)
+		t.FailNow()
+	}
+
+	if decoded.Previous != nil || !decoded.Deadline.IsZero() {
+		t.Errorf("expected the retired secret to stay retired: %+v", decoded.Previous)
+	}
+}
+
+func TestRotating_OTP(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	r := &Rotating{ID: "john", Current: &HOTP{Key: rotationOldKey, Counter: 10, Algorithm: config.HmacSHA1}, Now: func() time.Time { return now }}
+	r.Rotate(&HOTP{Key: rotationNewKey, Algorithm: config.HmacSHA1})
+
+	code, err := r.Generate()
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		t.FailNow()
+	}
+
+	if expected := hotpCode(t, rotationNewKey, 0); expected != code {
+		t.Errorf("unexpected code\nexpected: %s\n  actual: %s", expected, code)
+	}
+
+	if r.Type() != TypeHOTP {
+		t.Errorf("unexpected type\nexpected: %s\n  actual: %s", TypeHOTP, r.Type())
+	}
+
+	expected := r.Current.KeyUri("john", "Acme").String()
+	if actual := r.KeyUri("john", "Acme").String(); expected != actual {
+		t.Errorf("unexpected key uri\nexpected: %s\n  actual: %s", expected, actual)
+	}
+}
+
+func TestRotating_Errors(t *testing.T) {
+	events := &eventRecorder{}
+	r := &Rotating{ID: "john", Observer: events}
+
+	if _, err := r.Generate(); reflect.TypeOf(err) != reflect.TypeOf(ErrorInvalidConfig{}) {
+		t.Errorf("unexpected error\nexpected: %T\n  actual: %T", ErrorInvalidConfig{}, err)
+	}
+
+	if _, err := r.Validate("123456"); reflect.TypeOf(err) != reflect.TypeOf(ErrorInvalidConfig{}) {
+		t.Errorf("unexpected error\nexpected: %T\n  actual: %T", ErrorInvalidConfig{}, err)
+	}
+
+	if expected := failure("john", "", ReasonError); expected != events.last() {
+		t.Errorf("unexpected event\nexpected: %+v\n  actual: %+v", expected, events.last())
+	}
+
+	if r.Type() != "" {
+		t.Errorf("unexpected type: %q", r.Type())
+	}
+
+	if ku := r.KeyUri("john", "Acme"); ku != nil {
+		t.Errorf("unexpected key uri: %+v", ku)
+	}
+
+	// An invalid previous secret is reported, the current one was checked.
+	r.Current = &HOTP{Key: rotationNewKey, Algorithm: config.HmacSHA1}
+	r.Previous = &HOTP{Key: "1NVAL1D", Algorithm: config.HmacSHA1}
+
+	if _, err := r.Validate("123456"); err == nil {
+		t.Error("expected an error validating with an invalid previous secret")
+	}
+
+	if expected := failure("john", TypeHOTP, ReasonError); expected != events.last() {
+		t.Errorf("unexpected event\nexpected: %+v\n  actual: %+v", expected, events.last())
+	}
+}
@@ -0,0 +1,15 @@
+package sheet
+
+import (
+	"fmt"
+)
+
+// The ErrorInvalidSheet represents a sheet that can't be rendered, e.g.: one
+// without a key URI, or whose key URI has no secret.
+type ErrorInvalidSheet struct {
+	msg string
+}
+
+func (eis ErrorInvalidSheet) Error() string {
+	return fmt.Sprintf("invalid enrollment sheet: %s", eis.msg)
+}
@@ -0,0 +1,167 @@
+// Package sheet renders printable enrollment sheets, for users registering
+// an OTP secret on paper or on a device without a camera.
+//
+// A sheet is a self-contained HTML document: the QR code of the key URI, the
+// secret grouped in blocks of 4 characters for manual entry, the issuer and
+// account, and a list of single use recovery codes. It has no external
+// resources, so it can be printed or saved offline. Sheets hold the secret in
+// plain text and must be handled like the secret itself.
+package sheet
+
+import (
+	"crypto/rand"
+	"html/template"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/jltorresm/otpgo/authenticator"
+	"github.com/jltorresm/otpgo/internal/clock"
+)
+
+const (
+	// DefaultTitle is the heading of sheets without a custom title.
+	DefaultTitle = "Two-Factor Authentication"
+	// DefaultRecoveryCodes is the number of recovery codes made by
+	// NewRecoveryCodes when no count is given.
+	DefaultRecoveryCodes = 10
+)
+
+// recoveryAlphabet is the Crockford base32 alphabet, without the letters that
+// are easily mistaken for digits when read from paper.
+const recoveryAlphabet = "0123456789abcdefghjkmnpqrstvwxyz"
+
+// The Sheet type describes the enrollment sheet of a single account.
+type Sheet struct {
+	KeyUri        *authenticator.KeyUri
+	RecoveryCodes []string         // Optional, listed in the order given
+	Title         string           // Defaults to DefaultTitle
+	Now           func() time.Time // Defaults to time.Now, printed as the issue date
+}
+
+// The page type holds the values rendered by the template.
+type page struct {
+	Title         string
+	Issuer        string
+	AccountName   string
+	Type          string
+	QRCode        template.URL
+	Secret        string
+	Algorithm     string
+	Digits        string
+	Period        string
+	Counter       string
+	RecoveryCodes []string
+	Issued        string
+}
+
+// Render writes the sheet as an HTML document.
+func (s *Sheet) Render(w io.Writer) error {
+	p, err := s.page()
+	if err != nil {
+		return err
+	}
+
+	return sheetTemplate.Execute(w, p)
+}
+
+// page validates the key URI and collects the values of the sheet.
+func (s *Sheet) page() (*page, error) {
+	if s.KeyUri == nil {
+		return nil, ErrorInvalidSheet{msg: "missing key uri"}
+	}
+
+	uri, err := s.KeyUri.Build()
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, ErrorInvalidSheet{msg: err.Error()}
+	}
+
+	params := u.Query()
+	if params.Get("secret") == "" {
+		return nil, ErrorInvalidSheet{msg: "missing secret"}
+	}
+
+	qr, err := s.KeyUri.QRCode()
+	if err != nil {
+		return nil, err
+	}
+
+	return &page{
+		Title:         s.title(),
+		Issuer:        s.KeyUri.Label.Issuer,
+		AccountName:   s.KeyUri.Label.AccountName,
+		Type:          strings.ToUpper(s.KeyUri.Type),
+		QRCode:        template.URL(qr), // Generated by QRCode, safe to use as an image source
+		Secret:        GroupSecret(params.Get("secret")),
+		Algorithm:     params.Get("algorithm"),
+		Digits:        params.Get("digits"),
+		Period:        params.Get("period"),
+		Counter:       params.Get("counter"),
+		RecoveryCodes: s.RecoveryCodes,
+		Issued:        clock.Now(s.Now).Format("2006-01-02"),
+	}, nil
+}
+
+func (s *Sheet) title() string {
+	if s.Title == "" {
+		return DefaultTitle
+	}
+
+	return s.Title
+}
+
+// GroupSecret formats a base32 secret for manual entry: upper case, without
+// padding, in blocks of 4 characters separated by spaces.
+func GroupSecret(secret string) string {
+	secret = strings.TrimRight(strings.ToUpper(strings.Replace(secret, " ", "", -1)), "=")
+
+	var b strings.Builder
+	for i, r := range secret {
+		if i > 0 && i%4 == 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
+
+// NewRecoveryCodes returns n random recovery codes, DefaultRecoveryCodes if n
+// is not positive. Each code has 50 bits of entropy, written as two blocks of
+// 5 characters, e.g.: 7kq2m-x9d4h.
+//
+// The codes are only meant to be printed, storing them (preferably hashed)
+// and accepting each one once is up to the caller.
+func NewRecoveryCodes(n int) ([]string, error) {
+	if n <= 0 {
+		n = DefaultRecoveryCodes
+	}
+
+	codes := make([]string, n)
+	buff := make([]byte, 10)
+	for i := range codes {
+		if _, err := rand.Read(buff); err != nil {
+			return nil, err
+		}
+
+		// The alphabet has 32 characters, 5 bits of each byte pick one
+		// without bias.
+		code := make([]byte, 0, 11)
+		for j, b := range buff {
+			if j == 5 {
+				code = append(code, '-')
+			}
+			code = append(code, recoveryAlphabet[b&0x1f])
+		}
+
+		codes[i] = string(code)
+	}
+
+	return codes, nil
+}
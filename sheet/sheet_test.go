@@ -0,0 +1,219 @@
+package sheet
+
+import (
+	"bytes"
+	"encoding/base64"
+	"html"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jltorresm/otpgo"
+	"github.com/jltorresm/otpgo/authenticator"
+	"github.com/jltorresm/otpgo/config"
+)
+
+var imagePattern = regexp.MustCompile(`src="data:image/png;base64,([^"]+)"`)
+
+func TestSheet_Render(t *testing.T) {
+	totp := otpgo.TOTP{Key: "JBSWY3DPEHPK3PXPJBSWY3DP", Algorithm: config.HmacSHA256, Length: config.Length8, Period: 60}
+	s := &Sheet{
+		KeyUri:        totp.KeyUri("john@example.com", "Acme & Co"),
+		RecoveryCodes: []string{"7kq2m-x9d4h", "0a1b2-c3d4e"},
+		Now:           func() time.Time { return time.Date(2024, 5, 17, 10, 0, 0, 0, time.UTC) },
+	}
+
+	var out bytes.Buffer
+	if err := s.Render(&out); err != nil {
+		t.Errorf("unexpected error: %s", err)
+		t.FailNow()
+	}
+
+	page := out.String()
+
+	expected := []string{
+		"<title>Two-Factor Authentication - Acme &amp; Co</title>",
+		"<strong>Acme &amp; Co</strong> &middot; john@example.com",
+		`<p class="secret">JBSW Y3DP EHPK 3PXP JBSW Y3DP</p>`,
+		"<dt>Type</dt><dd>TOTP</dd>",
+		"<dt>Algorithm</dt><dd>SHA256</dd>",
+		"<dt>Digits</dt><dd>8</dd>",
+		"<dt>Period</dt><dd>60 seconds</dd>",
+		"<li>7kq2m-x9d4h</li>",
+		"<li>0a1b2-c3d4e</li>",
+		"Issued on 2024-05-17.",
+	}
+
+	for _, e := range expected {
+		if !strings.Contains(page, e) {
+			t.Errorf("expected the sheet to contain %q", e)
+		}
+	}
+
+	if strings.Contains(page, "<dt>Counter</dt>") {
+		t.Error("expected no counter for a totp sheet")
+	}
+
+	if strings.Contains(page, "http://") || strings.Contains(page, "https://") {
+		t.Error("expected the sheet to have no external resources")
+	}
+}
+
+func TestSheet_RenderQRCode(t *testing.T) {
+	totp := otpgo.TOTP{Key: "JBSWY3DPEHPK3PXPJBSWY3DP", Algorithm: config.HmacSHA256, Length: config.Length8, Period: 60}
+	s := &Sheet{
+		KeyUri:        totp.KeyUri("john@example.com", "Acme & Co"),
+		RecoveryCodes: []string{"7kq2m-x9d4h", "0a1b2-c3d4e"},
+		Now:           func() time.Time { return time.Date(2024, 5, 17, 10, 0, 0, 0, time.UTC) },
+	}
+
+	var out bytes.Buffer
+	if err := s.Render(&out); err != nil {
+		t.Errorf("unexpected error: %s", err)
+		t.FailNow()
+	}
+
+	match := imagePattern.FindStringSubmatch(out.String())
+	if match == nil {
+		t.Error("expected the sheet to embed the qr code")
+		t.FailNow()
+	}
+
+	// The base64 data is escaped like any attribute value.
+	png, err := base64.StdEncoding.DecodeString(html.UnescapeString(match[1]))
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		t.FailNow()
+	}
+
+	ku, err := authenticator.ParseQRCode(bytes.NewReader(png))
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		t.FailNow()
+	}
+
+	if s.KeyUri.String() != ku.String() {
+		t.Errorf("unexpected uri\nexpected: %s\n  actual: %s", s.KeyUri.String(), ku.String())
+	}
+}
+
+func TestSheet_RenderOptional(t *testing.T) {
+	hotp := otpgo.HOTP{Key: "JBSWY3DPEHPK3PXP", Counter: 42, Algorithm: config.HmacSHA1, Length: config.Length6}
+	s := &Sheet{KeyUri: hotp.KeyUri("<b>john</b>", ""), Title: "Your Token"}
+
+	var out bytes.Buffer
+	if err := s.Render(&out); err != nil {
+		t.Errorf("unexpected error: %s", err)
+		t.FailNow()
+	}
+
+	page := out.String()
+
+	expected := []string{
+		"<title>Your Token</title>",
+		`<p class="account">&lt;b&gt;john&lt;/b&gt;</p>`,
+		"<dt>Type</dt><dd>HOTP</dd>",
+		"<dt>Counter</dt><dd>42</dd>",
+		"Issued on " + time.Now().Format("2006-01-02"),
+	}
+
+	for _, e := range expected {
+		if !strings.Contains(page, e) {
+			t.Errorf("expected the sheet to contain %q", e)
+		}
+	}
+
+	if strings.Contains(page, "Recovery Codes") {
+		t.Error("expected no recovery codes section")
+	}
+}
+
+func TestSheet_RenderErrors(t *testing.T) {
+	totp := otpgo.TOTP{Key: "JBSWY3DPEHPK3PXP"}
+
+	cases := []struct {
+		label    string
+		sheet    *Sheet
+		expected error
+	}{
+		{"Missing Key Uri", &Sheet{}, ErrorInvalidSheet{}},
+		{"Invalid Label", &Sheet{KeyUri: totp.KeyUri("", "Acme")}, authenticator.ErrorInvalidLabel{}},
+		{
+			"Missing Secret",
+			&Sheet{KeyUri: &authenticator.KeyUri{Type: "totp", Label: authenticator.Label{AccountName: "john"}, Parameters: authenticator.Values{}}},
+			ErrorInvalidSheet{},
+		},
+	}
+
+	for _, c := range cases {
+		var out bytes.Buffer
+		err := c.sheet.Render(&out)
+		if reflect.TypeOf(err) != reflect.TypeOf(c.expected) {
+			t.Errorf("case %s: unexpected error\nexpected: %T\n  actual: %T", c.label, c.expected, err)
+		}
+
+		if out.Len() != 0 {
+			t.Errorf("case %s: expected nothing to be written", c.label)
+		}
+	}
+}
+
+func TestGroupSecret(t *testing.T) {
+	cases := []struct {
+		label    string
+		secret   string
+		expected string
+	}{
+		{"Empty", "", ""},
+		{"Short", "JBS", "JBS"},
+		{"Exact Blocks", "JBSWY3DP", "JBSW Y3DP"},
+		{"Partial Block", "JBSWY3DPEH", "JBSW Y3DP EH"},
+		{"Normalized", "jbsw y3dp eh======", "JBSW Y3DP EH"},
+	}
+
+	for _, c := range cases {
+		if actual := GroupSecret(c.secret); c.expected != actual {
+			t.Errorf("case %s: unexpected secret\nexpected: %q\n  actual: %q", c.label, c.expected, actual)
+		}
+	}
+}
+
+func TestNewRecoveryCodes(t *testing.T) {
+	pattern := regexp.MustCompile(`^[0-9a-hjkmnp-tv-z]{5}-[0-9a-hjkmnp-tv-z]{5}$`)
+
+	cases := []struct {
+		label    string
+		n        int
+		expected int
+	}{
+		{"Default", 0, DefaultRecoveryCodes},
+		{"Negative", -1, DefaultRecoveryCodes},
+		{"Custom", 25, 25},
+	}
+
+	for _, c := range cases {
+		codes, err := NewRecoveryCodes(c.n)
+		if err != nil {
+			t.Errorf("case %s: unexpected error: %s", c.label, err)
+			continue
+		}
+
+		if len(codes) != c.expected {
+			t.Errorf("case %s: unexpected count\nexpected: %d\n  actual: %d", c.label, c.expected, len(codes))
+		}
+
+		seen := map[string]bool{}
+		for _, code := range codes {
+			if !pattern.MatchString(code) {
+				t.Errorf("case %s: malformed code %q", c.label, code)
+			}
+
+			if seen[code] {
+				t.Errorf("case %s: duplicated code %q", c.label, code)
+			}
+			seen[code] = true
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package sheet
+
+import (
+	"html/template"
+)
+
+// sheetTemplate lays out a single A4 or letter page. Styles are inlined so
+// that the document has no external resources.
+var sheetTemplate = template.Must(template.New("sheet").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}{{if .Issuer}} - {{.Issuer}}{{end}}</title>
+<style>
+  @page { size: auto; margin: 15mm; }
+  body { font-family: Helvetica, Arial, sans-serif; color: #000; background: #fff; max-width: 180mm; margin: 0 auto; }
+  h1 { font-size: 20pt; margin: 0 0 4mm; }
+  .account { font-size: 12pt; margin: 0 0 8mm; }
+  .registration { display: flex; align-items: flex-start; gap: 8mm; }
+  .registration img { width: 60mm; height: 60mm; image-rendering: pixelated; border: 1px solid #ccc; }
+  .secret { font-family: "Courier New", Courier, monospace; font-size: 16pt; letter-spacing: 1px; word-spacing: 4px; }
+  dl { display: grid; grid-template-columns: max-content auto; gap: 1mm 4mm; margin: 4mm 0 0; font-size: 10pt; }
+  dt { font-weight: bold; }
+  dd { margin: 0; }
+  h2 { font-size: 14pt; margin: 10mm 0 2mm; }
+  ol { columns: 2; font-family: "Courier New", Courier, monospace; font-size: 13pt; line-height: 1.8; }
+  .note { font-size: 9pt; color: #444; }
+  footer { margin-top: 10mm; font-size: 9pt; color: #444; border-top: 1px solid #ccc; padding-top: 2mm; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p class="account">{{if .Issuer}}<strong>{{.Issuer}}</strong> &middot; {{end}}{{.AccountName}}</p>
+
+<div class="registration">
+  <img src="{{.QRCode}}" alt="QR code to scan with an authenticator app">
+  <div>
+    <p>Scan the QR code with your authenticator app, or enter this key manually:</p>
+    <p class="secret">{{.Secret}}</p>
+    <dl>
+      <dt>Type</dt><dd>{{.Type}}</dd>
+      {{- if .Algorithm}}
+      <dt>Algorithm</dt><dd>{{.Algorithm}}</dd>
+      {{- end}}
+      {{- if .Digits}}
+      <dt>Digits</dt><dd>{{.Digits}}</dd>
+      {{- end}}
+      {{- if .Period}}
+      <dt>Period</dt><dd>{{.Period}} seconds</dd>
+      {{- end}}
+      {{- if .Counter}}
+      <dt>Counter</dt><dd>{{.Counter}}</dd>
+      {{- end}}
+    </dl>
+  </div>
+</div>
+{{- if .RecoveryCodes}}
+
+<h2>Recovery Codes</h2>
+<p class="note">Use one of these codes if you lose access to your authenticator. Each code works only once.</p>
+<ol>
+  {{- range .RecoveryCodes}}
+  <li>{{.}}</li>
+  {{- end}}
+</ol>
+{{- end}}
+
+<footer>Issued on {{.Issued}}. Keep this sheet somewhere safe, anyone holding it can sign in as you.</footer>
+</body>
+</html>
+`))
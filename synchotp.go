@@ -0,0 +1,194 @@
+package otpgo
+
+import (
+	"context"
+)
+
+// The SyncHOTP type shares an HOTP credential between goroutines, or servers,
+// by keeping its counter in a CounterStore. Counters are only advanced with an
+// atomic compare-and-swap, so two simultaneous validations can never consume
+// the same counter, and the HOTP config itself is never modified.
+//
+// The stored counter is the next one expected from the authenticator. Unlike
+// HOTP.Validate, only the look-ahead window is used (LookAhead, or Leeway when
+// no asymmetric window is set), as accepting counters that were already
+// consumed would allow replaying a token.
+type SyncHOTP struct {
+	ID    string       // Identifies the counter in the store
+	HOTP  HOTP         // Key and parameters, Counter is the initial value when none is stored
+	Store CounterStore // Where the current counter is kept
+}
+
+// Counter returns the current counter of the credential.
+func (s *SyncHOTP) Counter() (uint64, error) {
+	return s.counter(context.Background())
+}
+
+func (s *SyncHOTP) counter(ctx context.Context) (uint64, error) {
+	if s.Store == nil {
+		return 0, ErrorInvalidConfig{msg: "missing counter store"}
+	}
+
+	counter, ok, err := s.Store.Load(ctx, s.ID)
+	if err != nil {
+		return 0, err
+	}
+
+	if !ok {
+		return s.HOTP.Counter, nil
+	}
+
+	return counter, nil
+}
+
+// Generate returns the code for the current counter and advances it, so the
+// same code is never handed out twice.
+func (s *SyncHOTP) Generate() (string, error) {
+	return s.GenerateContext(context.Background())
+}
+
+// GenerateContext is like Generate, passing the context to the store.
+func (s *SyncHOTP) GenerateContext(ctx context.Context) (string, error) {
+	h, err := s.config()
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		counter, err := s.counter(ctx)
+		if err != nil {
+			return "", err
+		}
+
+		code, err := generateOTP(h.Key, counter, h.Length, h.Algorithm)
+		if err != nil {
+			return "", err
+		}
+
+		swapped, err := s.Store.CompareAndSwap(ctx, s.ID, counter, counter+1)
+		if err != nil {
+			return "", err
+		}
+
+		if swapped {
+			return code, nil
+		}
+	}
+}
+
+// Validate checks the token against the current counter and the following
+// look-ahead ones. If the validation is successful the stored counter is moved
+// past the matching one. When another validation advances the counter first,
+// the token is checked again against the new counter.
+//
+// The HOTP Observer, if any, is notified of the outcome with ID as the
+// credential id. Tokens matching a counter that was already consumed are
+// reported as a replay.
+func (s *SyncHOTP) Validate(token string) (bool, error) {
+	return s.ValidateContext(context.Background(), token)
+}
+
+// ValidateContext is like Validate, passing the context to the store.
+func (s *SyncHOTP) ValidateContext(ctx context.Context, token string) (bool, error) {
+	valid, reason, offset, err := s.validate(ctx, token)
+	if valid {
+		notify(s.HOTP.Observer, success(s.ID, TypeHOTP, offset))
+	} else {
+		notify(s.HOTP.Observer, failure(s.ID, TypeHOTP, reason))
+	}
+
+	return valid, err
+}
+
+// validate does the work of Validate, returning the reason of a failure or the
+// offset of the matching counter.
+func (s *SyncHOTP) validate(ctx context.Context, token string) (valid bool, reason string, offset int64, err error) {
+	h, err := s.config()
+	if err != nil {
+		return false, ReasonError, 0, err
+	}
+
+	if !wellFormed(token, int(h.Length)) {
+		return false, ReasonInvalidFormat, 0, nil
+	}
+
+	for {
+		counter, err := s.counter(ctx)
+		if err != nil {
+			return false, ReasonError, 0, err
+		}
+
+		_, ahead := h.window()
+		matched, ok, err := h.scan(counter, ahead+1, token)
+		if err != nil {
+			return false, ReasonError, 0, err
+		}
+
+		if !ok {
+			return false, s.failureReason(h, counter, token), 0, nil
+		}
+
+		swapped, err := s.Store.CompareAndSwap(ctx, s.ID, counter, matched+1)
+		if err != nil {
+			return false, ReasonError, 0, err
+		}
+
+		if swapped {
+			return true, "", int64(matched - counter), nil
+		}
+	}
+}
+
+// failureReason tells whether a token that is not valid for the counter was
+// valid for one of the previous counters, within the look-behind window.
+func (s *SyncHOTP) failureReason(h HOTP, counter uint64, token string) string {
+	behind, _ := h.window()
+	if behind == 0 {
+		behind = 1
+	}
+
+	if behind > counter {
+		behind = counter
+	}
+
+	if _, ok, _ := h.scan(counter-behind, behind, token); ok {
+		return ReasonReplay
+	}
+
+	return ReasonInvalidToken
+}
+
+// config returns a copy of the HOTP config with the defaults applied, leaving
+// the shared one untouched.
+func (s *SyncHOTP) config() (HOTP, error) {
+	h := s.HOTP
+
+	if h.Key == "" {
+		return h, ErrorInvalidConfig{msg: "missing secret key"}
+	}
+
+	if s.Store == nil {
+		return h, ErrorInvalidConfig{msg: "missing counter store"}
+	}
+
+	h.ensureDefaults()
+
+	return h, nil
+}
+
+// scan returns the first of n counters, starting from the given one, whose
+// code matches the token.
+func (h *HOTP) scan(counter, n uint64, token string) (uint64, bool, error) {
+	for step := uint64(0); step < n; step++ {
+		expected, err := generateOTP(h.Key, counter+step, h.Length, h.Algorithm)
+		if err != nil {
+			return 0, false, err
+		}
+
+		if tokensEqual(expected, token) {
+			return counter + step, true, nil
+		}
+	}
+
+	return 0, false, nil
+}
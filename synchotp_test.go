@@ -0,0 +1,205 @@
+package otpgo
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/jltorresm/otpgo/config"
+)
+
+// codeAt returns the code of the SyncHOTP credential for the given counter.
+func codeAt(t *testing.T, s *SyncHOTP, counter uint64) string {
+	h := s.HOTP
+	h.Counter = counter
+
+	code, err := h.Generate()
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		t.FailNow()
+	}
+
+	return code
+}
+
+func TestSyncHOTP_Validate(t *testing.T) {
+	s := &SyncHOTP{ID: "john", HOTP: HOTP{Key: "73QK7D3A3PIZ6NUQQBF4BNFYQBRVUHUP", Counter: 363, Algorithm: config.HmacSHA256}, Store: &MemoryCounterStore{}}
+
+	cases := []struct {
+		label           string
+		token           string
+		expectedValid   bool
+		expectedCounter uint64
+	}{
+		{"Current", "363033", true, 364},
+		{"Replay", "363033", false, 364},
+		{"Look Ahead", codeAt(t, s, 365), true, 366},
+		{"Skipped", codeAt(t, s, 364), false, 366},
+		{"Too Far", codeAt(t, s, 368), false, 366},
+		{"Wrong", "000000", false, 366},
+	}
+
+	for _, c := range cases {
+		valid, err := s.Validate(c.token)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", c.label, err)
+		}
+
+		if valid != c.expectedValid {
+			t.Errorf("%s: unexpected validation\nexpected: %v\n  actual: %v", c.label, c.expectedValid, valid)
+		}
+
+		if counter, _ := s.Counter(); counter != c.expectedCounter {
+			t.Errorf("%s: unexpected counter\nexpected: %d\n  actual: %d", c.label, c.expectedCounter, counter)
+		}
+	}
+
+	if s.HOTP.Counter != 363 || s.HOTP.Leeway != 0 || s.HOTP.Length != 0 {
+		t.Errorf("unexpected change in the hotp config: %+v", s.HOTP)
+	}
+}
+
+func TestSyncHOTP_LookBehindIgnored(t *testing.T) {
+	s := &SyncHOTP{ID: "john", HOTP: HOTP{Key: "73QK7D3A3PIZ6NUQQBF4BNFYQBRVUHUP", Counter: 363, Algorithm: config.HmacSHA256}, Store: &MemoryCounterStore{}}
+	s.HOTP.LookBehind = 5
+	s.HOTP.LookAhead = 1
+
+	if valid, _ := s.Validate(codeAt(t, s, 362)); valid {
+		t.Error("expected a previous counter to be rejected")
+	}
+
+	if valid, _ := s.Validate(codeAt(t, s, 364)); !valid {
+		t.Error("expected the next counter to be accepted")
+	}
+}
+
+func TestSyncHOTP_Generate(t *testing.T) {
+	s := &SyncHOTP{ID: "john", HOTP: HOTP{Key: "73QK7D3A3PIZ6NUQQBF4BNFYQBRVUHUP", Counter: 363, Algorithm: config.HmacSHA256}, Store: &MemoryCounterStore{}}
+
+	first, err := s.Generate()
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	second, _ := s.Generate()
+
+	if first != "363033" || second != codeAt(t, s, 364) {
+		t.Errorf("unexpected codes: %s, %s", first, second)
+	}
+
+	if counter, _ := s.Counter(); counter != 365 {
+		t.Errorf("unexpected counter\nexpected: %d\n  actual: %d", 365, counter)
+	}
+}
+
+func TestSyncHOTP_Concurrency(t *testing.T) {
+	t.Run("Same Token", func(t *testing.T) {
+		s := &SyncHOTP{ID: "john", HOTP: HOTP{Key: "73QK7D3A3PIZ6NUQQBF4BNFYQBRVUHUP", Counter: 363, Algorithm: config.HmacSHA256}, Store: &MemoryCounterStore{}}
+
+		if valid := validateConcurrently(t, s, 50, func(int) string { return "363033" }); valid != 1 {
+			t.Errorf("unexpected number of valid tokens\nexpected: %d\n  actual: %d", 1, valid)
+		}
+	})
+
+	t.Run("Distinct Tokens", func(t *testing.T) {
+		s := &SyncHOTP{ID: "john", HOTP: HOTP{Key: "73QK7D3A3PIZ6NUQQBF4BNFYQBRVUHUP", Counter: 363, Algorithm: config.HmacSHA256}, Store: &MemoryCounterStore{}}
+		s.HOTP.Leeway = 10
+		codes := []string{codeAt(t, s, 363), codeAt(t, s, 363), codeAt(t, s, 367), codeAt(t, s, 370)}
+
+		// However the validations interleave, each counter can only be used
+		// once and never after a later one.
+		valid := validateConcurrently(t, s, len(codes), func(i int) string { return codes[i] })
+		if valid < 1 || valid > 3 {
+			t.Errorf("unexpected number of valid tokens: %d", valid)
+		}
+
+		if counter, _ := s.Counter(); counter != 371 && counter != 368 && counter != 364 {
+			t.Errorf("unexpected counter: %d", counter)
+		}
+	})
+
+	t.Run("Generate", func(t *testing.T) {
+		s := &SyncHOTP{ID: "john", HOTP: HOTP{Key: "73QK7D3A3PIZ6NUQQBF4BNFYQBRVUHUP", Counter: 363, Algorithm: config.HmacSHA256}, Store: &MemoryCounterStore{}}
+		codes := make(chan string, 20)
+
+		var wg sync.WaitGroup
+		for i := 0; i < cap(codes); i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				code, err := s.Generate()
+				if err != nil {
+					t.Errorf("unexpected error: %s", err)
+				}
+				codes <- code
+			}()
+		}
+		wg.Wait()
+		close(codes)
+
+		seen := map[string]bool{}
+		for code := range codes {
+			seen[code] = true
+		}
+
+		if counter, _ := s.Counter(); counter != 363+uint64(cap(codes)) || len(seen) < cap(codes)-1 {
+			t.Errorf("unexpected result, counter %d with %d distinct codes", counter, len(seen))
+		}
+	})
+}
+
+// validateConcurrently validates n tokens at the same time and returns how
+// many were valid.
+func validateConcurrently(t *testing.T, s *SyncHOTP, n int, token func(i int) string) int {
+	var wg sync.WaitGroup
+	results := make(chan bool, n)
+	start := make(chan struct{})
+
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			valid, err := s.Validate(token(i))
+			if err != nil {
+				t.Errorf("unexpected error: %s", err)
+			}
+			results <- valid
+		}(i)
+	}
+
+	close(start)
+	wg.Wait()
+	close(results)
+
+	valid := 0
+	for v := range results {
+		if v {
+			valid++
+		}
+	}
+
+	return valid
+}
+
+func TestSyncHOTP_InvalidConfig(t *testing.T) {
+	cases := []struct {
+		label       string
+		s           *SyncHOTP
+		expectedErr error
+	}{
+		{"No Key", &SyncHOTP{Store: &MemoryCounterStore{}}, ErrorInvalidConfig{msg: "missing secret key"}},
+		{"No Store", &SyncHOTP{HOTP: HOTP{Key: "73QK7D3A3PIZ6NUQQBF4BNFYQBRVUHUP"}}, ErrorInvalidConfig{msg: "missing counter store"}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.label, func(t *testing.T) {
+			if _, err := c.s.Validate("123456"); err != c.expectedErr {
+				t.Errorf("unexpected error\nexpected: %v\n  actual: %v", c.expectedErr, err)
+			}
+
+			if _, err := c.s.Generate(); err != c.expectedErr {
+				t.Errorf("unexpected error\nexpected: %v\n  actual: %v", c.expectedErr, err)
+			}
+		})
+	}
+}
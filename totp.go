@@ -1,6 +1,7 @@
 package otpgo
 
 import (
+	"context"
 	"errors"
 	"math"
 	"net/url"
@@ -21,12 +22,24 @@ const (
 )
 
 // The TOTP type used to generate Time-Based One-Time Passwords.
+//
+// Tokens are accepted within Delay steps of the current time, in both
+// directions. Setting PastSkew or FutureSkew replaces Delay with an asymmetric
+// window, e.g.: PastSkew 1 alone accepts the previous step but no future one,
+// as recommended by RFC 6238.
 type TOTP struct {
-	Key       string               `json:"key"`       // Secret base32 encoded string
-	Period    int                  `json:"period"`    // Number of seconds the TOTP is valid
-	Delay     int                  `json:"delay"`     // Acceptable steps for network delay
-	Algorithm config.HmacAlgorithm `json:"algorithm"` // Hash algorithm to use in the calculation
-	Length    config.Length        `json:"length"`    // Length of the resulting code
+	Key        string               `json:"key"`                  // Secret base32 encoded string
+	Period     int                  `json:"period"`               // Number of seconds the TOTP is valid
+	Delay      int                  `json:"delay"`                // Acceptable steps for network delay
+	PastSkew   int                  `json:"pastSkew,omitempty"`   // Acceptable steps in the past, overrides Delay
+	FutureSkew int                  `json:"futureSkew,omitempty"` // Acceptable steps in the future, overrides Delay
+	T0         int64                `json:"t0,omitempty"`         // Unix time from which steps are counted, usually 0
+	Algorithm  config.HmacAlgorithm `json:"algorithm"`            // Hash algorithm to use in the calculation
+	Length     config.Length        `json:"length"`               // Length of the resulting code
+	ID         string               `json:"-"`                    // Identifies the credential in validation events
+	Observer   Observer             `json:"-"`                    // Optional, notified of every validation
+
+	frozen bool // Set by NewTOTP, the config is complete and must not be modified
 }
 
 // Generate a Time-Based One-Time Password.
@@ -51,41 +64,112 @@ func (t *TOTP) Generate() (string, error) {
 // If the TOTP struct is using all the default values the config will be
 // compatible with the Google Authenticator app, as well as most other apps.
 func (t *TOTP) Validate(token string) (bool, error) {
+	valid, reason, offset, err := t.validate(token, nil)
+	notify(t.Observer, outcome(t.ID, TypeTOTP, valid, reason, int64(offset)))
+
+	return valid, err
+}
+
+// validate does the work of Validate without notifying the Observer, binding
+// the token to data when given. It returns the reason of a failure or the
+// offset of the matching step.
+func (t *TOTP) validate(token string, data []byte) (valid bool, reason string, offset int, err error) {
 	// This will be the base for all validations
 	now := time.Now().Unix()
 
 	// Validating without a proper key shouldn't happen
 	if t.Key == "" {
-		return false, errors.New("missing secret key for validation")
+		return false, ReasonError, 0, errors.New("missing secret key for validation")
 	}
 
 	// Make sure we have sensible values to generate secure OTPs
 	t.ensureDefaults()
 
-	// Now go through all the possible valid tokens
-	for step := 0; step <= t.Delay; step++ {
-		pad := int64(t.Period * step)
-		under := t.getCounter(now - pad)
+	if !wellFormed(token, int(t.Length)) {
+		return false, ReasonInvalidFormat, 0, nil
+	}
 
-		expected, err := generateOTP(t.Key, under, t.Length, t.Algorithm)
-		if err != nil {
-			return false, err
-		}
-		if expected == token {
-			return true, nil
-		}
+	offset, isValid, err := t.match(now, 0, token, data)
+	if err != nil {
+		return false, ReasonError, 0, err
+	}
+
+	if !isValid {
+		return false, ReasonInvalidToken, 0, nil
+	}
+
+	return true, "", offset, nil
+}
+
+// GenerateContext is like Generate, but fails with the context error if it is
+// already done.
+func (t *TOTP) GenerateContext(ctx context.Context) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	return t.Generate()
+}
 
-		over := t.getCounter(now + pad)
-		expected, err = generateOTP(t.Key, over, t.Length, t.Algorithm)
-		if err != nil {
-			return false, err
+// ValidateContext is like Validate, but fails with the context error if it is
+// already done.
+func (t *TOTP) ValidateContext(ctx context.Context, token string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		notify(t.Observer, failure(t.ID, TypeTOTP, ReasonError))
+		return false, err
+	}
+
+	return t.Validate(token)
+}
+
+// match checks the token against the validation window centered on the given
+// offset, in steps, from the timestamp, with the codes bound to data if any. It
+// returns the offset of the matching step from the timestamp. The defaults must
+// have been applied already.
+func (t *TOTP) match(timestamp int64, center int, token string, data []byte) (int, bool, error) {
+	past, future := t.window()
+	if past < 0 || future < 0 {
+		return 0, false, ErrorInvalidConfig{msg: "skew must not be negative"}
+	}
+
+	// Now go through all the possible valid tokens
+	for step := 0; step <= past || step <= future; step++ {
+		if step <= past {
+			offset := center - step
+			under := t.getCounter(timestamp + int64(t.Period*offset))
+			expected, err := generateOTPWithData(t.Key, under, data, t.Length, t.Algorithm)
+			if err != nil {
+				return 0, false, err
+			}
+			if tokensEqual(expected, token) {
+				return offset, true, nil
+			}
 		}
-		if expected == token {
-			return true, nil
+
+		if step <= future {
+			offset := center + step
+			over := t.getCounter(timestamp + int64(t.Period*offset))
+			expected, err := generateOTPWithData(t.Key, over, data, t.Length, t.Algorithm)
+			if err != nil {
+				return 0, false, err
+			}
+			if tokensEqual(expected, token) {
+				return offset, true, nil
+			}
 		}
 	}
 
-	return false, nil
+	return 0, false, nil
+}
+
+// window returns the number of steps accepted before and after the current
+// one, PastSkew and FutureSkew, or Delay for both if they are not set.
+func (t *TOTP) window() (past, future int) {
+	if t.PastSkew == 0 && t.FutureSkew == 0 {
+		return t.Delay, t.Delay
+	}
+
+	return t.PastSkew, t.FutureSkew
 }
 
 // KeyUri return an authenticator.KeyUri configured with the current TOTP params.
@@ -93,7 +177,7 @@ func (t *TOTP) Validate(token string) (bool, error) {
 //     - issuer is the site or org
 func (t *TOTP) KeyUri(accountName, issuer string) *authenticator.KeyUri {
 	return &authenticator.KeyUri{
-		Type: "totp",
+		Type: TypeTOTP,
 		Label: authenticator.Label{
 			AccountName: accountName,
 			Issuer:      issuer,
@@ -102,6 +186,11 @@ func (t *TOTP) KeyUri(accountName, issuer string) *authenticator.KeyUri {
 	}
 }
 
+// Type returns the OTP type, TypeTOTP.
+func (t *TOTP) Type() string {
+	return TypeTOTP
+}
+
 // AsUrlValues returns the TOTP parameters represented as url.Values.
 func (t *TOTP) AsUrlValues(issuer string) url.Values {
 	params := url.Values{}
@@ -122,6 +211,10 @@ func (t *TOTP) AsUrlValues(issuer string) url.Values {
 //     - Algorithm = SHA1
 //     - Length = 6
 func (t *TOTP) ensureDefaults() {
+	if t.frozen {
+		return
+	}
+
 	if t.Period == 0 {
 		t.Period = TOTPDefaultPeriod
 	}
@@ -140,11 +233,17 @@ func (t *TOTP) ensureDefaults() {
 }
 
 // ensureKey generates a proper random key if no value is provided by the caller.
+// The key of a config built by NewTOTP is never replaced, an empty one is an
+// error.
 func (t *TOTP) ensureKey() (err error) {
 	if t.Key != "" {
 		return nil
 	}
 
+	if t.frozen {
+		return ErrorInvalidConfig{msg: "missing secret key"}
+	}
+
 	t.Key, err = randomKey(RandomKeyLength)
 
 	return err
@@ -152,5 +251,5 @@ func (t *TOTP) ensureKey() (err error) {
 
 // getCounter returns a valid counter based on the given timestamp.
 func (t *TOTP) getCounter(timestamp int64) uint64 {
-	return uint64(math.Floor(float64(timestamp) / float64(t.Period)))
+	return uint64(math.Floor(float64(timestamp-t.T0) / float64(t.Period)))
 }
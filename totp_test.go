@@ -133,6 +133,8 @@ func TestTOTP_Validate(t *testing.T) {
 	t.Run("Success", testTOTPValidateSuccess)
 	t.Run("Failure", testTOTPValidateFailure)
 	t.Run("Delayed Validation", testTOTPValidateDelayed)
+	t.Run("Asymmetric Skew", testTOTPValidateAsymmetric)
+	t.Run("Negative Skew", testTOTPValidateNegativeSkew)
 	t.Run("Missing Key", testTOTPValidateMissingKey)
 }
 
@@ -226,6 +228,62 @@ func testTOTPValidateDelayed(t *testing.T) {
 	}
 }
 
+func testTOTPValidateAsymmetric(t *testing.T) {
+	t.Parallel()
+
+	totp := &TOTP{
+		Key:       "73QK7D3A3PIZ6NUQQBF4BNFYQBRVUHUQ",
+		Period:    10,
+		Delay:     3,
+		PastSkew:  1,
+		Algorithm: config.HmacSHA256,
+		Length:    config.Length7,
+	}
+
+	cases := []struct {
+		label         string
+		modifier      int
+		shouldBeValid bool
+	}{
+		{"On Time", 0, true},
+		{"One Step Delay", -1, true},
+		{"One Step Forward", 1, false},
+		{"Two Step Delay", -2, false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.label, func(t *testing.T) {
+			counter := totp.getCounter(time.Now().Unix() + int64(totp.Period*c.modifier))
+			expectedOTP, err := getExpectedTOTP(totp.Key, counter, totp.Length, totp.Algorithm)
+			if err != nil {
+				t.Errorf("unexpected error: %s", err)
+				t.FailNow()
+			}
+
+			isValid, err := totp.Validate(expectedOTP)
+			if err != nil {
+				t.Errorf("unexpected error: %s", err)
+			}
+
+			if isValid != c.shouldBeValid {
+				t.Errorf("unexpected result from Validate()\nexpected %s to be %v", c.label, c.shouldBeValid)
+			}
+		})
+	}
+}
+
+func testTOTPValidateNegativeSkew(t *testing.T) {
+	t.Parallel()
+
+	totp := &TOTP{Key: "73QK7D3A3PIZ6NUQQBF4BNFYQBRVUHUQ", FutureSkew: -1}
+
+	_, err := totp.Validate("123456")
+	expectedErr := ErrorInvalidConfig{msg: "skew must not be negative"}
+	if err != expectedErr {
+		t.Errorf("unexpected error\nexpected: %v\n  actual: %v", expectedErr, err)
+	}
+}
+
 func testTOTPValidateMissingKey(t *testing.T) {
 	totp := &TOTP{}
 
@@ -279,6 +337,18 @@ func TestTOTPJson(t *testing.T) {
 	if expectedJson != string(j) {
 		t.Errorf("unexpected json:\nexpected: %s\n  actual: %s", expectedJson, j)
 	}
+
+	h.PastSkew = 1
+	expectedJson = `{"key":"73QK7D3A3PIZ6NUQQBF4BNFYQBRVUHUQ","period":30,"delay":1,"pastSkew":1,"algorithm":"SHA256","length":7}`
+
+	if j, _ = json.Marshal(h); expectedJson != string(j) {
+		t.Errorf("unexpected json:\nexpected: %s\n  actual: %s", expectedJson, j)
+	}
+
+	decoded := TOTP{}
+	if err := json.Unmarshal(j, &decoded); err != nil || decoded != h {
+		t.Errorf("unexpected decoded totp\nexpected: %+v\n  actual: %+v (%v)", h, decoded, err)
+	}
 }
 
 func getExpectedTOTP(key string, counter uint64, length config.Length, algorithm config.HmacAlgorithm) (string, error) {
@@ -289,3 +359,23 @@ func getExpectedTOTP(key string, counter uint64, length config.Length, algorithm
 
 	return expectedOTP, nil
 }
+
+func TestTOTP_T0(t *testing.T) {
+	totp := &TOTP{Period: 30, T0: 15}
+
+	cases := []struct {
+		timestamp int64
+		expected  uint64
+	}{
+		{15, 0},
+		{44, 0},
+		{45, 1},
+		{1015, 33},
+	}
+
+	for _, c := range cases {
+		if actual := totp.getCounter(c.timestamp); c.expected != actual {
+			t.Errorf("unexpected counter for %d\nexpected: %d\n  actual: %d", c.timestamp, c.expected, actual)
+		}
+	}
+}
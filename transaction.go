@@ -0,0 +1,87 @@
+package otpgo
+
+import (
+	"encoding/binary"
+	"sort"
+	"time"
+)
+
+// The Transaction type holds the details a code is bound to, for dynamic
+// linking as required by PSD2, e.g.:
+//
+//	Transaction{"amount": "100.00", "currency": "EUR", "payee": "DE89370400440532013000"}
+//
+// Field values are compared as they are, so they must be formatted the same
+// way when generating and validating a code, e.g.: always with two decimals.
+type Transaction map[string]string
+
+// canonical returns an unambiguous encoding of the fields, sorted by name,
+// each name and value prefixed with its length.
+func (tx Transaction) canonical() ([]byte, error) {
+	if len(tx) == 0 {
+		return nil, ErrorInvalidConfig{msg: "transaction has no fields"}
+	}
+
+	names := make([]string, 0, len(tx))
+	for name := range tx {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var data []byte
+	for _, name := range names {
+		data = appendField(data, name)
+		data = appendField(data, tx[name])
+	}
+
+	return data, nil
+}
+
+// appendField appends the length prefixed field to data.
+func appendField(data []byte, field string) []byte {
+	size := make([]byte, 4)
+	binary.BigEndian.PutUint32(size, uint32(len(field)))
+
+	return append(append(data, size...), field...)
+}
+
+// GenerateTransaction generates a Time-Based One-Time Password bound to the
+// transaction. The code mixes the canonical encoding of the transaction into
+// the HMAC input along with the time step, so it is only valid for the exact
+// same transaction.
+//
+// Standard authenticator apps can't generate these codes, the user's device
+// must implement the same algorithm, e.g.: a banking app.
+func (t *TOTP) GenerateTransaction(tx Transaction) (string, error) {
+	data, err := tx.canonical()
+	if err != nil {
+		return "", err
+	}
+
+	// Make sure we have sensible values to generate secure OTPs
+	t.ensureDefaults()
+
+	// Make sure we have a valid non-empty key
+	if err := t.ensureKey(); err != nil {
+		return "", err
+	}
+
+	counter := t.getCounter(time.Now().Unix())
+
+	return generateOTPWithData(t.Key, counter, data, t.Length, t.Algorithm)
+}
+
+// ValidateTransaction checks the token like Validate, but only accepts codes
+// generated for the given transaction with GenerateTransaction.
+func (t *TOTP) ValidateTransaction(token string, tx Transaction) (bool, error) {
+	data, err := tx.canonical()
+	if err != nil {
+		notify(t.Observer, failure(t.ID, TypeTOTP, ReasonError))
+		return false, err
+	}
+
+	valid, reason, offset, err := t.validate(token, data)
+	notify(t.Observer, outcome(t.ID, TypeTOTP, valid, reason, int64(offset)))
+
+	return valid, err
+}
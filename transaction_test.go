@@ -0,0 +1,81 @@
+package otpgo
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/jltorresm/otpgo/config"
+)
+
+func TestTransaction_Canonical(t *testing.T) {
+	data, err := Transaction{"payee": "ACME", "amount": "1.00"}.canonical()
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	expected := []byte("\x00\x00\x00\x06amount\x00\x00\x00\x041.00\x00\x00\x00\x05payee\x00\x00\x00\x04ACME")
+	if !bytes.Equal(expected, data) {
+		t.Errorf("unexpected encoding\nexpected: %q\n  actual: %q", expected, data)
+	}
+
+	// Moving characters between name and value must change the encoding
+	first, _ := Transaction{"a": "bc"}.canonical()
+	second, _ := Transaction{"ab": "c"}.canonical()
+	if bytes.Equal(first, second) {
+		t.Errorf("ambiguous encoding: %q", first)
+	}
+
+	if _, err := (Transaction{}).canonical(); err != (ErrorInvalidConfig{msg: "transaction has no fields"}) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestTOTP_ValidateTransaction(t *testing.T) {
+	totp := &TOTP{Key: "73QK7D3A3PIZ6NUQQBF4BNFYQBRVUHUQ", Algorithm: config.HmacSHA256, Length: config.Length8}
+	tx := Transaction{"amount": "100.00", "currency": "EUR", "payee": "DE89370400440532013000"}
+
+	code, err := totp.GenerateTransaction(tx)
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		t.FailNow()
+	}
+
+	plain, _ := totp.Generate()
+	if code == plain {
+		t.Errorf("transaction code must differ from the plain code: %s", code)
+	}
+
+	cases := []struct {
+		label         string
+		tx            Transaction
+		expectedValid bool
+	}{
+		{"Same", Transaction{"payee": "DE89370400440532013000", "currency": "EUR", "amount": "100.00"}, true},
+		{"Other Amount", Transaction{"amount": "100.01", "currency": "EUR", "payee": "DE89370400440532013000"}, false},
+		{"Other Payee", Transaction{"amount": "100.00", "currency": "EUR", "payee": "GB29NWBK60161331926819"}, false},
+		{"Missing Field", Transaction{"amount": "100.00", "payee": "DE89370400440532013000"}, false},
+	}
+
+	for _, c := range cases {
+		valid, err := totp.ValidateTransaction(code, c.tx)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", c.label, err)
+		}
+
+		if valid != c.expectedValid {
+			t.Errorf("%s: unexpected validation\nexpected: %v\n  actual: %v", c.label, c.expectedValid, valid)
+		}
+	}
+
+	if valid, _ := totp.Validate(code); valid {
+		t.Error("transaction code must not be valid without the transaction")
+	}
+
+	if _, err := totp.ValidateTransaction(code, nil); err == nil {
+		t.Error("expected error for empty transaction")
+	}
+
+	if _, err := totp.GenerateTransaction(nil); err == nil {
+		t.Error("expected error for empty transaction")
+	}
+}
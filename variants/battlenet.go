@@ -0,0 +1,39 @@
+package variants
+
+import (
+	"regexp"
+
+	"github.com/jltorresm/otpgo"
+	"github.com/jltorresm/otpgo/config"
+)
+
+// BattleNetLength is the number of digits of a Battle.net code.
+const BattleNetLength = 8
+
+// battleNetSerial matches Battle.net authenticator serials, a region followed
+// by 12 digits, e.g.: US-1908-2318-7310.
+var battleNetSerial = regexp.MustCompile(`^(US|EU|CN|KR)-?\d{4}-?\d{4}-?\d{4}$`)
+
+// NewBattleNet returns the TOTP of a Battle.net authenticator: SHA1, 30
+// seconds and 8 digits. The serial is checked and used as the credential ID,
+// the key must be base32 encoded.
+func NewBattleNet(serial, key string) (*otpgo.TOTP, error) {
+	if !battleNetSerial.MatchString(serial) {
+		return nil, otpgo.NewErrorInvalidConfig("invalid battle.net serial " + serial)
+	}
+
+	t, err := otpgo.NewTOTP(
+		otpgo.WithKey(key),
+		otpgo.WithPeriod(Period),
+		otpgo.WithSkew(otpgo.TOTPDefaultDelay),
+		otpgo.WithAlgorithm(config.HmacSHA1),
+		otpgo.WithDigits(BattleNetLength),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	t.ID = serial
+
+	return t, nil
+}
@@ -0,0 +1,45 @@
+package variants
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jltorresm/otpgo"
+	"github.com/jltorresm/otpgo/config"
+)
+
+func TestNewBattleNet(t *testing.T) {
+	key := "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+	totp, err := NewBattleNet("US-1908-2318-7310", key)
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		t.FailNow()
+	}
+
+	if totp.ID != "US-1908-2318-7310" || totp.Period != Period || totp.Algorithm != config.HmacSHA1 || totp.Length != config.Length8 {
+		t.Errorf("unexpected totp: %+v", totp)
+	}
+
+	code, err := totp.Generate()
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	h := &otpgo.HOTP{Key: key, Counter: uint64(time.Now().Unix() / Period), Algorithm: config.HmacSHA1, Length: config.Length8}
+	if expected, _ := h.Generate(); expected != code {
+		t.Errorf("unexpected code\nexpected: %s\n  actual: %s", expected, code)
+	}
+
+	if _, err := NewBattleNet("EU190823187310", key); err != nil {
+		t.Errorf("unexpected error for compact serial: %s", err)
+	}
+
+	if _, err := NewBattleNet("XX-1908-2318-7310", key); err != otpgo.NewErrorInvalidConfig("invalid battle.net serial XX-1908-2318-7310") {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if _, err := NewBattleNet("US-1908-2318-7310", "not base32!"); err == nil {
+		t.Error("expected error for invalid key")
+	}
+}
@@ -0,0 +1,86 @@
+package variants
+
+import (
+	"net/url"
+	"time"
+
+	"github.com/jltorresm/otpgo"
+	"github.com/jltorresm/otpgo/authenticator"
+	"github.com/jltorresm/otpgo/config"
+	"github.com/jltorresm/otpgo/internal/clock"
+)
+
+// steamAlphabet holds the characters of Steam Guard codes.
+const steamAlphabet = "23456789BCDFGHJKMNPQRTVWXY"
+
+// SteamLength is the number of characters of a Steam Guard code.
+const SteamLength = 5
+
+// The Steam type generates Steam Guard codes. They are computed like a
+// SHA1 TOTP with a 30 seconds period, but the truncated value is encoded with
+// 5 characters of a 26 letters and digits alphabet instead of decimal digits.
+type Steam struct {
+	Key      string           `json:"key"`   // Secret base32 encoded string, the base64 shared_secret of Steam re-encoded
+	Delay    int              `json:"delay"` // Acceptable steps for network delay, defaults to otpgo.TOTPDefaultDelay
+	ID       string           `json:"-"`     // Identifies the credential in validation events
+	Observer otpgo.Observer   `json:"-"`     // Optional, notified of every validation
+	Now      func() time.Time `json:"-"`     // Defaults to time.Now
+}
+
+// Generate a Steam Guard code for the current time.
+func (s *Steam) Generate() (string, error) {
+	return s.code(counter(clock.Now(s.Now)))
+}
+
+// Validate checks the token against the codes within Delay steps of the
+// current time. Steam Guard codes are upper case.
+func (s *Steam) Validate(token string) (bool, error) {
+	return validate(s.Observer, s.ID, TypeSteam, clock.Now(s.Now), s.Delay, token, s.code)
+}
+
+// KeyUri return an authenticator.KeyUri configured with the current Steam
+// params, as understood by the authenticator apps supporting Steam.
+func (s *Steam) KeyUri(accountName, issuer string) *authenticator.KeyUri {
+	return &authenticator.KeyUri{
+		Type:       TypeSteam,
+		Label:      authenticator.Label{AccountName: accountName, Issuer: issuer},
+		Parameters: s,
+	}
+}
+
+// Type returns the OTP type, TypeSteam.
+func (s *Steam) Type() string {
+	return TypeSteam
+}
+
+// AsUrlValues returns the Steam parameters represented as url.Values.
+func (s *Steam) AsUrlValues(issuer string) url.Values {
+	params := url.Values{}
+	params.Add("secret", s.Key)
+	params.Add("issuer", issuer)
+
+	return params
+}
+
+// code returns the Steam Guard code for the time step.
+func (s *Steam) code(counter uint64) (string, error) {
+	key, err := otpgo.DecodeKey(s.Key)
+	if err != nil {
+		return "", err
+	}
+
+	sum, err := otpgo.Digest(key, counter, config.HmacSHA1)
+	if err != nil {
+		return "", err
+	}
+
+	value := otpgo.DynamicTruncate(sum, 4)
+
+	code := make([]byte, SteamLength)
+	for i := range code {
+		code[i] = steamAlphabet[value%uint64(len(steamAlphabet))]
+		value /= uint64(len(steamAlphabet))
+	}
+
+	return string(code), nil
+}
@@ -0,0 +1,72 @@
+package variants
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jltorresm/otpgo"
+)
+
+func TestSteam_Generate(t *testing.T) {
+	cases := []struct {
+		timestamp int64
+		expected  string
+	}{
+		{0, "RBKNW"},
+		{59, "W8PR4"},
+		{1603180800, "JNKRV"},
+		{2000000000, "HN469"},
+	}
+
+	for _, c := range cases {
+		s := &Steam{Key: "73QK7D3A3PIZ6NUQQBF4BNFYQBRVUHUQ", Now: fixedTime(c.timestamp)}
+
+		code, err := s.Generate()
+		if err != nil {
+			t.Errorf("unexpected error: %s", err)
+		}
+
+		if c.expected != code {
+			t.Errorf("unexpected code for %d\nexpected: %s\n  actual: %s", c.timestamp, c.expected, code)
+		}
+	}
+
+	if _, err := (&Steam{}).Generate(); err != otpgo.NewErrorInvalidKey("missing secret") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestSteam_Validate(t *testing.T) {
+	s := &Steam{Key: "73QK7D3A3PIZ6NUQQBF4BNFYQBRVUHUQ", Now: fixedTime(1603180800)}
+
+	cases := []struct {
+		label         string
+		token         string
+		expectedValid bool
+	}{
+		{"Current", "JNKRV", true},
+		{"Lower Case", "jnkrv", false},
+		{"Other", "RBKNW", false},
+	}
+
+	for _, c := range cases {
+		valid, err := s.Validate(c.token)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", c.label, err)
+		}
+
+		if valid != c.expectedValid {
+			t.Errorf("%s: unexpected validation\nexpected: %v\n  actual: %v", c.label, c.expectedValid, valid)
+		}
+	}
+
+	// The previous step is accepted with the default delay
+	s.Now = fixedTime(1603180800 + Period)
+	if valid, _ := s.Validate("JNKRV"); !valid {
+		t.Error("expected code of the previous step to be valid")
+	}
+}
+
+func fixedTime(timestamp int64) func() time.Time {
+	return func() time.Time { return time.Unix(timestamp, 0) }
+}
@@ -0,0 +1,70 @@
+// Package variants implements vendor specific variations of TOTP that deviate
+// from RFC 6238, so their codes can be generated and validated like any other
+// otpgo.OTP:
+//   - Steam: 5 characters codes from a 26 letters and digits alphabet
+//   - Yandex: codes mixing the user's PIN into the key, 8 letters long
+//   - Battle.net: plain 8 digits TOTP bound to an authenticator serial
+//
+// Importing the package registers the Steam and Yandex types for the
+// otpgo.Envelope JSON encoding.
+package variants
+
+import (
+	"crypto/hmac"
+	"time"
+
+	"github.com/jltorresm/otpgo"
+)
+
+// Types of the variants, as used in key URIs and in the otpgo.Envelope JSON
+// encoding.
+const (
+	TypeSteam  = "steam"
+	TypeYandex = "yandex"
+)
+
+// Period is the number of seconds a code is valid for every variant.
+const Period = 30
+
+func init() {
+	otpgo.RegisterType(TypeSteam, func() otpgo.OTP { return &Steam{} })
+	otpgo.RegisterType(TypeYandex, func() otpgo.OTP { return &Yandex{} })
+}
+
+// counter returns the time step of the timestamp.
+func counter(t time.Time) uint64 {
+	return uint64(t.Unix() / Period)
+}
+
+// validate checks the token against the codes of the steps within delay of
+// the current one, notifying the observer of the outcome.
+func validate(o otpgo.Observer, id, otpType string, now time.Time, delay int, token string, code func(counter uint64) (string, error)) (bool, error) {
+	if delay == 0 {
+		delay = otpgo.TOTPDefaultDelay
+	}
+
+	current := int64(counter(now))
+	for step := 0; step <= delay; step++ {
+		offsets := []int{-step, step}
+		if step == 0 {
+			offsets = offsets[:1]
+		}
+
+		for _, offset := range offsets {
+			expected, err := code(uint64(current + int64(offset)))
+			if err != nil {
+				otpgo.Notify(o, otpgo.Event{CredentialID: id, Type: otpType, Reason: otpgo.ReasonError})
+				return false, err
+			}
+
+			if hmac.Equal([]byte(expected), []byte(token)) {
+				otpgo.Notify(o, otpgo.Event{CredentialID: id, Type: otpType, Success: true, Offset: int64(offset)})
+				return true, nil
+			}
+		}
+	}
+
+	otpgo.Notify(o, otpgo.Event{CredentialID: id, Type: otpType, Reason: otpgo.ReasonInvalidToken})
+
+	return false, nil
+}
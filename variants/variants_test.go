@@ -0,0 +1,94 @@
+package variants
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/jltorresm/otpgo"
+)
+
+// recorder keeps the events it observes.
+type recorder struct {
+	events []otpgo.Event
+}
+
+func (r *recorder) Observe(e otpgo.Event) {
+	r.events = append(r.events, e)
+}
+
+func TestValidate_Window(t *testing.T) {
+	events := &recorder{}
+	s := &Steam{Key: "73QK7D3A3PIZ6NUQQBF4BNFYQBRVUHUQ", Delay: 2, ID: "john", Observer: events, Now: fixedTime(1603180800)}
+
+	codeAt := func(offset int64) string {
+		code, _ := (&Steam{Key: s.Key, Now: fixedTime(1603180800 + offset*Period)}).Generate()
+		return code
+	}
+
+	cases := []struct {
+		label          string
+		token          string
+		expectedValid  bool
+		expectedOffset int64
+	}{
+		{"Past", codeAt(-2), true, -2},
+		{"Future", codeAt(2), true, 2},
+		{"Too Far", codeAt(3), false, 0},
+	}
+
+	for i, c := range cases {
+		if valid, _ := s.Validate(c.token); valid != c.expectedValid {
+			t.Errorf("%s: unexpected validation\nexpected: %v\n  actual: %v", c.label, c.expectedValid, valid)
+		}
+
+		e := events.events[i]
+		if e.CredentialID != "john" || e.Type != TypeSteam || e.Success != c.expectedValid || e.Offset != c.expectedOffset {
+			t.Errorf("%s: unexpected event: %+v", c.label, e)
+		}
+	}
+}
+
+func TestEnvelope_Variants(t *testing.T) {
+	cases := []struct {
+		label        string
+		otp          otpgo.OTP
+		expectedJson string
+	}{
+		{"Steam", &Steam{Key: "73QK7D3A3PIZ6NUQQBF4BNFYQBRVUHUQ", Delay: 1}, `{"type":"steam","config":{"key":"73QK7D3A3PIZ6NUQQBF4BNFYQBRVUHUQ","delay":1}}`},
+		{"Yandex", &Yandex{Key: "LA2V6KMCGYMWWVEW64RNP3JA3I", PIN: "5239"}, `{"type":"yandex","config":{"key":"LA2V6KMCGYMWWVEW64RNP3JA3I","pin":"5239","delay":0}}`},
+	}
+
+	for _, c := range cases {
+		j, err := json.Marshal(otpgo.Envelope{OTP: c.otp})
+		if err != nil || c.expectedJson != string(j) {
+			t.Errorf("%s: unexpected json\nexpected: %s\n  actual: %s (%v)", c.label, c.expectedJson, j, err)
+		}
+
+		decoded := otpgo.Envelope{}
+		if err := json.Unmarshal(j, &decoded); err != nil {
+			t.Errorf("%s: unexpected error: %s", c.label, err)
+			continue
+		}
+
+		if decoded.OTP.Type() != c.otp.Type() {
+			t.Errorf("%s: unexpected type: %s", c.label, decoded.OTP.Type())
+		}
+	}
+}
+
+func TestKeyUri_Variants(t *testing.T) {
+	cases := []struct {
+		label    string
+		otp      otpgo.OTP
+		expected string
+	}{
+		{"Steam", &Steam{Key: "73QK7D3A3PIZ6NUQQBF4BNFYQBRVUHUQ"}, "otpauth://steam/Steam:john?issuer=Steam&secret=73QK7D3A3PIZ6NUQQBF4BNFYQBRVUHUQ"},
+		{"Yandex", &Yandex{Key: "LA2V6KMCGYMWWVEW64RNP3JA3I", PIN: "5239"}, "otpauth://yandex/Steam:john?issuer=Steam&secret=LA2V6KMCGYMWWVEW64RNP3JA3I"},
+	}
+
+	for _, c := range cases {
+		if actual := c.otp.KeyUri("john", "Steam").String(); c.expected != actual {
+			t.Errorf("%s: unexpected key uri\nexpected: %s\n  actual: %s", c.label, c.expected, actual)
+		}
+	}
+}
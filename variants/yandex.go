@@ -0,0 +1,106 @@
+package variants
+
+import (
+	"crypto/sha256"
+	"net/url"
+	"time"
+
+	"github.com/jltorresm/otpgo"
+	"github.com/jltorresm/otpgo/authenticator"
+	"github.com/jltorresm/otpgo/config"
+	"github.com/jltorresm/otpgo/internal/clock"
+)
+
+const (
+	// YandexLength is the number of letters of a Yandex Key code.
+	YandexLength = 8
+	// yandexSecretLength is the number of bytes of a Yandex secret, the key
+	// URIs issued by Yandex append extra bytes that are not part of it.
+	yandexSecretLength = 16
+)
+
+// The Yandex type generates Yandex Key codes. The HMAC key is the SHA256 hash
+// of the user's PIN followed by the secret, without its first byte when it is
+// zero. The codes are computed with HMAC-SHA256 over the 30 seconds time step,
+// truncated to 8 bytes and encoded with 8 lower case letters.
+type Yandex struct {
+	Key      string           `json:"key"`   // Secret base32 encoded string, only the first 16 bytes are used
+	PIN      string           `json:"pin"`   // PIN chosen by the user, required
+	Delay    int              `json:"delay"` // Acceptable steps for network delay, defaults to otpgo.TOTPDefaultDelay
+	ID       string           `json:"-"`     // Identifies the credential in validation events
+	Observer otpgo.Observer   `json:"-"`     // Optional, notified of every validation
+	Now      func() time.Time `json:"-"`     // Defaults to time.Now
+}
+
+// Generate a Yandex Key code for the current time.
+func (y *Yandex) Generate() (string, error) {
+	return y.code(counter(clock.Now(y.Now)))
+}
+
+// Validate checks the token against the codes within Delay steps of the
+// current time.
+func (y *Yandex) Validate(token string) (bool, error) {
+	return validate(y.Observer, y.ID, TypeYandex, clock.Now(y.Now), y.Delay, token, y.code)
+}
+
+// KeyUri return an authenticator.KeyUri configured with the current Yandex
+// params, the PIN is never included.
+func (y *Yandex) KeyUri(accountName, issuer string) *authenticator.KeyUri {
+	return &authenticator.KeyUri{
+		Type:       TypeYandex,
+		Label:      authenticator.Label{AccountName: accountName, Issuer: issuer},
+		Parameters: y,
+	}
+}
+
+// Type returns the OTP type, TypeYandex.
+func (y *Yandex) Type() string {
+	return TypeYandex
+}
+
+// AsUrlValues returns the Yandex parameters represented as url.Values.
+func (y *Yandex) AsUrlValues(issuer string) url.Values {
+	params := url.Values{}
+	params.Add("secret", y.Key)
+	params.Add("issuer", issuer)
+
+	return params
+}
+
+// code returns the Yandex Key code for the time step.
+func (y *Yandex) code(counter uint64) (string, error) {
+	secret, err := otpgo.DecodeKey(y.Key)
+	if err != nil {
+		return "", err
+	}
+
+	if len(secret) < yandexSecretLength {
+		return "", otpgo.NewErrorInvalidKey("yandex secrets are 16 bytes long")
+	}
+
+	if y.PIN == "" {
+		return "", otpgo.NewErrorInvalidConfig("missing pin")
+	}
+
+	key := sha256.Sum256(append([]byte(y.PIN), secret[:yandexSecretLength]...))
+	hmacKey := key[:]
+	if hmacKey[0] == 0 {
+		hmacKey = hmacKey[1:]
+	}
+
+	sum, err := otpgo.Digest(hmacKey, counter, config.HmacSHA256)
+	if err != nil {
+		return "", err
+	}
+
+	value := otpgo.DynamicTruncate(sum, 8)
+	value %= 208827064576 // 26^8
+
+	code := make([]byte, YandexLength)
+	for i := len(code) - 1; i >= 0; i-- {
+		code[i] = byte('a' + value%26)
+		value /= 26
+	}
+
+	return string(code), nil
+}
@@ -0,0 +1,75 @@
+package variants
+
+import (
+	"testing"
+
+	"github.com/jltorresm/otpgo"
+)
+
+func TestYandex_Generate(t *testing.T) {
+	cases := []struct {
+		timestamp int64
+		expected  string
+	}{
+		{0, "uqqovpej"},
+		{59, "scpivigv"},
+		{1603180800, "oriyvkze"},
+		{2000000000, "fnnbwjst"},
+	}
+
+	for _, c := range cases {
+		for _, key := range []string{"LA2V6KMCGYMWWVEW64RNP3JA3I", "LA2V6KMCGYMWWVEW64RNP3JA3IAAAAAAHTSG4HRZPI"} {
+			y := &Yandex{Key: key, PIN: "5239", Now: fixedTime(c.timestamp)}
+
+			code, err := y.Generate()
+			if err != nil {
+				t.Errorf("unexpected error: %s", err)
+			}
+
+			if c.expected != code {
+				t.Errorf("unexpected code for %d with %s\nexpected: %s\n  actual: %s", c.timestamp, key, c.expected, code)
+			}
+		}
+	}
+}
+
+func TestYandex_Validate(t *testing.T) {
+	y := &Yandex{Key: "LA2V6KMCGYMWWVEW64RNP3JA3I", PIN: "5239", Now: fixedTime(1603180800)}
+
+	if valid, err := y.Validate("oriyvkze"); !valid || err != nil {
+		t.Errorf("unexpected validation: %v, %v", valid, err)
+	}
+
+	// The hash of this PIN and secret starts with a zero byte, which is dropped
+	y.PIN = "260"
+	if valid, err := y.Validate("mcyqlxng"); !valid || err != nil {
+		t.Errorf("unexpected validation: %v, %v", valid, err)
+	}
+
+	y.PIN = "1234"
+	if valid, err := y.Validate("oriyvkze"); valid || err != nil {
+		t.Errorf("unexpected validation with another pin: %v, %v", valid, err)
+	}
+}
+
+func TestYandex_InvalidConfig(t *testing.T) {
+	cases := []struct {
+		label    string
+		y        *Yandex
+		expected error
+	}{
+		{"Missing Key", &Yandex{PIN: "5239"}, otpgo.NewErrorInvalidKey("missing secret")},
+		{"Short Key", &Yandex{Key: "JBSWY3DPEHPK3PXP", PIN: "5239"}, otpgo.NewErrorInvalidKey("yandex secrets are 16 bytes long")},
+		{"Missing PIN", &Yandex{Key: "LA2V6KMCGYMWWVEW64RNP3JA3I"}, otpgo.NewErrorInvalidConfig("missing pin")},
+	}
+
+	for _, c := range cases {
+		if _, err := c.y.Generate(); err != c.expected {
+			t.Errorf("%s: unexpected error\nexpected: %v\n  actual: %v", c.label, c.expected, err)
+		}
+
+		if _, err := c.y.Validate("oriyvkze"); err != c.expected {
+			t.Errorf("%s: unexpected validation error\nexpected: %v\n  actual: %v", c.label, c.expected, err)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package vault
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+
+	"github.com/jltorresm/otpgo/internal/kdf"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	// FormatVersion is the version of the vault file format written by Save.
+	FormatVersion = 1
+
+	saltLength = 32
+	keyLength  = 32
+)
+
+// DefaultKDF holds the scrypt cost parameters used for new vaults.
+var DefaultKDF = KDF{Name: "scrypt", N: 1 << 15, R: 8, P: 1}
+
+// The KDF type describes how the encryption key is derived from the
+// passphrase.
+type KDF struct {
+	Name string `json:"name"`
+	N    int    `json:"n"`
+	R    int    `json:"r"`
+	P    int    `json:"p"`
+	Salt []byte `json:"salt"`
+}
+
+// deriveKey returns the AES-256 key derived from the passphrase.
+func (k KDF) deriveKey(passphrase string) ([]byte, error) {
+	if k.Name != "scrypt" {
+		return nil, ErrorInvalidFile{msg: "unsupported kdf " + k.Name}
+	}
+
+	// scrypt checks the parameters are valid, not that they are affordable.
+	if !kdf.Affordable(k.N, k.R, k.P) {
+		return nil, ErrorInvalidFile{msg: "kdf cost exceeds the supported limits"}
+	}
+
+	key, err := scrypt.Key([]byte(passphrase), k.Salt, k.N, k.R, k.P, keyLength)
+	if err != nil {
+		return nil, ErrorInvalidFile{msg: err.Error()}
+	}
+
+	return key, nil
+}
+
+// The file type is the on-disk representation of a vault. Only the entries
+// are encrypted, the header is authenticated as additional data.
+type file struct {
+	Version int    `json:"version"`
+	KDF     KDF    `json:"kdf"`
+	Nonce   []byte `json:"nonce"`
+	Data    []byte `json:"data"`
+}
+
+// additionalData returns the header bytes bound to the ciphertext.
+func (f *file) additionalData() []byte {
+	header, _ := json.Marshal(struct {
+		Version int `json:"version"`
+		KDF     KDF `json:"kdf"`
+	}{f.Version, f.KDF})
+
+	return header
+}
+
+// seal encrypts the plaintext with AES-GCM using a fresh nonce.
+func (f *file) seal(key, plaintext []byte) error {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return err
+	}
+
+	f.Nonce = make([]byte, aead.NonceSize())
+	if _, err := rand.Read(f.Nonce); err != nil {
+		return err
+	}
+
+	f.Data = aead.Seal(nil, f.Nonce, plaintext, f.additionalData())
+
+	return nil
+}
+
+// open decrypts and authenticates the file data.
+func (f *file) open(key []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(f.Nonce) != aead.NonceSize() {
+		return nil, ErrorInvalidFile{msg: "invalid nonce"}
+	}
+
+	plaintext, err := aead.Open(nil, f.Nonce, f.Data, f.additionalData())
+	if err != nil {
+		return nil, ErrorDecryption{}
+	}
+
+	return plaintext, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+func randomSalt() ([]byte, error) {
+	salt := make([]byte, saltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	return salt, nil
+}
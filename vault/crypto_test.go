@@ -0,0 +1,68 @@
+package vault
+
+import (
+	"testing"
+)
+
+func TestFile_SealOpen(t *testing.T) {
+	kdf := KDF{Name: "scrypt", N: 1 << 10, R: 8, P: 1, Salt: []byte("salt")}
+	key, err := kdf.deriveKey("passphrase")
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		t.FailNow()
+	}
+
+	f := file{Version: FormatVersion, KDF: kdf}
+	if err := f.seal(key, []byte("plaintext")); err != nil {
+		t.Errorf("unexpected error: %s", err)
+		t.FailNow()
+	}
+
+	plaintext, err := f.open(key)
+	if err != nil || string(plaintext) != "plaintext" {
+		t.Errorf("unexpected result: %s, %v", plaintext, err)
+	}
+
+	// The header is authenticated, weakening the kdf must be detected.
+	tampered := f
+	tampered.KDF.N = 2
+	if _, err := tampered.open(key); err != (ErrorDecryption{}) {
+		t.Errorf("unexpected error\nexpected: %v\n  actual: %v", ErrorDecryption{}, err)
+	}
+
+	tampered = f
+	tampered.Nonce = []byte("short")
+	expectedErr := ErrorInvalidFile{msg: "invalid nonce"}
+	if _, err := tampered.open(key); err != expectedErr {
+		t.Errorf("unexpected error\nexpected: %v\n  actual: %v", expectedErr, err)
+	}
+}
+
+func TestKDF_DeriveKey(t *testing.T) {
+	cases := []struct {
+		label       string
+		kdf         KDF
+		expectedErr error
+	}{
+		{"Valid", KDF{Name: "scrypt", N: 1 << 10, R: 8, P: 1}, nil},
+		{"Unknown", KDF{Name: "md5"}, ErrorInvalidFile{msg: "unsupported kdf md5"}},
+		{"Bad Cost", KDF{Name: "scrypt", N: 3, R: 8, P: 1}, ErrorInvalidFile{msg: "scrypt: N must be > 1 and a power of 2"}},
+		{"Memory Limit", KDF{Name: "scrypt", N: 1 << 20, R: 8, P: 1}, ErrorInvalidFile{msg: "kdf cost exceeds the supported limits"}},
+		{"Block Size Limit", KDF{Name: "scrypt", N: 2, R: 1 << 20, P: 1}, ErrorInvalidFile{msg: "kdf cost exceeds the supported limits"}},
+		{"Parallelism Limit", KDF{Name: "scrypt", N: 1 << 10, R: 8, P: 1 << 20}, ErrorInvalidFile{msg: "kdf cost exceeds the supported limits"}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.label, func(t *testing.T) {
+			key, err := c.kdf.deriveKey("passphrase")
+
+			if c.expectedErr != err {
+				t.Errorf("unexpected error\nexpected: %v\n  actual: %v", c.expectedErr, err)
+			}
+
+			if err == nil && len(key) != keyLength {
+				t.Errorf("unexpected key length\nexpected: %d\n  actual: %d", keyLength, len(key))
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package vault
+
+import (
+	"fmt"
+)
+
+// The ErrorEntryNotFound represents a missing vault entry.
+type ErrorEntryNotFound struct {
+	Name string
+}
+
+func (enf ErrorEntryNotFound) Error() string {
+	return fmt.Sprintf("entry not found: %s", enf.Name)
+}
+
+// The ErrorEntryExists represents an entry name that is already taken.
+type ErrorEntryExists struct {
+	Name string
+}
+
+func (ee ErrorEntryExists) Error() string {
+	return fmt.Sprintf("entry already exists: %s", ee.Name)
+}
+
+// The ErrorDecryption is returned when the vault can not be decrypted, either
+// because of a wrong passphrase or because the file was tampered with.
+type ErrorDecryption struct{}
+
+func (ed ErrorDecryption) Error() string {
+	return "unable to decrypt vault: wrong passphrase or corrupted file"
+}
+
+// The ErrorInvalidFile represents a vault file that can not be read.
+type ErrorInvalidFile struct {
+	msg string
+}
+
+func (eif ErrorInvalidFile) Error() string {
+	return fmt.Sprintf("invalid vault file: %s", eif.msg)
+}
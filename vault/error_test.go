@@ -0,0 +1,24 @@
+package vault
+
+import (
+	"testing"
+)
+
+func TestErrors(t *testing.T) {
+	cases := []struct {
+		label    string
+		err      error
+		expected string
+	}{
+		{"Not Found", ErrorEntryNotFound{Name: "work"}, "entry not found: work"},
+		{"Exists", ErrorEntryExists{Name: "work"}, "entry already exists: work"},
+		{"Decryption", ErrorDecryption{}, "unable to decrypt vault: wrong passphrase or corrupted file"},
+		{"Invalid File", ErrorInvalidFile{msg: "an arbitrary error message"}, "invalid vault file: an arbitrary error message"},
+	}
+
+	for _, c := range cases {
+		if c.expected != c.err.Error() {
+			t.Errorf("case %s: unexpected error\nexpected: %s\n  actual: %s", c.label, c.expected, c.err.Error())
+		}
+	}
+}
@@ -0,0 +1,379 @@
+// Package vault stores OTP configurations in a single file encrypted
+// with a passphrase, so that otpgo can be used as an authenticator.
+//
+// The encryption key is derived from the passphrase with scrypt and the entries
+// are sealed with AES-256-GCM. Every change is written to disk immediately,
+// including the counter of event based OTPs after each generated code.
+package vault
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jltorresm/otpgo"
+	"github.com/jltorresm/otpgo/authenticator"
+)
+
+// The Entry type is a single account stored in the vault.
+type Entry struct {
+	Name    string              `json:"name"` // Unique name used to refer to the entry
+	Label   authenticator.Label `json:"label"`
+	OTP     otpgo.OTP           `json:"-"` // Stored along with its type, see otpgo.Envelope
+	Created time.Time           `json:"created"`
+}
+
+// The entryJSON type is the JSON representation of an Entry.
+type entryJSON struct {
+	entryAlias
+	OTP otpgo.Envelope `json:"otp"`
+}
+
+type entryAlias Entry
+
+// MarshalJSON encodes the entry, wrapping the OTP in an otpgo.Envelope.
+func (e Entry) MarshalJSON() ([]byte, error) {
+	return json.Marshal(entryJSON{entryAlias: entryAlias(e), OTP: otpgo.Envelope{OTP: e.OTP}})
+}
+
+// UnmarshalJSON decodes an entry encoded by MarshalJSON. Entries without an
+// OTP are rejected.
+func (e *Entry) UnmarshalJSON(data []byte) error {
+	raw := entryJSON{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*e = Entry(raw.entryAlias)
+	e.OTP = raw.OTP.OTP
+
+	if e.OTP == nil {
+		return fmt.Errorf("entry %q has no otp", e.Name)
+	}
+
+	return nil
+}
+
+// Type returns the OTP type of the entry, e.g.: "hotp" or "totp".
+func (e *Entry) Type() string {
+	if e.OTP == nil {
+		return ""
+	}
+
+	return e.OTP.Type()
+}
+
+// clone returns a deep copy of the entry, so callers can't modify the vault
+// contents behind its back.
+func (e *Entry) clone() Entry {
+	c := *e
+	c.OTP = otpgo.Clone(e.OTP)
+
+	return c
+}
+
+// The Vault type holds the decrypted entries and the key needed to write them
+// back. It is safe for concurrent use.
+type Vault struct {
+	mu      sync.Mutex
+	path    string
+	kdf     KDF
+	key     []byte
+	entries map[string]*Entry
+}
+
+// Create initializes a new empty vault file at path, protected by passphrase.
+// It fails if the file already exists.
+func Create(path, passphrase string) (*Vault, error) {
+	if _, err := os.Stat(path); err == nil {
+		return nil, &os.PathError{Op: "create", Path: path, Err: os.ErrExist}
+	}
+
+	kdf := DefaultKDF
+
+	var err error
+	if kdf.Salt, err = randomSalt(); err != nil {
+		return nil, err
+	}
+
+	key, err := kdf.deriveKey(passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	v := &Vault{path: path, kdf: kdf, key: key, entries: map[string]*Entry{}}
+	if err := v.save(); err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+// Open reads and decrypts the vault file at path.
+func Open(path, passphrase string) (*Vault, error) {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	f := file{}
+	if err := json.Unmarshal(raw, &f); err != nil {
+		return nil, ErrorInvalidFile{msg: err.Error()}
+	}
+
+	if f.Version != FormatVersion {
+		return nil, ErrorInvalidFile{msg: "unsupported version"}
+	}
+
+	key, err := f.KDF.deriveKey(passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := f.open(key)
+	if err != nil {
+		return nil, err
+	}
+
+	var content struct {
+		Entries []*Entry `json:"entries"`
+	}
+	if err := json.Unmarshal(plaintext, &content); err != nil {
+		return nil, ErrorInvalidFile{msg: err.Error()}
+	}
+
+	v := &Vault{path: path, kdf: f.KDF, key: key, entries: map[string]*Entry{}}
+	for _, e := range content.Entries {
+		v.entries[e.Name] = e
+	}
+
+	return v, nil
+}
+
+// Add stores a new entry. Any empty OTP parameter is filled with its default
+// value, and an empty key is replaced by a random one.
+func (v *Vault) Add(e Entry) error {
+	if e.Name == "" {
+		return errors.New("entry name must not be empty")
+	}
+
+	if e.OTP == nil {
+		return errors.New("entry must hold an otp")
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if _, ok := v.entries[e.Name]; ok {
+		return ErrorEntryExists{Name: e.Name}
+	}
+
+	entry := e.clone()
+	if entry.Created.IsZero() {
+		entry.Created = time.Now().UTC()
+	}
+
+	// Generating a code validates the key and populates the defaults. Event
+	// based OTPs are not advanced.
+	if _, err := entry.OTP.Generate(); err != nil {
+		return err
+	}
+
+	v.entries[entry.Name] = &entry
+
+	return v.saveOrRollback(func() { delete(v.entries, entry.Name) })
+}
+
+// ImportUri adds the account described by a key URI. When name is empty the
+// label is used as the entry name.
+func (v *Vault) ImportUri(name, uri string) (Entry, error) {
+	ku, err := authenticator.ParseKeyUri(uri)
+	if err != nil {
+		return Entry{}, err
+	}
+
+	e := Entry{Name: name, Label: ku.Label}
+	if e.Name == "" {
+		e.Name = ku.Label.AccountName
+		if ku.Label.Issuer != "" {
+			e.Name = ku.Label.Issuer + ":" + e.Name
+		}
+	}
+
+	if e.OTP, err = otpgo.OTPFromKeyUri(ku); err != nil {
+		return Entry{}, err
+	}
+
+	if err := v.Add(e); err != nil {
+		return Entry{}, err
+	}
+
+	return v.Get(e.Name)
+}
+
+// Get returns a copy of the named entry.
+func (v *Vault) Get(name string) (Entry, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	e, ok := v.entries[name]
+	if !ok {
+		return Entry{}, ErrorEntryNotFound{Name: name}
+	}
+
+	return e.clone(), nil
+}
+
+// List returns a copy of all entries sorted by name.
+func (v *Vault) List() []Entry {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	list := make([]Entry, 0, len(v.entries))
+	for _, e := range v.entries {
+		list = append(list, e.clone())
+	}
+
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+
+	return list
+}
+
+// Remove deletes the named entry.
+func (v *Vault) Remove(name string) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	e, ok := v.entries[name]
+	if !ok {
+		return ErrorEntryNotFound{Name: name}
+	}
+
+	delete(v.entries, name)
+
+	return v.saveOrRollback(func() { v.entries[name] = e })
+}
+
+// Rename changes the name of an entry, the new name must not be taken.
+func (v *Vault) Rename(oldName, newName string) error {
+	if newName == "" {
+		return errors.New("entry name must not be empty")
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	e, ok := v.entries[oldName]
+	if !ok {
+		return ErrorEntryNotFound{Name: oldName}
+	}
+
+	if _, ok := v.entries[newName]; ok {
+		return ErrorEntryExists{Name: newName}
+	}
+
+	delete(v.entries, oldName)
+	e.Name = newName
+	v.entries[newName] = e
+
+	return v.saveOrRollback(func() {
+		delete(v.entries, newName)
+		e.Name = oldName
+		v.entries[oldName] = e
+	})
+}
+
+// Generate returns the current code for the named entry. For event based
+// entries, like HOTP, the counter is advanced and persisted before the code is
+// returned, so a code is never handed out twice.
+func (v *Vault) Generate(name string) (string, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	e, ok := v.entries[name]
+	if !ok {
+		return "", ErrorEntryNotFound{Name: name}
+	}
+
+	code, err := e.OTP.Generate()
+	if err != nil {
+		return "", err
+	}
+
+	eb, ok := e.OTP.(otpgo.EventBased)
+	if !ok {
+		return code, nil
+	}
+
+	previous := otpgo.Clone(eb)
+	eb.Advance()
+	if err := v.saveOrRollback(func() { e.OTP = previous }); err != nil {
+		return "", err
+	}
+
+	return code, nil
+}
+
+// saveOrRollback writes the vault, undoing the in-memory change if it fails.
+func (v *Vault) saveOrRollback(rollback func()) error {
+	err := v.save()
+	if err != nil {
+		rollback()
+	}
+
+	return err
+}
+
+// save encrypts the entries and atomically replaces the vault file.
+func (v *Vault) save() error {
+	content := struct {
+		Entries []*Entry `json:"entries"`
+	}{Entries: make([]*Entry, 0, len(v.entries))}
+	for _, e := range v.entries {
+		content.Entries = append(content.Entries, e)
+	}
+	sort.Slice(content.Entries, func(i, j int) bool { return content.Entries[i].Name < content.Entries[j].Name })
+
+	plaintext, err := json.Marshal(content)
+	if err != nil {
+		return err
+	}
+
+	f := file{Version: FormatVersion, KDF: v.kdf}
+	if err := f.seal(v.key, plaintext); err != nil {
+		return err
+	}
+
+	raw, err := json.Marshal(f)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(v.path), filepath.Base(v.path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(raw); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), v.path)
+}
@@ -0,0 +1,398 @@
+package vault
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/jltorresm/otpgo"
+	"github.com/jltorresm/otpgo/authenticator"
+	"github.com/jltorresm/otpgo/config"
+)
+
+const (
+	testPassphrase = "correct horse battery staple"
+	testKey        = "73QK7D3A3PIZ6NUQQBF4BNFYQBRVUHUP"
+)
+
+func init() {
+	// Keep the key derivation cheap for tests.
+	DefaultKDF.N = 1 << 10
+}
+
+func TestCreate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "otpgo-vault")
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		t.FailNow()
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	path := filepath.Join(dir, "vault.json")
+	v, err := Create(path, testPassphrase)
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		t.FailNow()
+	}
+
+	if len(v.List()) != 0 {
+		t.Errorf("expected empty vault, got %d entries", len(v.List()))
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		t.FailNow()
+	}
+
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("unexpected file mode\nexpected: %s\n  actual: %s", os.FileMode(0600), info.Mode().Perm())
+	}
+
+	if _, err := Create(path, testPassphrase); !os.IsExist(err) {
+		t.Errorf("expected file exists error, got: %v", err)
+	}
+}
+
+func TestOpen(t *testing.T) {
+	dir, err := ioutil.TempDir("", "otpgo-vault")
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		t.FailNow()
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	path := filepath.Join(dir, "vault.json")
+	v, err := Create(path, testPassphrase)
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		t.FailNow()
+	}
+
+	err = v.Add(Entry{
+		Name:  "work",
+		Label: authenticator.Label{AccountName: "john", Issuer: "Acme"},
+		OTP:   &otpgo.TOTP{Key: testKey, Algorithm: config.HmacSHA256},
+	})
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		t.FailNow()
+	}
+
+	raw, _ := ioutil.ReadFile(path)
+	if bytes.Contains(raw, []byte(testKey)) || bytes.Contains(raw, []byte("Acme")) {
+		t.Error("expected the vault file to be encrypted")
+	}
+
+	reopened, err := Open(path, testPassphrase)
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		t.FailNow()
+	}
+
+	e, err := reopened.Get("work")
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		t.FailNow()
+	}
+
+	expected := otpgo.TOTP{Key: testKey, Period: 30, Delay: 1, Algorithm: config.HmacSHA256, Length: config.Length6}
+	if totp, ok := e.OTP.(*otpgo.TOTP); !ok || expected != *totp || e.Label.Issuer != "Acme" || e.Type() != "totp" {
+		t.Errorf("unexpected entry\nexpected: %+v\n  actual: %+v", expected, e.OTP)
+	}
+
+	if _, err := Open(path, "wrong passphrase"); err != (ErrorDecryption{}) {
+		t.Errorf("unexpected error\nexpected: %v\n  actual: %v", ErrorDecryption{}, err)
+	}
+
+	if err := ioutil.WriteFile(path, []byte("{}"), 0600); err != nil {
+		t.Errorf("unexpected error: %s", err)
+		t.FailNow()
+	}
+
+	expectedErr := ErrorInvalidFile{msg: "unsupported version"}
+	if _, err := Open(path, testPassphrase); err != expectedErr {
+		t.Errorf("unexpected error\nexpected: %v\n  actual: %v", expectedErr, err)
+	}
+
+	// A crafted cost is rejected before deriving the key.
+	raw, _ = json.Marshal(file{Version: FormatVersion, KDF: KDF{Name: "scrypt", N: 1 << 30, R: 8, P: 1}})
+	if err := ioutil.WriteFile(path, raw, 0600); err != nil {
+		t.Errorf("unexpected error: %s", err)
+		t.FailNow()
+	}
+
+	expectedErr = ErrorInvalidFile{msg: "kdf cost exceeds the supported limits"}
+	if _, err := Open(path, testPassphrase); err != expectedErr {
+		t.Errorf("unexpected error\nexpected: %v\n  actual: %v", expectedErr, err)
+	}
+}
+
+func TestOpen_Entries(t *testing.T) {
+	dir, err := ioutil.TempDir("", "otpgo-vault")
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		t.FailNow()
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	kdf := DefaultKDF
+	kdf.Salt = []byte("salt")
+	key, err := kdf.deriveKey(testPassphrase)
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		t.FailNow()
+	}
+
+	cases := []struct {
+		label       string
+		version     int
+		entries     string
+		expectedOTP otpgo.OTP
+		expectedErr error
+	}{
+		{
+			"HOTP",
+			FormatVersion,
+			`[{"name":"work","otp":{"type":"hotp","config":{"key":"` + testKey + `","counter":1,"leeway":1,"algorithm":"SHA1","length":6}}}]`,
+			&otpgo.HOTP{Key: testKey, Counter: 1, Leeway: 1, Algorithm: config.HmacSHA1, Length: config.Length6},
+			nil,
+		},
+		{"Null OTP", FormatVersion, `[{"name":"work","otp":null}]`, nil, ErrorInvalidFile{msg: `entry "work" has no otp`}},
+		{"Missing OTP", FormatVersion, `[{"name":"work"}]`, nil, ErrorInvalidFile{msg: `entry "work" has no otp`}},
+		{"Future Version", FormatVersion + 1, `[]`, nil, ErrorInvalidFile{msg: "unsupported version"}},
+	}
+
+	for _, c := range cases {
+		f := file{Version: c.version, KDF: kdf}
+		if err := f.seal(key, []byte(`{"entries":`+c.entries+`}`)); err != nil {
+			t.Errorf("case %s: unexpected error: %s", c.label, err)
+			continue
+		}
+
+		raw, _ := json.Marshal(f)
+		path := filepath.Join(dir, "vault.json")
+		if err := ioutil.WriteFile(path, raw, 0600); err != nil {
+			t.Errorf("case %s: unexpected error: %s", c.label, err)
+			continue
+		}
+
+		v, err := Open(path, testPassphrase)
+		if c.expectedErr != err {
+			t.Errorf("case %s: unexpected error\nexpected: %v\n  actual: %v", c.label, c.expectedErr, err)
+		}
+
+		if err != nil {
+			continue
+		}
+
+		e, _ := v.Get("work")
+		if !reflect.DeepEqual(c.expectedOTP, e.OTP) {
+			t.Errorf("case %s: unexpected otp\nexpected: %+v\n  actual: %+v", c.label, c.expectedOTP, e.OTP)
+		}
+	}
+}
+
+func TestVault_Add(t *testing.T) {
+	dir, err := ioutil.TempDir("", "otpgo-vault")
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		t.FailNow()
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	v, err := Create(filepath.Join(dir, "vault.json"), testPassphrase)
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		t.FailNow()
+	}
+
+	cases := []struct {
+		label       string
+		entry       Entry
+		expectedErr string
+	}{
+		{"Valid", Entry{Name: "a", OTP: &otpgo.TOTP{Key: testKey}}, ""},
+		{"Random Key", Entry{Name: "b", OTP: &otpgo.HOTP{}}, ""},
+		{"Duplicate", Entry{Name: "a", OTP: &otpgo.TOTP{Key: testKey}}, "entry already exists: a"},
+		{"No Name", Entry{OTP: &otpgo.TOTP{Key: testKey}}, "entry name must not be empty"},
+		{"No OTP", Entry{Name: "c"}, "entry must hold an otp"},
+		{"Bad Key", Entry{Name: "c", OTP: &otpgo.TOTP{Key: "not-base-32"}}, "invalid key: illegal base32 data at input byte 3"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.label, func(t *testing.T) {
+			err := v.Add(c.entry)
+
+			if (err == nil && c.expectedErr != "") || (err != nil && c.expectedErr != err.Error()) {
+				t.Errorf("unexpected error\nexpected: %s\n  actual: %v", c.expectedErr, err)
+			}
+		})
+	}
+
+	list := v.List()
+	if len(list) != 2 || list[0].Name != "a" || list[1].Name != "b" {
+		t.Errorf("unexpected entries: %+v", list)
+	}
+
+	if list[1].OTP.(*otpgo.HOTP).Key == "" {
+		t.Error("expected a random key to be generated")
+	}
+
+	// Modifying the returned entries must not modify the vault.
+	list[0].OTP.(*otpgo.TOTP).Key = "CHANGED"
+	if e, _ := v.Get("a"); e.OTP.(*otpgo.TOTP).Key != testKey {
+		t.Errorf("unexpected key\nexpected: %s\n  actual: %s", testKey, e.OTP.(*otpgo.TOTP).Key)
+	}
+}
+
+func TestVault_RemoveAndRename(t *testing.T) {
+	dir, err := ioutil.TempDir("", "otpgo-vault")
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		t.FailNow()
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	path := filepath.Join(dir, "vault.json")
+	v, err := Create(path, testPassphrase)
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		t.FailNow()
+	}
+
+	for _, name := range []string{"a", "b", "c"} {
+		if err := v.Add(Entry{Name: name, OTP: &otpgo.TOTP{Key: testKey}}); err != nil {
+			t.Errorf("unexpected error: %s", err)
+			t.FailNow()
+		}
+	}
+
+	if err := v.Remove("b"); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	if err := v.Remove("b"); err != (ErrorEntryNotFound{Name: "b"}) {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if err := v.Rename("a", "c"); err != (ErrorEntryExists{Name: "c"}) {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if err := v.Rename("x", "y"); err != (ErrorEntryNotFound{Name: "x"}) {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if err := v.Rename("a", "z"); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	reopened, err := Open(path, testPassphrase)
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		t.FailNow()
+	}
+
+	list := reopened.List()
+	if len(list) != 2 || list[0].Name != "c" || list[1].Name != "z" {
+		t.Errorf("unexpected entries: %+v", list)
+	}
+}
+
+func TestVault_Generate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "otpgo-vault")
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		t.FailNow()
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	path := filepath.Join(dir, "vault.json")
+	v, err := Create(path, testPassphrase)
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		t.FailNow()
+	}
+
+	err = v.Add(Entry{
+		Name: "hotp",
+		OTP:  &otpgo.HOTP{Key: testKey, Counter: 363, Algorithm: config.HmacSHA256},
+	})
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		t.FailNow()
+	}
+
+	code, err := v.Generate("hotp")
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	if code != "363033" {
+		t.Errorf("unexpected code\nexpected: %s\n  actual: %s", "363033", code)
+	}
+
+	reopened, err := Open(path, testPassphrase)
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		t.FailNow()
+	}
+
+	e, _ := reopened.Get("hotp")
+	if counter := e.OTP.(*otpgo.HOTP).Counter; counter != 364 {
+		t.Errorf("unexpected counter\nexpected: %d\n  actual: %d", 364, counter)
+	}
+
+	next, _ := reopened.Generate("hotp")
+	if next == code {
+		t.Error("expected a different code after generation")
+	}
+
+	if _, err := v.Generate("missing"); err != (ErrorEntryNotFound{Name: "missing"}) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestVault_ImportUri(t *testing.T) {
+	dir, err := ioutil.TempDir("", "otpgo-vault")
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		t.FailNow()
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	v, err := Create(filepath.Join(dir, "vault.json"), testPassphrase)
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		t.FailNow()
+	}
+
+	e, err := v.ImportUri("", "otpauth://totp/Acme:john?secret="+testKey+"&issuer=Acme&digits=8")
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		t.FailNow()
+	}
+
+	if e.Name != "Acme:john" || e.OTP.(*otpgo.TOTP).Length != config.Length8 {
+		t.Errorf("unexpected entry: %+v", e)
+	}
+
+	e, err = v.ImportUri("counter", "otpauth://hotp/john?secret="+testKey+"&counter=7")
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+		t.FailNow()
+	}
+
+	if e.Name != "counter" || e.OTP.(*otpgo.HOTP).Counter != 7 {
+		t.Errorf("unexpected entry: %+v", e)
+	}
+
+	if _, err := v.ImportUri("", "otpauth://motp/john?secret=ABC"); err == nil {
+		t.Error("expected unsupported type error")
+	}
+}